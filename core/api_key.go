@@ -0,0 +1,53 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetApiKeyFromRequest get api key from "Authorization: ApiKey <key>" or "X-API-Key: <key>" header
+func GetApiKeyFromRequest(c *fiber.Ctx) (string, bool) {
+	if apiKey := c.Get("X-API-Key"); apiKey != "" {
+		return apiKey, true
+	}
+	arrayHeader := strings.Fields(c.Get("Authorization"))
+	if len(arrayHeader) == 2 && arrayHeader[0] == "ApiKey" {
+		return arrayHeader[1], true
+	}
+	return "", false
+}
+
+// GetUserFromApiKey get user and api key from raw api key,
+// expired or deleted api key (or api key of inactive user) is invalid. last used of api key updated at most once a minute
+func GetUserFromApiKey(tx *gorm.DB, rawKey string) (models.User, models.ApiKey, error) {
+	user := models.User{}
+	apiKey := models.ApiKey{}
+	if err := tx.Where("key_hash = ? AND deleted_at IS NULL", HashToken(rawKey)).First(&apiKey).Error; err != nil {
+		return user, apiKey, err
+	}
+	now := time.Now()
+	if apiKey.ExpiredAt != nil && now.After(*apiKey.ExpiredAt) {
+		return user, apiKey, errors.New("api key expired")
+	}
+
+	if err := tx.Where("id = ? AND deleted_at IS NULL", apiKey.UserID).First(&user).Error; err != nil {
+		return user, apiKey, err
+	}
+	if err := CheckUserStatus(user); err != nil {
+		return user, apiKey, err
+	}
+
+	// Update last used
+	if err := tx.Model(&models.ApiKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKey.ID, now.Add(-time.Minute)).
+		Update("last_used_at", now).Error; err != nil {
+		return user, apiKey, err
+	}
+
+	return user, apiKey, nil
+}
@@ -0,0 +1,41 @@
+package core_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetApiKeyFromRequest(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		apiKey, isFound := core.GetApiKeyFromRequest(c)
+		if !isFound {
+			return c.SendStatus(401)
+		}
+		return c.SendString(apiKey)
+	})
+
+	headers := []map[string]string{
+		{"X-API-Key": "secret"},
+		{"Authorization": "ApiKey secret"},
+	}
+	for _, header := range headers {
+		req, _ := http.NewRequest("GET", "/", nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
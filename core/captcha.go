@@ -0,0 +1,17 @@
+package core
+
+// CaptchaVerifier verify captcha response token submitted by client (recaptcha, hcaptcha, turnstile, etc)
+type CaptchaVerifier interface {
+	Verify(token string, ip string) (bool, error)
+}
+
+// RegistrationCaptchaVerifier used by /auth/register,
+// replace it with real captcha verifier on production
+var RegistrationCaptchaVerifier CaptchaVerifier = NoopCaptchaVerifier{}
+
+// NoopCaptchaVerifier accept every token, captcha disabled
+type NoopCaptchaVerifier struct{}
+
+func (NoopCaptchaVerifier) Verify(token string, ip string) (bool, error) {
+	return true, nil
+}
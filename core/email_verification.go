@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+const EmailVerificationPolicyNone = "none"
+const EmailVerificationPolicyRefuseLogin = "refuse_login"
+const EmailVerificationPolicyLimitScope = "limit_scope"
+
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// ApplyEmailVerificationPolicy check EMAIL_VERIFICATION_POLICY before token issued to user,
+// return scope of the token. Unverified user refused on refuse_login policy,
+// and on limit_scope policy the scope limited to EMAIL_UNVERIFIED_SCOPE
+// (refused if requested scope has nothing in common with it)
+func ApplyEmailVerificationPolicy(user models.User, scope string) (string, error) {
+	if user.EmailVerifiedAt != nil {
+		return scope, nil
+	}
+
+	switch settings.EMAIL_VERIFICATION_POLICY {
+	case EmailVerificationPolicyRefuseLogin:
+		return "", ErrEmailNotVerified
+	case EmailVerificationPolicyLimitScope:
+		allowedScopes := SplitSpaceSeparated(settings.EMAIL_UNVERIFIED_SCOPE)
+		requestedScopes := SplitSpaceSeparated(scope)
+		if len(requestedScopes) == 0 {
+			requestedScopes = allowedScopes
+		}
+		scopes := []string{}
+		for _, item := range requestedScopes {
+			if IsSubset([]string{item}, allowedScopes) {
+				scopes = append(scopes, item)
+			}
+		}
+		if len(scopes) == 0 {
+			return "", ErrEmailNotVerified
+		}
+		return strings.Join(scopes, " "), nil
+	default:
+		return scope, nil
+	}
+}
@@ -0,0 +1,47 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyEmailVerificationPolicy(t *testing.T) {
+	now := time.Now()
+	verifiedUser := models.User{EmailVerifiedAt: &now}
+	unverifiedUser := models.User{}
+	settings.EMAIL_UNVERIFIED_SCOPE = "user:read"
+	defer func() { settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyNone }()
+
+	// none policy
+	settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyNone
+	scope, err := core.ApplyEmailVerificationPolicy(unverifiedUser, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", scope)
+
+	// refuse_login policy
+	settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyRefuseLogin
+	_, err = core.ApplyEmailVerificationPolicy(unverifiedUser, "")
+	assert.ErrorIs(t, err, core.ErrEmailNotVerified)
+	scope, err = core.ApplyEmailVerificationPolicy(verifiedUser, "user:update")
+	assert.Nil(t, err)
+	assert.Equal(t, "user:update", scope)
+
+	// limit_scope policy
+	settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyLimitScope
+	scope, err = core.ApplyEmailVerificationPolicy(unverifiedUser, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "user:read", scope)
+	scope, err = core.ApplyEmailVerificationPolicy(unverifiedUser, "user:read user:delete")
+	assert.Nil(t, err)
+	assert.Equal(t, "user:read", scope)
+	_, err = core.ApplyEmailVerificationPolicy(unverifiedUser, "user:delete")
+	assert.ErrorIs(t, err, core.ErrEmailNotVerified)
+	scope, err = core.ApplyEmailVerificationPolicy(verifiedUser, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", scope)
+}
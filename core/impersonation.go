@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// ImpersonationLocalsKey fiber locals key of Impersonation, set when request authorized with impersonation token
+const ImpersonationLocalsKey = "impersonation"
+
+var ErrImpersonatorInvalid = errors.New("impersonator not found, inactive or not superuser")
+
+// AuditLogger log impersonation started, ended and every impersonated request
+var AuditLogger = log.New(os.Stdout, "[audit] ", log.LstdFlags)
+
+// Impersonation superuser (actor) impersonating the user on a request
+type Impersonation struct {
+	ActorID       string
+	ActorUsername string
+	UserID        string
+	Username      string
+}
+
+// GenerateImpersonationJWTToken generate access token of user with act claim ({"sub": <superuser id>}, RFC 8693)
+// expired after IMPERSONATION_EXPIRE_MINUTES. Return value (token, expired_at, error)
+func GenerateImpersonationJWTToken(user models.User, actor models.User) (string, time.Time, error) {
+	// Generate Payload
+	expiredAt := time.Now().Add(time.Minute * time.Duration(settings.IMPERSONATION_EXPIRE_MINUTES))
+	tok, err := jwt.NewBuilder().
+		JwtID(uuid.NewString()).
+		IssuedAt(time.Now()).
+		Expiration(expiredAt).
+		Build()
+	if err != nil {
+		return "", expiredAt, err
+	}
+	tok.Set("id", user.ID)
+	tok.Set("email", user.Email)
+	tok.Set("act", map[string]interface{}{"sub": actor.ID})
+
+	signed, err := signJWTToken(tok)
+	return signed, expiredAt, err
+}
+
+// getImpersonatorFromJWTToken get superuser on act claim, nil if token has no act claim.
+// return ErrImpersonatorInvalid if the superuser deleted, deactivated or no longer superuser
+func getImpersonatorFromJWTToken(tok jwt.Token) (*models.User, error) {
+	act, isActFound := tok.Get("act")
+	if !isActFound {
+		return nil, nil
+	}
+	claims, ok := act.(map[string]interface{})
+	if !ok || claims["sub"] == nil {
+		return nil, ErrImpersonatorInvalid
+	}
+
+	impersonator := models.User{}
+	if err := models.DBConn.Where("id = ? AND deleted_at IS NULL", fmt.Sprint(claims["sub"])).First(&impersonator).Error; err != nil {
+		return nil, ErrImpersonatorInvalid
+	}
+	if CheckUserStatus(impersonator) != nil || !impersonator.IsSuperuser {
+		return nil, ErrImpersonatorInvalid
+	}
+	return &impersonator, nil
+}
+
+// setImpersonationLocals keep Impersonation on fiber locals so the request logged by audit log
+func setImpersonationLocals(c *fiber.Ctx, user models.User, impersonator *models.User) {
+	if impersonator == nil {
+		return
+	}
+	c.Locals(ImpersonationLocalsKey, Impersonation{
+		ActorID:       impersonator.ID,
+		ActorUsername: impersonator.Username,
+		UserID:        user.ID,
+		Username:      user.Username,
+	})
+}
+
+// GetImpersonation get Impersonation of request authorized with impersonation token
+func GetImpersonation(c *fiber.Ctx) (Impersonation, bool) {
+	impersonation, ok := c.Locals(ImpersonationLocalsKey).(Impersonation)
+	return impersonation, ok
+}
+
+// IsImpersonationJWTToken check valid jwt token has act claim
+func IsImpersonationJWTToken(jwtToken string) bool {
+	tok, err := ParseJWTToken(jwtToken)
+	if err != nil {
+		return false
+	}
+	_, isActFound := tok.Get("act")
+	return isActFound
+}
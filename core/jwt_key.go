@@ -0,0 +1,185 @@
+package core
+
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// JWTKey key used to sign and verify jwt token
+type JWTKey struct {
+	KeyID      string
+	Status     string
+	Algorithm  jwa.SignatureAlgorithm
+	SigningKey jwk.Key
+	VerifyKey  jwk.Key
+}
+
+var jwtKeyCache struct {
+	mu       sync.Mutex
+	cacheKey string
+	key      JWTKey
+}
+
+// GetJWTKey return jwt key based on settings,
+// key loaded from file only once unless settings changed
+func GetJWTKey() (JWTKey, error) {
+	jwtKeyCache.mu.Lock()
+	defer jwtKeyCache.mu.Unlock()
+
+	cacheKey := settings.JWT_ALGORITHM + "|" + settings.JWT_SECRET + "|" +
+		settings.JWT_PRIVATE_KEY_PATH + "|" + settings.JWT_PUBLIC_KEY_PATH
+	if jwtKeyCache.cacheKey == cacheKey {
+		return jwtKeyCache.key, nil
+	}
+
+	key, err := LoadJWTKey(
+		settings.JWT_ALGORITHM, settings.JWT_SECRET,
+		settings.JWT_PRIVATE_KEY_PATH, settings.JWT_PUBLIC_KEY_PATH,
+	)
+	if err != nil {
+		return JWTKey{}, err
+	}
+	jwtKeyCache.cacheKey = cacheKey
+	jwtKeyCache.key = key
+	return key, nil
+}
+
+// LoadJWTKey load jwt key, HS256 use secret and
+// asymmetric algorithm (RS256, ES256, EdDSA) use pem file.
+// if publicKeyPath empty public key derived from private key
+func LoadJWTKey(algorithm string, secret string, privateKeyPath string, publicKeyPath string) (JWTKey, error) {
+	if algorithm == "" || algorithm == jwa.HS256.String() {
+		return NewJWTKey(algorithm, secret, nil, nil)
+	}
+
+	// Private key
+	if privateKeyPath == "" {
+		return JWTKey{}, errors.New("JWT_PRIVATE_KEY_PATH required for " + algorithm)
+	}
+	privateKeyPEM, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return JWTKey{}, err
+	}
+
+	// Public key
+	var publicKeyPEM []byte
+	if publicKeyPath != "" {
+		publicKeyPEM, err = os.ReadFile(publicKeyPath)
+		if err != nil {
+			return JWTKey{}, err
+		}
+	}
+
+	return NewJWTKey(algorithm, "", privateKeyPEM, publicKeyPEM)
+}
+
+// NewJWTKey create jwt key from secret (HS256) or pem encoded key (RS256, ES256, EdDSA),
+// key id is the key thumbprint
+func NewJWTKey(algorithm string, secret string, privateKeyPEM []byte, publicKeyPEM []byte) (JWTKey, error) {
+	alg := jwa.SignatureAlgorithm(algorithm)
+	if algorithm == "" {
+		alg = jwa.HS256
+	}
+
+	if alg == jwa.HS256 {
+		key, err := jwk.FromRaw([]byte(secret))
+		if err != nil {
+			return JWTKey{}, err
+		}
+		keyId, err := jwkThumbprint(key)
+		if err != nil {
+			return JWTKey{}, err
+		}
+		key.Set(jwk.KeyIDKey, keyId)
+		key.Set(jwk.AlgorithmKey, alg)
+		return JWTKey{KeyID: keyId, Algorithm: alg, SigningKey: key, VerifyKey: key}, nil
+	}
+
+	var keyType jwa.KeyType
+	switch alg {
+	case jwa.RS256:
+		keyType = jwa.RSA
+	case jwa.ES256:
+		keyType = jwa.EC
+	case jwa.EdDSA:
+		keyType = jwa.OKP
+	default:
+		return JWTKey{}, errors.New("unsupported jwt algorithm " + algorithm)
+	}
+
+	// Private key
+	privateKey, err := jwk.ParseKey(privateKeyPEM, jwk.WithPEM(true))
+	if err != nil {
+		return JWTKey{}, err
+	}
+	var isPrivateKey bool
+	switch privateKey := privateKey.(type) {
+	case jwk.RSAPrivateKey:
+		isPrivateKey = keyType == jwa.RSA
+	case jwk.ECDSAPrivateKey:
+		isPrivateKey = keyType == jwa.EC && privateKey.Crv() == jwa.P256
+	case jwk.OKPPrivateKey:
+		isPrivateKey = keyType == jwa.OKP && privateKey.Crv() == jwa.Ed25519
+	}
+	if !isPrivateKey {
+		return JWTKey{}, errors.New("not valid private key for " + algorithm)
+	}
+
+	// Public key
+	var publicKey jwk.Key
+	if publicKeyPEM != nil {
+		publicKey, err = jwk.ParseKey(publicKeyPEM, jwk.WithPEM(true))
+	} else {
+		publicKey, err = jwk.PublicKeyOf(privateKey)
+	}
+	if err != nil {
+		return JWTKey{}, err
+	}
+	if publicKey.KeyType() != keyType {
+		return JWTKey{}, errors.New("public key type not match with " + algorithm)
+	}
+	keyId, err := jwkThumbprint(publicKey)
+	if err != nil {
+		return JWTKey{}, err
+	}
+	privateKey.Set(jwk.KeyIDKey, keyId)
+	publicKey.Set(jwk.KeyIDKey, keyId)
+	publicKey.Set(jwk.AlgorithmKey, alg)
+	publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature)
+
+	return JWTKey{KeyID: keyId, Algorithm: alg, SigningKey: privateKey, VerifyKey: publicKey}, nil
+}
+
+func jwkThumbprint(key jwk.Key) (string, error) {
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// GetJWKS return public keys of every non retired key as json web key set,
+// symmetric key (HS256) never published
+func GetJWKS() (jwk.Set, error) {
+	set := jwk.NewSet()
+	keys, err := GetJWTKeys()
+	if err != nil {
+		return set, err
+	}
+	for _, key := range keys {
+		if key.Algorithm == jwa.HS256 {
+			continue
+		}
+		if err := set.AddKey(key.VerifyKey); err != nil {
+			return set, err
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,92 @@
+package core_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePrivateKeyPEM(t *testing.T, privateKey interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	path := filepath.Join(t.TempDir(), "private.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	return path
+}
+
+func TestAsymmetricJWTToken(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	defer settings.InitiateSettings("../.env")
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	testCases := map[string]interface{}{
+		"RS256": rsaKey,
+		"ES256": ecKey,
+		"EdDSA": edKey,
+	}
+
+	for algorithm, privateKey := range testCases {
+		settings.JWT_ALGORITHM = algorithm
+		settings.JWT_PRIVATE_KEY_PATH = writePrivateKeyPEM(t, privateKey)
+
+		// sign and verify token
+		token, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+		assert.Nil(t, err, algorithm)
+		id, email, err := core.GetPayloadFromJWTToken(token)
+		assert.Nil(t, err, algorithm)
+		assert.Equal(t, "aaaaa-bbbbb-ccccc", id, algorithm)
+		assert.Equal(t, "bimaadi419@gmail.com", email, algorithm)
+
+		// only public key published
+		set, err := core.GetJWKS()
+		assert.Nil(t, err, algorithm)
+		assert.Equal(t, 1, set.Len(), algorithm)
+		setJson, _ := json.Marshal(set)
+		jwks := map[string][]map[string]interface{}{}
+		json.Unmarshal(setJson, &jwks)
+		assert.Equal(t, algorithm, jwks["keys"][0]["alg"], algorithm)
+		assert.NotContains(t, jwks["keys"][0], "d", algorithm)
+	}
+}
+
+func TestLoadJWTKeyInvalidKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	path := writePrivateKeyPEM(t, ecKey)
+
+	_, err := core.LoadJWTKey("RS256", "", path, "")
+	assert.NotNil(t, err)
+	_, err = core.LoadJWTKey("ES256", "", "", "")
+	assert.NotNil(t, err)
+	_, err = core.LoadJWTKey("none", "", path, "")
+	assert.NotNil(t, err)
+	_, err = core.LoadJWTKey("ES256", "", path, "")
+	assert.Nil(t, err)
+}
+
+func TestHS256JWKSEmpty(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	settings.JWT_ALGORITHM = "HS256"
+
+	set, err := core.GetJWKS()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, set.Len())
+}
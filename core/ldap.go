@@ -0,0 +1,161 @@
+package core
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapTimeout of connecting and every request to LDAP server
+const ldapTimeout = 10 * time.Second
+
+var ErrLDAPInvalidCredentials = errors.New("invalid ldap credentials")
+
+// LDAPIdentity user entry on LDAP directory, Groups is values of LDAP_GROUP_ATTRIBUTE (group dn)
+type LDAPIdentity struct {
+	DN       string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+// AuthenticateLDAP search user entry by LDAP_USER_FILTER under LDAP_BASE_DN then bind as the entry with password.
+// return ErrLDAPInvalidCredentials if entry not found, more than one entry found or wrong password
+func AuthenticateLDAP(username string, password string) (LDAPIdentity, error) {
+	// empty password is unauthenticated bind, accepted by most server without checking anything
+	if username == "" || password == "" {
+		return LDAPIdentity{}, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := dialLDAP()
+	if err != nil {
+		return LDAPIdentity{}, err
+	}
+	defer conn.Close()
+	if settings.LDAP_BIND_DN != "" {
+		if err := conn.Bind(settings.LDAP_BIND_DN, settings.LDAP_BIND_PASSWORD); err != nil {
+			return LDAPIdentity{}, err
+		}
+	}
+
+	// size limit 2 so ambiguous filter detected without reading every entry
+	result, err := conn.Search(ldap.NewSearchRequest(
+		settings.LDAP_BASE_DN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(ldapTimeout.Seconds()),
+		false,
+		strings.ReplaceAll(settings.LDAP_USER_FILTER, "%s", ldap.EscapeFilter(username)),
+		[]string{settings.LDAP_USERNAME_ATTRIBUTE, settings.LDAP_EMAIL_ATTRIBUTE, settings.LDAP_GROUP_ATTRIBUTE},
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return LDAPIdentity{}, ErrLDAPInvalidCredentials
+		}
+		return LDAPIdentity{}, err
+	}
+	if len(result.Entries) != 1 {
+		return LDAPIdentity{}, ErrLDAPInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return LDAPIdentity{}, ErrLDAPInvalidCredentials
+		}
+		return LDAPIdentity{}, err
+	}
+
+	identity := LDAPIdentity{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(settings.LDAP_USERNAME_ATTRIBUTE),
+		Email:    entry.GetAttributeValue(settings.LDAP_EMAIL_ATTRIBUTE),
+		Groups:   entry.GetAttributeValues(settings.LDAP_GROUP_ATTRIBUTE),
+	}
+	if identity.Username == "" {
+		identity.Username = username
+	}
+	return identity, nil
+}
+
+// dialLDAP connect to LDAP_URL (ldap:// or ldaps://), upgraded with StartTLS if LDAP_START_TLS
+func dialLDAP() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(settings.LDAP_URL, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+	if settings.LDAP_START_TLS {
+		ldapUrl, err := url.Parse(settings.LDAP_URL)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: ldapUrl.Hostname()}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// LDAPGroupRole role granted to member of LDAP group
+type LDAPGroupRole struct {
+	GroupDN string
+	Role    string
+}
+
+// ParseLDAPGroupRoles parse LDAP_GROUP_ROLES, semicolon separated <group dn>:<role name> pair
+func ParseLDAPGroupRoles(value string) ([]LDAPGroupRole, error) {
+	groupRoles := []LDAPGroupRole{}
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		separator := strings.LastIndex(pair, ":")
+		if separator == -1 {
+			return nil, fmt.Errorf("ldap group role %s should <group dn>:<role name>", pair)
+		}
+		groupDN := strings.TrimSpace(pair[:separator])
+		role := strings.TrimSpace(pair[separator+1:])
+		if _, err := ldap.ParseDN(groupDN); err != nil || groupDN == "" || role == "" {
+			return nil, fmt.Errorf("ldap group role %s should <group dn>:<role name>", pair)
+		}
+		groupRoles = append(groupRoles, LDAPGroupRole{GroupDN: groupDN, Role: role})
+	}
+	return groupRoles, nil
+}
+
+// MapLDAPGroupRoles return every mapped role name, true if one of groups mapped to the role.
+// Group dn compared case insensitive
+func MapLDAPGroupRoles(groupRoles []LDAPGroupRole, groups []string) map[string]bool {
+	parsedGroups := []*ldap.DN{}
+	for _, group := range groups {
+		if parsedGroup, err := ldap.ParseDN(group); err == nil {
+			parsedGroups = append(parsedGroups, parsedGroup)
+		}
+	}
+	roles := map[string]bool{}
+	for _, groupRole := range groupRoles {
+		isMember := false
+		groupDN, _ := ldap.ParseDN(groupRole.GroupDN)
+		for _, parsedGroup := range parsedGroups {
+			if groupDN != nil && groupDN.EqualFold(parsedGroup) {
+				isMember = true
+				break
+			}
+		}
+		roles[groupRole.Role] = roles[groupRole.Role] || isMember
+	}
+	return roles
+}
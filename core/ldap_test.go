@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/ldaptest"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateLDAP(t *testing.T) {
+	// Given
+	settings.InitiateSettings("../.env")
+	defer settings.InitiateSettings("../.env")
+	server, err := ldaptest.NewServer(
+		ldaptest.Entry{
+			DN:       "cn=service,dc=example,dc=com",
+			Password: "servicepassword",
+		},
+		ldaptest.Entry{
+			DN:       "uid=alice,ou=people,dc=example,dc=com",
+			Password: "alicepassword",
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"uid":         {"alice"},
+				"mail":        {"alice@example.com"},
+				"memberOf":    {"cn=admins,ou=groups,dc=example,dc=com"},
+			},
+		},
+		ldaptest.Entry{
+			DN:       "uid=bob,ou=people,dc=example,dc=com",
+			Password: "bobpassword",
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"uid":         {"bob"},
+			},
+		},
+	)
+	assert.Nil(t, err)
+	defer server.Close()
+	settings.LDAP_URL = server.URL()
+	settings.LDAP_BIND_DN = "cn=service,dc=example,dc=com"
+	settings.LDAP_BIND_PASSWORD = "servicepassword"
+	settings.LDAP_BASE_DN = "ou=people,dc=example,dc=com"
+	settings.LDAP_USER_FILTER = "(&(objectClass=person)(uid=%s))"
+
+	// When
+	identity, err := core.AuthenticateLDAP("alice", "alicepassword")
+
+	// Expect
+	assert.Nil(t, err)
+	assert.Equal(t, core.LDAPIdentity{
+		DN:       "uid=alice,ou=people,dc=example,dc=com",
+		Username: "alice",
+		Email:    "alice@example.com",
+		Groups:   []string{"cn=admins,ou=groups,dc=example,dc=com"},
+	}, identity)
+
+	// Expect wrong password, empty password, unknown user and filter injection refused
+	for _, credential := range [][2]string{
+		{"alice", "wrongpassword"},
+		{"alice", ""},
+		{"carol", "alicepassword"},
+		{"*", "alicepassword"},
+	} {
+		_, err = core.AuthenticateLDAP(credential[0], credential[1])
+		assert.ErrorIs(t, err, core.ErrLDAPInvalidCredentials, credential[0])
+	}
+
+	// Expect ambiguous filter refused
+	settings.LDAP_USER_FILTER = "(objectClass=person)"
+	_, err = core.AuthenticateLDAP("alice", "alicepassword")
+	assert.ErrorIs(t, err, core.ErrLDAPInvalidCredentials)
+
+	// Expect wrong service account password is not invalid credentials of user
+	settings.LDAP_USER_FILTER = "(uid=%s)"
+	settings.LDAP_BIND_PASSWORD = "wrongpassword"
+	_, err = core.AuthenticateLDAP("alice", "alicepassword")
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, core.ErrLDAPInvalidCredentials)
+}
+
+func TestMapLDAPGroupRoles(t *testing.T) {
+	// Given
+	groupRoles, err := core.ParseLDAPGroupRoles(
+		"cn=admins,ou=groups,dc=example,dc=com:admin; cn=staff,ou=groups,dc=example,dc=com:staff;" +
+			"cn=operators,ou=groups,dc=example,dc=com:admin",
+	)
+	assert.Nil(t, err)
+
+	// When
+	roles := core.MapLDAPGroupRoles(groupRoles, []string{"CN=Admins,OU=Groups,DC=example,DC=com", "cn=other,dc=example,dc=com"})
+
+	// Expect
+	assert.Equal(t, map[string]bool{"admin": true, "staff": false}, roles)
+
+	// Expect invalid mapping refused
+	for _, value := range []string{"cn=admins,dc=example,dc=com", "cn=admins,dc=example,dc=com:", "not a dn:admin"} {
+		_, err = core.ParseLDAPGroupRoles(value)
+		assert.NotNil(t, err, value)
+	}
+}
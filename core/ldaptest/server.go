@@ -0,0 +1,280 @@
+// Package ldaptest provide stand-in LDAP server to test ldap login without real directory.
+// Only simple bind, search (and, or, not, equality and present filter) and unbind supported
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LDAP protocol operation tags (RFC 4511)
+const (
+	applicationBindRequest       = 0
+	applicationBindResponse      = 1
+	applicationUnbindRequest     = 2
+	applicationSearchRequest     = 3
+	applicationSearchResultEntry = 4
+	applicationSearchResultDone  = 5
+)
+
+// LDAP result codes used by the server
+const (
+	resultSuccess            = 0
+	resultProtocolError      = 2
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+)
+
+// Filter choice tags
+const (
+	filterAnd           = 0
+	filterOr            = 1
+	filterNot           = 2
+	filterEqualityMatch = 3
+	filterPresent       = 7
+)
+
+// Entry directory entry, bind as the entry allowed when Password not empty
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server LDAP server listening on random local port,
+// anonymous bind allowed and search not restricted by bound entry
+type Server struct {
+	Listener net.Listener
+	mu       sync.Mutex
+	entries  []Entry
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+func NewServer(entries ...Entry) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		Listener: listener,
+		entries:  entries,
+		conns:    map[net.Conn]struct{}{},
+	}
+	server.wg.Add(1)
+	go server.serve()
+	return server, nil
+}
+
+func (server *Server) URL() string {
+	return "ldap://" + server.Listener.Addr().String()
+}
+
+// Close stop listening and close every open connection
+func (server *Server) Close() {
+	server.Listener.Close()
+	server.mu.Lock()
+	for conn := range server.conns {
+		conn.Close()
+	}
+	server.mu.Unlock()
+	server.wg.Wait()
+}
+
+// SetEntries replace every entry on the directory
+func (server *Server) SetEntries(entries ...Entry) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.entries = entries
+}
+
+func (server *Server) serve() {
+	defer server.wg.Done()
+	for {
+		conn, err := server.Listener.Accept()
+		if err != nil {
+			return
+		}
+		server.mu.Lock()
+		server.conns[conn] = struct{}{}
+		server.mu.Unlock()
+		server.wg.Add(1)
+		go func() {
+			defer server.wg.Done()
+			server.handle(conn)
+			server.mu.Lock()
+			delete(server.conns, conn)
+			server.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (server *Server) handle(conn net.Conn) {
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageId, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+		request := packet.Children[1]
+		switch request.Tag {
+		case applicationBindRequest:
+			code := server.bind(request)
+			if _, err := conn.Write(message(messageId, result(applicationBindResponse, code)).Bytes()); err != nil {
+				return
+			}
+		case applicationSearchRequest:
+			entries, code := server.search(request)
+			for _, entry := range entries {
+				if _, err := conn.Write(message(messageId, entry).Bytes()); err != nil {
+					return
+				}
+			}
+			if _, err := conn.Write(message(messageId, result(applicationSearchResultDone, code)).Bytes()); err != nil {
+				return
+			}
+		case applicationUnbindRequest:
+			return
+		default:
+			// unsupported operation (extended, modify, ...) close the connection
+			return
+		}
+	}
+}
+
+// bind check simple bind, empty name and password is anonymous bind
+func (server *Server) bind(request *ber.Packet) int64 {
+	if len(request.Children) < 3 || request.Children[2].ClassType != ber.ClassContext || request.Children[2].Tag != 0 {
+		return resultProtocolError
+	}
+	name := request.Children[1].Data.String()
+	password := request.Children[2].Data.String()
+	if name == "" && password == "" {
+		return resultSuccess
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, entry := range server.entries {
+		if strings.EqualFold(entry.DN, name) && entry.Password != "" && entry.Password == password {
+			return resultSuccess
+		}
+	}
+	return resultInvalidCredentials
+}
+
+// search return entry under base dn matching the filter, only requested attributes returned
+func (server *Server) search(request *ber.Packet) ([]*ber.Packet, int64) {
+	if len(request.Children) < 8 {
+		return nil, resultProtocolError
+	}
+	baseDN := strings.ToLower(request.Children[0].Data.String())
+	sizeLimit, _ := request.Children[3].Value.(int64)
+	filter := request.Children[6]
+	requestedAttributes := map[string]bool{}
+	for _, attribute := range request.Children[7].Children {
+		requestedAttributes[strings.ToLower(attribute.Data.String())] = true
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	entries := []*ber.Packet{}
+	for _, entry := range server.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), baseDN) || !matchFilter(filter, entry) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(entries)) == sizeLimit {
+			return entries, resultSizeLimitExceeded
+		}
+		entries = append(entries, searchResultEntry(entry, requestedAttributes))
+	}
+	return entries, resultSuccess
+}
+
+func matchFilter(filter *ber.Packet, entry Entry) bool {
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			if !matchFilter(child, entry) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.Children {
+			if matchFilter(child, entry) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(filter.Children) == 1 && !matchFilter(filter.Children[0], entry)
+	case filterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, value := range attributeValues(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case filterPresent:
+		return len(attributeValues(entry, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+// attributeValues get values of attribute, attribute name is case insensitive
+func attributeValues(entry Entry, name string) []string {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func searchResultEntry(entry Entry, requestedAttributes map[string]bool) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, applicationSearchResultEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		if len(requestedAttributes) > 0 && !requestedAttributes[strings.ToLower(name)] {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		attributeValues := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			attributeValues.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(attributeValues)
+		attributes.AppendChild(attribute)
+	}
+	packet.AppendChild(attributes)
+	return packet
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return packet
+}
+
+func message(messageId int64, operation *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Message")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "Message ID"))
+	packet.AppendChild(operation)
+	return packet
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+// LoginLockoutDuration exponential backoff lock duration after failedCount failed login,
+// not locked before maxFailedAttempts then LOGIN_LOCKOUT_MINUTES doubled every next failure
+// up to LOGIN_LOCKOUT_MAX_MINUTES
+func LoginLockoutDuration(failedCount int, maxFailedAttempts int) time.Duration {
+	if maxFailedAttempts <= 0 || failedCount < maxFailedAttempts {
+		return 0
+	}
+	maxDuration := time.Minute * time.Duration(settings.LOGIN_LOCKOUT_MAX_MINUTES)
+	duration := time.Minute * time.Duration(settings.LOGIN_LOCKOUT_MINUTES)
+	for i := maxFailedAttempts; i < failedCount && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		return maxDuration
+	}
+	return duration
+}
+
+// LoginFailureWindow failure older than LOGIN_LOCKOUT_MAX_MINUTES (the longest lock) is forgotten,
+// so occasional typo spread over months not add up to long lockout
+func LoginFailureWindow() time.Duration {
+	return time.Minute * time.Duration(settings.LOGIN_LOCKOUT_MAX_MINUTES)
+}
+
+// LoginThrottle keep track of failed login per key (client ip),
+// locked key wait before allowed to login again
+type LoginThrottle struct {
+	mu       sync.Mutex
+	attempts map[string]loginAttempt
+}
+
+type loginAttempt struct {
+	failedCount  int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+// IPLoginThrottle throttle failed login per client ip, kept on memory of each server
+// so not shared across replicas (each replica allow LOGIN_IP_MAX_FAILED_ATTEMPTS), username lockout is on database
+var IPLoginThrottle = NewLoginThrottle()
+
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{attempts: map[string]loginAttempt{}}
+}
+
+// RetryAfter return how long key should wait, 0 if not locked
+func (throttle *LoginThrottle) RetryAfter(key string, now time.Time) time.Duration {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	attempt, isFound := throttle.attempts[key]
+	if !isFound || !now.Before(attempt.lockedUntil) {
+		return 0
+	}
+	return attempt.lockedUntil.Sub(now)
+}
+
+// RecordFailure add failed login of key, failure older than LOGIN_LOCKOUT_MAX_MINUTES is forgotten
+func (throttle *LoginThrottle) RecordFailure(key string, now time.Time) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	// remove stale attempt, no need to keep them
+	window := LoginFailureWindow()
+	for item, attempt := range throttle.attempts {
+		if now.Sub(attempt.lastFailedAt) > window && !now.Before(attempt.lockedUntil) {
+			delete(throttle.attempts, item)
+		}
+	}
+
+	attempt := throttle.attempts[key]
+	attempt.failedCount++
+	attempt.lastFailedAt = now
+	if duration := LoginLockoutDuration(attempt.failedCount, settings.LOGIN_IP_MAX_FAILED_ATTEMPTS); duration > 0 {
+		attempt.lockedUntil = now.Add(duration)
+	}
+	throttle.attempts[key] = attempt
+}
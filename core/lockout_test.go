@@ -0,0 +1,47 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockoutDuration(t *testing.T) {
+	settings.LOGIN_LOCKOUT_MINUTES = 1
+	settings.LOGIN_LOCKOUT_MAX_MINUTES = 10
+	assert.Equal(t, time.Duration(0), core.LoginLockoutDuration(4, 5))
+	assert.Equal(t, time.Minute, core.LoginLockoutDuration(5, 5))
+	assert.Equal(t, 2*time.Minute, core.LoginLockoutDuration(6, 5))
+	assert.Equal(t, 8*time.Minute, core.LoginLockoutDuration(8, 5))
+	assert.Equal(t, 10*time.Minute, core.LoginLockoutDuration(100, 5))
+
+	// disabled
+	assert.Equal(t, time.Duration(0), core.LoginLockoutDuration(100, 0))
+}
+
+func TestLoginThrottle(t *testing.T) {
+	settings.LOGIN_IP_MAX_FAILED_ATTEMPTS = 3
+	settings.LOGIN_LOCKOUT_MINUTES = 1
+	settings.LOGIN_LOCKOUT_MAX_MINUTES = 60
+	throttle := core.NewLoginThrottle()
+	now := time.Date(2022, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	throttle.RecordFailure("10.0.0.1", now)
+	throttle.RecordFailure("10.0.0.1", now)
+	assert.Equal(t, time.Duration(0), throttle.RetryAfter("10.0.0.1", now))
+
+	throttle.RecordFailure("10.0.0.1", now)
+	assert.Equal(t, time.Minute, throttle.RetryAfter("10.0.0.1", now))
+	assert.Equal(t, 30*time.Second, throttle.RetryAfter("10.0.0.1", now.Add(30*time.Second)))
+	assert.Equal(t, time.Duration(0), throttle.RetryAfter("10.0.0.1", now.Add(time.Minute)))
+
+	// other ip not affected
+	assert.Equal(t, time.Duration(0), throttle.RetryAfter("10.0.0.2", now))
+
+	// next failure doubled lock duration
+	throttle.RecordFailure("10.0.0.1", now.Add(time.Minute))
+	assert.Equal(t, 2*time.Minute, throttle.RetryAfter("10.0.0.1", now.Add(time.Minute)))
+}
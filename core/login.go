@@ -0,0 +1,8 @@
+package core
+
+const LoginIdentifierUsername = "username"
+const LoginIdentifierEmail = "email"
+const LoginIdentifierUsernameOrEmail = "username_or_email"
+
+const AuthenticatorPassword = "password"
+const AuthenticatorLDAP = "ldap"
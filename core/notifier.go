@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+// Notification message sent to user (password reset link, etc)
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier deliver notification to user
+type Notifier interface {
+	Send(notification Notification) error
+}
+
+// UserNotifier used to deliver notification to user,
+// replace it with NewSMTPNotifier when SMTP configured
+var UserNotifier Notifier = NewMemoryNotifier()
+
+// ==========================================
+
+// MemoryNotifier keep sent notification in memory, used for development and testing
+type MemoryNotifier struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{
+		notifications: []Notification{},
+	}
+}
+
+func (notifier *MemoryNotifier) Send(notification Notification) error {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	notifier.notifications = append(notifier.notifications, notification)
+	return nil
+}
+
+// Notifications return every sent notification to given address
+func (notifier *MemoryNotifier) Notifications(to string) []Notification {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	notifications := []Notification{}
+	for _, notification := range notifier.notifications {
+		if notification.To == to {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications
+}
+
+// Clear remove every sent notification
+func (notifier *MemoryNotifier) Clear() {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	notifier.notifications = []Notification{}
+}
+
+// ==========================================
+
+// SMTPNotifier send notification as plain text email,
+// authenticate using PLAIN auth when username not empty
+type SMTPNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPNotifier(host string, port string, username string, password string, from string) *SMTPNotifier {
+	return &SMTPNotifier{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (notifier *SMTPNotifier) Send(notification Notification) error {
+	var auth smtp.Auth
+	if notifier.username != "" {
+		auth = smtp.PlainAuth("", notifier.username, notifier.password, notifier.host)
+	}
+	return smtp.SendMail(
+		notifier.host+":"+notifier.port,
+		auth,
+		notifier.from,
+		[]string{notification.To},
+		buildEmailMessage(notifier.from, notification),
+	)
+}
+
+// buildEmailMessage format notification as RFC 5322 message,
+// line break removed from header to prevent header injection
+func buildEmailMessage(from string, notification Notification) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+	message := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s",
+		header.Replace(from),
+		header.Replace(notification.To),
+		header.Replace(notification.Subject),
+		strings.ReplaceAll(notification.Body, "\n", "\r\n"),
+	)
+	return []byte(message)
+}
+
+// ==========================================
+
+// NewPasswordResetNotification notification containing password reset link of user
+func NewPasswordResetNotification(user models.User, rawToken string) Notification {
+	return Notification{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new password:\n\n%s%s\n\nThe link expires in %d minutes and can only be used once. If you did not request a password reset, ignore this email.\n",
+			user.Username,
+			settings.PASSWORD_RESET_URL,
+			url.QueryEscape(rawToken),
+			settings.PASSWORD_RESET_TOKEN_EXPIRE_MINUTES,
+		),
+	}
+}
+
+// NewMagicLinkNotification notification containing passwordless login link of user
+func NewMagicLinkNotification(user models.User, rawToken string) Notification {
+	return Notification{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to login:\n\n%s%s\n\nThe link expires in %d minutes, can only be used once and only works on the device the link requested from. If you did not request a login link, ignore this email.\n",
+			user.Username,
+			settings.MAGIC_LINK_URL,
+			url.QueryEscape(rawToken),
+			settings.MAGIC_LINK_TOKEN_EXPIRE_MINUTES,
+		),
+	}
+}
+
+// NewEmailVerificationNotification notification containing email verification link of user
+func NewEmailVerificationNotification(user models.User, rawToken string) Notification {
+	return Notification{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to verify your email address:\n\n%s%s\n\nThe link expires in %d minutes and can only be used once.\n",
+			user.Username,
+			settings.EMAIL_VERIFICATION_URL,
+			url.QueryEscape(rawToken),
+			settings.EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES,
+		),
+	}
+}
+
+// NewInvitationNotification notification containing invitation link sent to invited email
+func NewInvitationNotification(email string, inviter models.User, rawToken string) Notification {
+	return Notification{
+		To:      email,
+		Subject: "You are invited",
+		Body: fmt.Sprintf(
+			"Hi,\n\n%s invited you to create an account. Open the link below to choose your username and password:\n\n%s%s\n\nThe link expires in %d minutes and can only be used once.\n",
+			inviter.Username,
+			settings.INVITATION_URL,
+			url.QueryEscape(rawToken),
+			settings.INVITATION_EXPIRE_MINUTES,
+		),
+	}
+}
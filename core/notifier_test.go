@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryNotifier(t *testing.T) {
+	notifier := core.NewMemoryNotifier()
+	assert.Nil(t, notifier.Send(core.Notification{To: "a@test.com", Subject: "a"}))
+	assert.Nil(t, notifier.Send(core.Notification{To: "b@test.com", Subject: "b"}))
+	assert.Nil(t, notifier.Send(core.Notification{To: "a@test.com", Subject: "c"}))
+
+	notifications := notifier.Notifications("a@test.com")
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, "a", notifications[0].Subject)
+	assert.Equal(t, "c", notifications[1].Subject)
+
+	notifier.Clear()
+	assert.Len(t, notifier.Notifications("a@test.com"), 0)
+}
+
+func TestPasswordResetNotification(t *testing.T) {
+	settings.PASSWORD_RESET_URL = "https://example.com/reset?token="
+	settings.PASSWORD_RESET_TOKEN_EXPIRE_MINUTES = 30
+	user := models.User{Username: "test", Email: "test@test.com"}
+
+	notification := core.NewPasswordResetNotification(user, "a+b/c")
+
+	assert.Equal(t, "test@test.com", notification.To)
+	assert.True(t, strings.Contains(notification.Body, "https://example.com/reset?token="+url.QueryEscape("a+b/c")))
+	assert.True(t, strings.Contains(notification.Body, "30 minutes"))
+}
+
+func TestMagicLinkNotification(t *testing.T) {
+	settings.MAGIC_LINK_URL = "https://example.com/magic-link?token="
+	settings.MAGIC_LINK_TOKEN_EXPIRE_MINUTES = 15
+	user := models.User{Username: "test", Email: "test@test.com"}
+
+	notification := core.NewMagicLinkNotification(user, "a+b/c")
+
+	assert.Equal(t, "test@test.com", notification.To)
+	assert.True(t, strings.Contains(notification.Body, "https://example.com/magic-link?token="+url.QueryEscape("a+b/c")))
+	assert.True(t, strings.Contains(notification.Body, "15 minutes"))
+}
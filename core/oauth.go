@@ -0,0 +1,49 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// GenerateCodeChallenge generate PKCE S256 code challenge from code verifier (RFC 7636)
+func GenerateCodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// VerifyCodeChallenge verify PKCE code verifier, only S256 method supported
+func VerifyCodeChallenge(codeVerifier string, codeChallenge string, codeChallengeMethod string) bool {
+	if codeChallengeMethod != "S256" || len(codeVerifier) < 43 || len(codeVerifier) > 128 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(GenerateCodeChallenge(codeVerifier)), []byte(codeChallenge)) == 1
+}
+
+// CheckTokenHash compare token with hash created by HashToken
+func CheckTokenHash(token string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
+
+// SplitSpaceSeparated split space separated value (scope, redirect uri) into slice
+func SplitSpaceSeparated(value string) []string {
+	return strings.Fields(value)
+}
+
+// IsSubset check every item of subset is in set
+func IsSubset(subset []string, set []string) bool {
+	for _, item := range subset {
+		found := false
+		for _, setItem := range set {
+			if item == setItem {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
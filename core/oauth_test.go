@@ -0,0 +1,20 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636 Appendix B example
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	codeChallenge := core.GenerateCodeChallenge(codeVerifier)
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", codeChallenge)
+
+	assert.True(t, core.VerifyCodeChallenge(codeVerifier, codeChallenge, "S256"))
+	assert.False(t, core.VerifyCodeChallenge(codeVerifier+"x", codeChallenge, "S256"))
+	assert.False(t, core.VerifyCodeChallenge(codeVerifier, codeVerifier, "plain"))
+	assert.False(t, core.VerifyCodeChallenge("short", core.GenerateCodeChallenge("short"), "S256"))
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const oidcLoginTokenType = "oidc_login"
+
+var ErrOIDCInvalidSession = errors.New("invalid, expired or already used oidc login session")
+
+// OIDCHTTPClient used to call identity provider (discovery, token and jwks endpoint)
+var OIDCHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCProviderMetadata endpoints of identity provider from discovery document
+type OIDCProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClaims identity of user asserted by verified ID token
+type OIDCClaims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// IsOIDCEnabled check OpenID Connect login configured
+func IsOIDCEnabled() bool {
+	return settings.OIDC_ISSUER != ""
+}
+
+// DiscoverOIDCProvider get provider metadata from {issuer}/.well-known/openid-configuration,
+// issuer on the metadata should be the same as requested issuer
+func DiscoverOIDCProvider(issuer string) (OIDCProviderMetadata, error) {
+	metadata := OIDCProviderMetadata{}
+	resp, err := OIDCHTTPClient.Get(strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return metadata, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return metadata, fmt.Errorf("oidc discovery responded with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return metadata, err
+	}
+	if metadata.Issuer != issuer {
+		return metadata, fmt.Errorf("oidc discovery issuer %s does not match %s", metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return metadata, errors.New("oidc discovery missing authorization, token or jwks endpoint")
+	}
+	return metadata, nil
+}
+
+// BeginOIDCLogin start authorization code flow (with PKCE S256) on OIDC_ISSUER,
+// return authorization url to redirect the user to and session token (state, nonce and code verifier)
+// needed to finish the login
+func BeginOIDCLogin() (string, string, error) {
+	metadata, err := DiscoverOIDCProvider(settings.OIDC_ISSUER)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	sessionToken, err := generateTypedJWTToken(oidcLoginTokenType, state, settings.OIDC_SESSION_EXPIRE_MINUTES, map[string]interface{}{
+		"nonce":         nonce,
+		"code_verifier": codeVerifier,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", settings.OIDC_CLIENT_ID)
+	query.Set("redirect_uri", settings.OIDC_REDIRECT_URL)
+	query.Set("scope", settings.OIDC_SCOPE)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", GenerateCodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), sessionToken, nil
+}
+
+// FinishOIDCLogin check state against session token, exchange authorization code
+// and verify the ID token (signature, issuer, audience, expiration and nonce).
+// Session token only used once, return ErrOIDCInvalidSession if session invalid or state not match
+func FinishOIDCLogin(sessionToken string, state string, code string) (OIDCClaims, error) {
+	tok, err := parseTypedJWTToken(sessionToken, oidcLoginTokenType)
+	if err != nil {
+		return OIDCClaims{}, ErrOIDCInvalidSession
+	}
+	if subtle.ConstantTimeCompare([]byte(tok.Subject()), []byte(state)) != 1 {
+		return OIDCClaims{}, ErrOIDCInvalidSession
+	}
+	if err := revokeTypedJWTToken(sessionToken); err != nil {
+		return OIDCClaims{}, err
+	}
+	nonce, _ := tok.Get("nonce")
+	codeVerifier, _ := tok.Get("code_verifier")
+
+	metadata, err := DiscoverOIDCProvider(settings.OIDC_ISSUER)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	idToken, err := exchangeOIDCCode(metadata, code, fmt.Sprint(codeVerifier))
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	return verifyOIDCIDToken(metadata, idToken, fmt.Sprint(nonce))
+}
+
+// exchangeOIDCCode exchange authorization code for ID token on token endpoint,
+// client authenticated using client_secret_basic
+func exchangeOIDCCode(metadata OIDCProviderMetadata, code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", settings.OIDC_REDIRECT_URL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequest("POST", metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(settings.OIDC_CLIENT_ID), url.QueryEscape(settings.OIDC_CLIENT_SECRET))
+
+	resp, err := OIDCHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token endpoint responded with status %d", resp.StatusCode)
+	}
+	tokenResponse := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.IDToken == "" {
+		return "", errors.New("oidc token endpoint response has no id_token")
+	}
+	return tokenResponse.IDToken, nil
+}
+
+// verifyOIDCIDToken verify ID token signed by key published on provider jwks endpoint
+func verifyOIDCIDToken(metadata OIDCProviderMetadata, idToken string, nonce string) (OIDCClaims, error) {
+	keySet, err := jwk.Fetch(context.Background(), metadata.JWKSURI, jwk.WithHTTPClient(OIDCHTTPClient))
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	tok, err := jwt.Parse(
+		[]byte(idToken),
+		jwt.WithKeySet(keySet, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(settings.OIDC_CLIENT_ID),
+		jwt.WithClaimValue("nonce", nonce),
+		jwt.WithAcceptableSkew(time.Minute),
+	)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	claims := OIDCClaims{Subject: tok.Subject()}
+	if email, ok := tok.Get("email"); ok {
+		claims.Email = fmt.Sprint(email)
+	}
+	// some provider send email_verified as string
+	if emailVerified, ok := tok.Get("email_verified"); ok {
+		claims.EmailVerified = fmt.Sprint(emailVerified) == "true"
+	}
+	if preferredUsername, ok := tok.Get("preferred_username"); ok {
+		claims.PreferredUsername = fmt.Sprint(preferredUsername)
+	}
+	return claims, nil
+}
@@ -0,0 +1,70 @@
+package core_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/oidctest"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	// Given
+	settings.InitiateSettings("../.env")
+	defer settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	provider, err := oidctest.NewProvider("client", "secret")
+	assert.Nil(t, err)
+	defer provider.Close()
+	settings.OIDC_ISSUER = provider.Issuer()
+	settings.OIDC_CLIENT_ID = "client"
+	settings.OIDC_CLIENT_SECRET = "secret"
+	identity := oidctest.Identity{
+		Subject:           "subject",
+		Email:             "oidc@test.com",
+		EmailVerified:     true,
+		PreferredUsername: "oidc",
+	}
+
+	// When
+	authorizationUrl, sessionToken, err := core.BeginOIDCLogin()
+	assert.Nil(t, err)
+	parsedUrl, err := url.Parse(authorizationUrl)
+	assert.Nil(t, err)
+	state := parsedUrl.Query().Get("state")
+	assert.Equal(t, "S256", parsedUrl.Query().Get("code_challenge_method"))
+	code, err := provider.Authorize(authorizationUrl, identity)
+	assert.Nil(t, err)
+	claims, err := core.FinishOIDCLogin(sessionToken, state, code)
+
+	// Expect
+	assert.Nil(t, err)
+	assert.Equal(t, core.OIDCClaims{
+		Subject:           "subject",
+		Email:             "oidc@test.com",
+		EmailVerified:     true,
+		PreferredUsername: "oidc",
+	}, claims)
+
+	// Expect session used only once
+	_, err = core.FinishOIDCLogin(sessionToken, state, code)
+	assert.ErrorIs(t, err, core.ErrOIDCInvalidSession)
+
+	// Expect state bound to session
+	_, sessionToken, err = core.BeginOIDCLogin()
+	assert.Nil(t, err)
+	_, err = core.FinishOIDCLogin(sessionToken, state, code)
+	assert.ErrorIs(t, err, core.ErrOIDCInvalidSession)
+
+	// Expect ID token for other client refused
+	authorizationUrl, sessionToken, err = core.BeginOIDCLogin()
+	assert.Nil(t, err)
+	parsedUrl, _ = url.Parse(authorizationUrl)
+	code, err = provider.Authorize(authorizationUrl, identity)
+	assert.Nil(t, err)
+	provider.Audience = "other"
+	_, err = core.FinishOIDCLogin(sessionToken, parsedUrl.Query().Get("state"), code)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,193 @@
+// Package oidctest provide stand-in OpenID Connect identity provider to test oidc login without real provider
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Identity user logged in on the provider, put on the ID token claims
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+type authorization struct {
+	identity      Identity
+	nonce         string
+	redirectUri   string
+	codeChallenge string
+}
+
+// Provider identity provider served by httptest, ID token signed using RS256.
+// Authorize issue authorization code directly (without login page) for given identity.
+// Audience of ID token is the client id unless Audience set
+type Provider struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	Audience     string
+	key          jwk.Key
+	mu           sync.Mutex
+	codes        map[string]authorization
+}
+
+func NewProvider(clientId string, clientSecret string) (*Provider, error) {
+	rawKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	key.Set(jwk.KeyIDKey, "oidctest")
+	key.Set(jwk.AlgorithmKey, jwa.RS256)
+
+	provider := &Provider{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        map[string]authorization{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/jwks", provider.jwks)
+	provider.Server = httptest.NewServer(mux)
+	return provider, nil
+}
+
+func (provider *Provider) Issuer() string {
+	return provider.Server.URL
+}
+
+func (provider *Provider) Close() {
+	provider.Server.Close()
+}
+
+// Authorize act as user logged in on authorization url (generated by relying party),
+// return authorization code for the identity
+func (provider *Provider) Authorize(authorizationUrl string, identity Identity) (string, error) {
+	parsedUrl, err := url.Parse(authorizationUrl)
+	if err != nil {
+		return "", err
+	}
+	query := parsedUrl.Query()
+	codeBytes := make([]byte, 16)
+	if _, err := rand.Read(codeBytes); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(codeBytes)
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	provider.codes[code] = authorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		redirectUri:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	return code, nil
+}
+
+func (provider *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 provider.Issuer(),
+		"authorization_endpoint": provider.Issuer() + "/authorize",
+		"token_endpoint":         provider.Issuer() + "/token",
+		"jwks_uri":               provider.Issuer() + "/jwks",
+	})
+}
+
+func (provider *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey, err := provider.key.PublicKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	set := jwk.NewSet()
+	set.AddKey(publicKey)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+func (provider *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok || clientId != provider.ClientID || clientSecret != provider.ClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	// authorization code only used once
+	provider.mu.Lock()
+	auth, ok := provider.codes[r.PostForm.Get("code")]
+	delete(provider.codes, r.PostForm.Get("code"))
+	provider.mu.Unlock()
+	codeVerifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != auth.redirectUri ||
+		base64.RawURLEncoding.EncodeToString(codeVerifierHash[:]) != auth.codeChallenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	audience := provider.Audience
+	if audience == "" {
+		audience = provider.ClientID
+	}
+	idToken, err := provider.IDToken(auth.identity, audience, auth.nonce, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "oidctest",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// IDToken generate ID token of identity signed by the provider key
+func (provider *Provider) IDToken(identity Identity, audience string, nonce string, issuedAt time.Time) (string, error) {
+	tok, err := jwt.NewBuilder().
+		Issuer(provider.Issuer()).
+		Subject(identity.Subject).
+		Audience([]string{audience}).
+		IssuedAt(issuedAt).
+		Expiration(issuedAt.Add(5*time.Minute)).
+		Claim("nonce", nonce).
+		Claim("email", identity.Email).
+		Claim("email_verified", identity.EmailVerified).
+		Claim("preferred_username", identity.PreferredUsername).
+		Build()
+	if err != nil {
+		return "", err
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.RS256, provider.key))
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
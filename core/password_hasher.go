@@ -0,0 +1,158 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const PasswordHasherBcrypt = "bcrypt"
+const PasswordHasherArgon2id = "argon2id"
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHasher hash and verify password, hash encoded with its algorithm and parameters
+// so every hash could be verified after the configured hasher changed
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Identify check hash created by the algorithm of this hasher
+	Identify(hash string) bool
+	Verify(password string, hash string) (bool, error)
+	// NeedsRehash check hash created with parameters different from this hasher
+	NeedsRehash(hash string) bool
+}
+
+// CurrentPasswordHasher hasher configured by PASSWORD_HASHER, new password hashed with it
+func CurrentPasswordHasher() PasswordHasher {
+	if settings.PASSWORD_HASHER == PasswordHasherBcrypt {
+		return BcryptHasher{Cost: settings.BCRYPT_COST}
+	}
+	return Argon2idHasher{
+		Memory:      uint32(settings.ARGON2_MEMORY_KIB),
+		Iterations:  uint32(settings.ARGON2_ITERATIONS),
+		Parallelism: uint8(settings.ARGON2_PARALLELISM),
+	}
+}
+
+// passwordHasherOf get hasher able to verify hash, parameters read from the hash
+func passwordHasherOf(hash string) (PasswordHasher, bool) {
+	for _, hasher := range []PasswordHasher{BcryptHasher{}, Argon2idHasher{}} {
+		if hasher.Identify(hash) {
+			return hasher, true
+		}
+	}
+	return nil, false
+}
+
+// ==========================================
+
+// BcryptHasher hash password using bcrypt, hash formatted as $2a$<cost>$<salt and hash>
+type BcryptHasher struct {
+	Cost int
+}
+
+func (hasher BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	return string(bytes), err
+}
+
+func (hasher BcryptHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (hasher BcryptHasher) Verify(password string, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (hasher BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != hasher.Cost
+}
+
+// ==========================================
+
+const argon2idSaltLength = 16
+const argon2idKeyLength = 32
+
+// Argon2idHasher hash password using argon2id, hash formatted on PHC string format
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key> (base64 without padding)
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func (hasher Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		hasher.Memory,
+		hasher.Iterations,
+		hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (hasher Argon2idHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (hasher Argon2idHasher) Verify(password string, hash string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func (hasher Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2idHash(hash)
+	return err != nil || params != hasher || len(key) != argon2idKeyLength
+}
+
+// decodeArgon2idHash parse parameters, salt and key of PHC formatted argon2id hash
+func decodeArgon2idHash(hash string) (Argon2idHasher, []byte, []byte, error) {
+	params := Argon2idHasher{}
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil ||
+		params.Iterations < 1 || params.Parallelism < 1 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	return params, salt, key, nil
+}
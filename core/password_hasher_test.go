@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := core.BcryptHasher{Cost: 4}
+	hash, err := hasher.Hash("Fakepassword")
+	assert.Nil(t, err)
+	assert.True(t, hasher.Identify(hash))
+	valid, err := hasher.Verify("Fakepassword", hash)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	valid, err = hasher.Verify("wrong password", hash)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, core.BcryptHasher{Cost: 5}.NeedsRehash(hash))
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := core.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}
+	hash, err := hasher.Hash("Fakepassword")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, hasher.Identify(hash))
+	valid, err := hasher.Verify("Fakepassword", hash)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	valid, err = hasher.Verify("wrong password", hash)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, core.Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1}.NeedsRehash(hash))
+
+	// Invalid hash
+	_, err = hasher.Verify("Fakepassword", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5")
+	assert.ErrorIs(t, err, core.ErrInvalidPasswordHash)
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	defer func() {
+		settings.PASSWORD_HASHER = core.PasswordHasherArgon2id
+		settings.BCRYPT_COST = 12
+		settings.ARGON2_MEMORY_KIB = 19456
+		settings.ARGON2_ITERATIONS = 2
+		settings.ARGON2_PARALLELISM = 1
+	}()
+
+	// Given hash created by bcrypt
+	settings.PASSWORD_HASHER = core.PasswordHasherBcrypt
+	settings.BCRYPT_COST = 4
+	bcryptHash, err := core.HashPassword("Fakepassword")
+	assert.Nil(t, err)
+	assert.False(t, core.PasswordNeedsRehash(bcryptHash))
+
+	// When hasher changed to argon2id
+	settings.PASSWORD_HASHER = core.PasswordHasherArgon2id
+	settings.ARGON2_MEMORY_KIB = 1024
+	settings.ARGON2_ITERATIONS = 1
+	settings.ARGON2_PARALLELISM = 1
+	argon2idHash, err := core.HashPassword("Fakepassword")
+	assert.Nil(t, err)
+
+	// Expect both hash verified and bcrypt hash rehashed
+	assert.True(t, core.CheckPasswordHash("Fakepassword", bcryptHash))
+	assert.True(t, core.CheckPasswordHash("Fakepassword", argon2idHash))
+	assert.False(t, core.CheckPasswordHash("wrong password", argon2idHash))
+	assert.False(t, core.CheckPasswordHash("Fakepassword", "Fakepassword"))
+	assert.True(t, core.PasswordNeedsRehash(bcryptHash))
+	assert.False(t, core.PasswordNeedsRehash(argon2idHash))
+
+	// When argon2id parameter changed
+	settings.ARGON2_ITERATIONS = 2
+
+	// Expect
+	assert.True(t, core.PasswordNeedsRehash(argon2idHash))
+}
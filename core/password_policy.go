@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+const PasswordCharacterClassLowercase = "lowercase"
+const PasswordCharacterClassUppercase = "uppercase"
+const PasswordCharacterClassDigit = "digit"
+const PasswordCharacterClassSymbol = "symbol"
+
+// minimum length of username or email name checked by PASSWORD_FORBID_USER_INFO,
+// shorter value is too common to be forbidden
+const passwordUserInfoMinLength = 3
+
+// CheckPasswordPolicy check password against password policy settings and breached password list,
+// return message of every violation (empty if password allowed)
+func CheckPasswordPolicy(password string, username string, email string) ([]string, error) {
+	violations := []string{}
+	if utf8.RuneCountInString(password) < settings.PASSWORD_MIN_LENGTH {
+		violations = append(violations, fmt.Sprintf("password should at least %d characters", settings.PASSWORD_MIN_LENGTH))
+	}
+
+	for _, characterClass := range SplitSpaceSeparated(settings.PASSWORD_REQUIRED_CHARACTER_CLASSES) {
+		if !containsCharacterClass(password, characterClass) {
+			violations = append(violations, "password should contain "+characterClass+" character")
+		}
+	}
+
+	if settings.PASSWORD_FORBID_USER_INFO {
+		lowerPassword := strings.ToLower(password)
+		if len(username) >= passwordUserInfoMinLength && strings.Contains(lowerPassword, strings.ToLower(username)) {
+			violations = append(violations, "password should not contain username")
+		}
+		emailName := email
+		if at := strings.LastIndex(email, "@"); at != -1 {
+			emailName = email[:at]
+		}
+		if len(emailName) >= passwordUserInfoMinLength && strings.Contains(lowerPassword, strings.ToLower(emailName)) {
+			violations = append(violations, "password should not contain email")
+		}
+	}
+
+	isBreached, err := PasswordBreachedChecker.IsBreached(password)
+	if err != nil {
+		return violations, err
+	}
+	if isBreached {
+		violations = append(violations, "password found on breached password list, choose another password")
+	}
+	return violations, nil
+}
+
+func containsCharacterClass(password string, characterClass string) bool {
+	for _, char := range password {
+		switch {
+		case characterClass == PasswordCharacterClassLowercase && unicode.IsLower(char):
+			return true
+		case characterClass == PasswordCharacterClassUppercase && unicode.IsUpper(char):
+			return true
+		case characterClass == PasswordCharacterClassDigit && unicode.IsDigit(char):
+			return true
+		case characterClass == PasswordCharacterClassSymbol && !unicode.IsLetter(char) && !unicode.IsDigit(char) && !unicode.IsSpace(char):
+			return true
+		}
+	}
+	return false
+}
+
+// ==========================================
+
+// BreachedPasswordChecker check password appear on breached password list
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PasswordBreachedChecker used to check new password,
+// replace it with NewHashPrefixBreachedPasswordChecker when PASSWORD_BREACHED_LIST_PATH configured
+var PasswordBreachedChecker BreachedPasswordChecker = NoopBreachedPasswordChecker{}
+
+// NoopBreachedPasswordChecker never found password breached, used when breached password list not configured
+type NoopBreachedPasswordChecker struct{}
+
+func (checker NoopBreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	return false, nil
+}
+
+// breachedPasswordHashPrefixLength length of SHA-1 hash prefix (range) of breached password list
+const breachedPasswordHashPrefixLength = 5
+
+// HashPrefixBreachedPasswordChecker check password on breached password list
+// grouped by SHA-1 hash prefix (k-anonymity range, same as Have I Been Pwned),
+// only hash suffixes on the range of the password prefix compared
+type HashPrefixBreachedPasswordChecker struct {
+	ranges map[string]map[string]struct{}
+}
+
+// NewHashPrefixBreachedPasswordChecker load breached password list file,
+// every line is upper case hex SHA-1 hash of breached password optionally followed by :<count>
+func NewHashPrefixBreachedPasswordChecker(path string) (*HashPrefixBreachedPasswordChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checker := &HashPrefixBreachedPasswordChecker{
+		ranges: map[string]map[string]struct{}{},
+	}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of %s", lineNumber, path)
+		}
+		prefix := hash[:breachedPasswordHashPrefixLength]
+		if checker.ranges[prefix] == nil {
+			checker.ranges[prefix] = map[string]struct{}{}
+		}
+		checker.ranges[prefix][hash[breachedPasswordHashPrefixLength:]] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checker, nil
+}
+
+func (checker *HashPrefixBreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := checker.ranges[hash[:breachedPasswordHashPrefixLength]][hash[breachedPasswordHashPrefixLength:]]
+	return ok, nil
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	defer func() {
+		settings.PASSWORD_MIN_LENGTH = 8
+		settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = ""
+		settings.PASSWORD_FORBID_USER_INFO = false
+		core.PasswordBreachedChecker = core.NoopBreachedPasswordChecker{}
+	}()
+	settings.PASSWORD_MIN_LENGTH = 8
+	settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = ""
+	settings.PASSWORD_FORBID_USER_INFO = false
+	core.PasswordBreachedChecker = core.NoopBreachedPasswordChecker{}
+
+	// minimum length counted in characters
+	violations, err := core.CheckPasswordPolicy("short", "test", "test@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"password should at least 8 characters"}, violations)
+	violations, err = core.CheckPasswordPolicy("pässwörd", "test", "test@test.com")
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+
+	// character classes
+	settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = "lowercase uppercase digit symbol"
+	violations, err = core.CheckPasswordPolicy("alllowercase", "test", "test@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"password should contain uppercase character",
+		"password should contain digit character",
+		"password should contain symbol character",
+	}, violations)
+	violations, err = core.CheckPasswordPolicy("Fake-passw0rd", "test", "test@test.com")
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+	settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = ""
+
+	// username and email
+	settings.PASSWORD_FORBID_USER_INFO = true
+	violations, err = core.CheckPasswordPolicy("MyTestPassword", "test", "john@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"password should not contain username"}, violations)
+	violations, err = core.CheckPasswordPolicy("john.doe.password", "test", "John.Doe@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"password should not contain email"}, violations)
+	violations, err = core.CheckPasswordPolicy("Fakepassword", "ab", "ab@test.com")
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestHashPrefixBreachedPasswordChecker(t *testing.T) {
+	defer func() { core.PasswordBreachedChecker = core.NoopBreachedPasswordChecker{} }()
+
+	// Given list containing SHA-1 of "password" and "123456"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte(
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n"+
+			"\n"+
+			"7c4a8d09ca3762af61e59520943dc26494f8941b\n",
+	), 0600)
+	assert.Nil(t, err)
+
+	// When
+	checker, err := core.NewHashPrefixBreachedPasswordChecker(path)
+
+	// Expect
+	assert.Nil(t, err)
+	isBreached, err := checker.IsBreached("password")
+	assert.Nil(t, err)
+	assert.True(t, isBreached)
+	isBreached, err = checker.IsBreached("123456")
+	assert.Nil(t, err)
+	assert.True(t, isBreached)
+	isBreached, err = checker.IsBreached("Fakepassword")
+	assert.Nil(t, err)
+	assert.False(t, isBreached)
+
+	// Expect breached password violate password policy
+	core.PasswordBreachedChecker = checker
+	violations, err := core.CheckPasswordPolicy("password", "test", "test@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"password found on breached password list, choose another password"}, violations)
+
+	// Expect invalid list refused
+	err = os.WriteFile(path, []byte("not a hash\n"), 0600)
+	assert.Nil(t, err)
+	_, err = core.NewHashPrefixBreachedPasswordChecker(path)
+	assert.NotNil(t, err)
+}
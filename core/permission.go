@@ -0,0 +1,7 @@
+package core
+
+// Permission declared by route, seeded on permission table by migration
+const PermissionUserRead = "user:read"
+const PermissionUserCreate = "user:create"
+const PermissionUserUpdate = "user:update"
+const PermissionUserDelete = "user:delete"
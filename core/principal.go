@@ -0,0 +1,171 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"gorm.io/gorm"
+)
+
+const PrincipalTypeUser = "user"
+const PrincipalTypeClient = "client"
+
+// Principal who make the request, user (login, authorization code grant)
+// or machine client (client_credentials grant).
+// Scopes is nil for user token without scope (not limited),
+// Permissions is user permission from roles, loaded by permission middleware.
+// Impersonator is superuser impersonating the user (nil if not impersonated).
+// ApiKey is api key the user authenticated with (nil if authenticated with access token)
+type Principal struct {
+	Type         string
+	User         models.User
+	Client       models.OAuthClient
+	Scopes       []string
+	Permissions  []string
+	Impersonator *models.User
+	ApiKey       *models.ApiKey
+}
+
+func (principal Principal) IsUser() bool {
+	return principal.Type == PrincipalTypeUser
+}
+
+// IsApiKey check user authenticated with api key
+func (principal Principal) IsApiKey() bool {
+	return principal.ApiKey != nil
+}
+
+func (principal Principal) IsClient() bool {
+	return principal.Type == PrincipalTypeClient
+}
+
+// HasScope check scope granted on the token, user token without scope has every scope
+func (principal Principal) HasScope(scope string) bool {
+	if principal.IsUser() && principal.Scopes == nil {
+		return true
+	}
+	return IsSubset([]string{scope}, principal.Scopes)
+}
+
+// HasPermission check principal permission, superuser has every permission
+// and machine client permission is the granted scope.
+// scoped user token limited to permission on it's scope
+func (principal Principal) HasPermission(permission string) bool {
+	if !principal.HasScope(permission) {
+		return false
+	}
+	if principal.IsClient() || principal.User.IsSuperuser {
+		return true
+	}
+	return IsSubset([]string{permission}, principal.Permissions)
+}
+
+// IsSuperuser check principal is superuser, machine client never superuser
+func (principal Principal) IsSuperuser() bool {
+	return principal.IsUser() && principal.User.IsSuperuser
+}
+
+// GenerateJWTTokenFromClient generate jwt token for machine client,
+// token subject is the client_id and has no user id
+func GenerateJWTTokenFromClient(client models.OAuthClient, scope string) (string, error) {
+	// Generate Payload
+	expiredAt := time.Now().Add(time.Minute * time.Duration(settings.ACCESS_TOKEN_EXPIRE_MINUTES))
+	tok, err := jwt.NewBuilder().
+		JwtID(uuid.NewString()).
+		Subject(client.ClientID).
+		IssuedAt(time.Now()).
+		Expiration(expiredAt).
+		Build()
+	if err != nil {
+		return "", err
+	}
+	tok.Set("client_id", client.ClientID)
+	tok.Set("scope", scope)
+
+	return signJWTToken(tok)
+}
+
+// getPrincipalIdFromJWTToken return user id for user token or client_id for client token
+func getPrincipalIdFromJWTToken(tok jwt.Token) (string, error) {
+	if id, isIdFound := tok.Get("id"); isIdFound {
+		return fmt.Sprint(id), nil
+	}
+	if clientId, isClientIdFound := tok.Get("client_id"); isClientIdFound {
+		return fmt.Sprint(clientId), nil
+	}
+	return "", errors.New("id not found on token payload")
+}
+
+func GetPrincipalFromJWTToken(tx *gorm.DB, jwtToken string) (Principal, error) {
+	tok, err := ParseJWTToken(jwtToken)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// User
+	if _, isIdFound := tok.Get("id"); isIdFound {
+		user, impersonator, err := GetUserAndImpersonatorFromJWTToken(tx, jwtToken)
+		if err != nil {
+			return Principal{}, err
+		}
+		principal := Principal{Type: PrincipalTypeUser, User: user, Impersonator: impersonator}
+		if scope, isScopeFound := tok.Get("scope"); isScopeFound {
+			principal.Scopes = SplitSpaceSeparated(fmt.Sprint(scope))
+		}
+		return principal, nil
+	}
+
+	// Client
+	clientId, err := getPrincipalIdFromJWTToken(tok)
+	if err != nil {
+		return Principal{}, err
+	}
+	client := models.OAuthClient{}
+	if err := tx.Where("client_id = ? AND deleted_at IS NULL", clientId).First(&client).Error; err != nil {
+		return Principal{}, err
+	}
+	if !IsSubset([]string{"client_credentials"}, SplitSpaceSeparated(client.GrantTypes)) {
+		return Principal{}, errors.New("client not allowed to use client_credentials grant")
+	}
+	scope, _ := tok.Get("scope")
+	return Principal{
+		Type:   PrincipalTypeClient,
+		Client: client,
+		Scopes: SplitSpaceSeparated(fmt.Sprint(scope)),
+	}, nil
+}
+
+// GetPrincipalFromAuthorizationHeader get user or machine client from bearer token or user from api key,
+// with scope of the token or api key, route should enforce the scope
+func GetPrincipalFromAuthorizationHeader(tx *gorm.DB, c *fiber.Ctx) (Principal, error) {
+	if rawApiKey, isFound := GetApiKeyFromRequest(c); isFound {
+		user, apiKey, err := GetUserFromApiKey(tx, rawApiKey)
+		if err != nil {
+			return Principal{}, errors.New("invalid api key")
+		}
+		principal := Principal{Type: PrincipalTypeUser, User: user, ApiKey: &apiKey}
+		if apiKey.Scope != "" {
+			principal.Scopes = SplitSpaceSeparated(apiKey.Scope)
+		}
+		return principal, nil
+	}
+
+	token, err := GetTokenFromAuthorizationHeader(c)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal, err := GetPrincipalFromJWTToken(tx, token)
+	if err != nil {
+		return Principal{}, errors.New("invalid token")
+	}
+	setImpersonationLocals(c, principal.User, principal.Impersonator)
+
+	return principal, nil
+}
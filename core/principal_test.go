@@ -0,0 +1,78 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateJWTTokenFromClient(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	client := models.OAuthClient{ClientID: "batch-job"}
+	token, err := core.GenerateJWTTokenFromClient(client, "user:read")
+	assert.Nil(t, err)
+
+	tok, err := core.ParseJWTToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "batch-job", tok.Subject())
+	scope, _ := tok.Get("scope")
+	assert.Equal(t, "user:read", scope)
+
+	// client token is not user token
+	_, _, err = core.GetPayloadFromJWTToken(token)
+	assert.NotNil(t, err)
+
+	// client token could be revoked
+	err = core.RevokeJWTToken(token)
+	assert.Nil(t, err)
+	_, err = core.ParseJWTToken(token)
+	assert.NotNil(t, err)
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	user := core.Principal{Type: core.PrincipalTypeUser}
+	assert.True(t, user.IsUser())
+	assert.True(t, user.HasScope("user:delete"))
+
+	client := core.Principal{Type: core.PrincipalTypeClient, Scopes: []string{"user:read"}}
+	assert.True(t, client.IsClient())
+	assert.True(t, client.HasScope("user:read"))
+	assert.False(t, client.HasScope("user:delete"))
+}
+
+func TestPrincipalHasPermission(t *testing.T) {
+	superuser := core.Principal{Type: core.PrincipalTypeUser, User: models.User{IsSuperuser: true}}
+	assert.True(t, superuser.HasPermission(core.PermissionUserDelete))
+
+	user := core.Principal{Type: core.PrincipalTypeUser, Permissions: []string{core.PermissionUserRead}}
+	assert.True(t, user.HasPermission(core.PermissionUserRead))
+	assert.False(t, user.HasPermission(core.PermissionUserDelete))
+	assert.False(t, user.IsSuperuser())
+
+	client := core.Principal{Type: core.PrincipalTypeClient, Scopes: []string{core.PermissionUserRead}}
+	assert.True(t, client.HasPermission(core.PermissionUserRead))
+	assert.False(t, client.HasPermission(core.PermissionUserCreate))
+	assert.False(t, client.IsSuperuser())
+}
+
+func TestScopedUserPrincipal(t *testing.T) {
+	superuser := core.Principal{
+		Type:   core.PrincipalTypeUser,
+		User:   models.User{IsSuperuser: true},
+		Scopes: []string{core.PermissionUserRead},
+	}
+	assert.True(t, superuser.HasPermission(core.PermissionUserRead))
+	assert.False(t, superuser.HasPermission(core.PermissionUserDelete))
+
+	user := core.Principal{
+		Type:        core.PrincipalTypeUser,
+		Scopes:      []string{core.PermissionUserRead, core.PermissionUserDelete},
+		Permissions: []string{core.PermissionUserRead},
+	}
+	assert.True(t, user.HasPermission(core.PermissionUserRead))
+	assert.False(t, user.HasPermission(core.PermissionUserDelete))
+}
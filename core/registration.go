@@ -0,0 +1,25 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+const RegistrationPolicyOpen = "open"
+const RegistrationPolicyInviteOnly = "invite_only"
+const RegistrationPolicyDisabled = "disabled"
+
+// IsEmailDomainAllowed check email domain on REGISTRATION_EMAIL_DOMAINS (case insensitive),
+// every domain allowed if REGISTRATION_EMAIL_DOMAINS empty
+func IsEmailDomainAllowed(email string) bool {
+	allowedDomains := SplitSpaceSeparated(strings.ToLower(settings.REGISTRATION_EMAIL_DOMAINS))
+	if len(allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return false
+	}
+	return IsSubset([]string{strings.ToLower(email[at+1:])}, allowedDomains)
+}
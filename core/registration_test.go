@@ -0,0 +1,24 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmailDomainAllowed(t *testing.T) {
+	defer func() { settings.REGISTRATION_EMAIL_DOMAINS = "" }()
+
+	settings.REGISTRATION_EMAIL_DOMAINS = ""
+	assert.True(t, core.IsEmailDomainAllowed("a@anything.com"))
+
+	settings.REGISTRATION_EMAIL_DOMAINS = "example.com Company.co.id"
+	assert.True(t, core.IsEmailDomainAllowed("a@example.com"))
+	assert.True(t, core.IsEmailDomainAllowed("a@EXAMPLE.com"))
+	assert.True(t, core.IsEmailDomainAllowed("a@company.co.id"))
+	assert.False(t, core.IsEmailDomainAllowed("a@sub.example.com"))
+	assert.False(t, core.IsEmailDomainAllowed("a@example.com.evil.com"))
+	assert.False(t, core.IsEmailDomainAllowed("example.com"))
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"gorm.io/gorm"
+)
+
+// RevocationStore keep track of revoked access token
+type RevocationStore interface {
+	// RevokeToken revoke single token by it's jti until the token expired
+	RevokeToken(jti string, userId string, expiredAt time.Time) error
+	// RevokeAllBefore revoke every token of user issued before given time,
+	// truncated to the second since iat claim has second precision
+	// (token issued on the same second after the revocation stay valid)
+	RevokeAllBefore(userId string, before time.Time) error
+	IsRevoked(jti string, userId string, issuedAt time.Time) (bool, error)
+}
+
+// TokenRevocationStore used by GetPayloadFromJWTToken,
+// replace it with NewDatabaseRevocationStore after database initiated
+var TokenRevocationStore RevocationStore = NewMemoryRevocationStore()
+
+// ==========================================
+
+type MemoryRevocationStore struct {
+	mu            sync.Mutex
+	revokedTokens map[string]time.Time
+	revokedBefore map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revokedTokens: map[string]time.Time{},
+		revokedBefore: map[string]time.Time{},
+	}
+}
+
+func (store *MemoryRevocationStore) RevokeToken(jti string, userId string, expiredAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// remove expired token, no need to keep them
+	now := time.Now()
+	for key, value := range store.revokedTokens {
+		if value.Before(now) {
+			delete(store.revokedTokens, key)
+		}
+	}
+	store.revokedTokens[jti] = expiredAt
+	return nil
+}
+
+func (store *MemoryRevocationStore) RevokeAllBefore(userId string, before time.Time) error {
+	before = before.Truncate(time.Second)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if current, isFound := store.revokedBefore[userId]; isFound && current.After(before) {
+		return nil
+	}
+	store.revokedBefore[userId] = before
+	return nil
+}
+
+func (store *MemoryRevocationStore) IsRevoked(jti string, userId string, issuedAt time.Time) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, isFound := store.revokedTokens[jti]; isFound {
+		return true, nil
+	}
+	if before, isFound := store.revokedBefore[userId]; isFound && issuedAt.Before(before) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// ==========================================
+
+type DatabaseRevocationStore struct {
+	tx *gorm.DB
+}
+
+func NewDatabaseRevocationStore(tx *gorm.DB) *DatabaseRevocationStore {
+	return &DatabaseRevocationStore{tx: tx}
+}
+
+func (store *DatabaseRevocationStore) RevokeToken(jti string, userId string, expiredAt time.Time) error {
+	now := time.Now()
+	// remove expired token, no need to keep them
+	if err := store.tx.Where("expired_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	revokedToken := models.RevokedToken{
+		JTI:       jti,
+		ExpiredAt: expiredAt,
+		CreatedAt: now,
+	}
+	// machine client (client_id) and login ceremony token has no user row
+	if IsValidUUID(userId) {
+		revokedToken.UserID = &userId
+	}
+	return store.tx.Create(&revokedToken).Error
+}
+
+func (store *DatabaseRevocationStore) RevokeAllBefore(userId string, before time.Time) error {
+	// machine client (client_id) has no user row
+	if !IsValidUUID(userId) {
+		return nil
+	}
+	before = before.Truncate(time.Second)
+	return store.tx.Model(&models.User{}).
+		Where("id = ? AND (token_revoked_before IS NULL OR token_revoked_before < ?)", userId, before).
+		Update("token_revoked_before", before).Error
+}
+
+func (store *DatabaseRevocationStore) IsRevoked(jti string, userId string, issuedAt time.Time) (bool, error) {
+	revokedToken := models.RevokedToken{}
+	err := store.tx.Where("jti = ?", jti).First(&revokedToken).Error
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if !IsValidUUID(userId) {
+		return false, nil
+	}
+	user := models.User{}
+	err = store.tx.Select("id", "token_revoked_before").Where("id = ?", userId).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if user.TokenRevokedBefore != nil && issuedAt.Before(*user.TokenRevokedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
@@ -1,12 +1,26 @@
 package core
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"encoding/base64"
+	mathRand "math/rand"
+)
 
 func GenerateRandomString(n int) string {
 	const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, n)
 	for i := range b {
-		b[i] = letterBytes[rand.Intn(len(letterBytes))]
+		b[i] = letterBytes[mathRand.Intn(len(letterBytes))]
 	}
 	return string(b)
 }
+
+// GenerateSecureToken return url safe random token from n bytes of crypto/rand,
+// use it for anything that grants access (refresh token, reset token, etc)
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,34 +1,334 @@
 package core
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
 	"github.com/BimaAdi/fiberGormBoilerplate/settings"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// HashPassword hash password using hasher configured by PASSWORD_HASHER
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 5)
-	return string(bytes), err
+	return CurrentPasswordHasher().Hash(password)
 }
 
+// CheckPasswordHash verify password against hash created by any supported hasher
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	hasher, ok := passwordHasherOf(hash)
+	if !ok {
+		return false
+	}
+	valid, err := hasher.Verify(password, hash)
+	return err == nil && valid
+}
+
+// PasswordNeedsRehash check hash created by algorithm or parameters other than PASSWORD_HASHER configuration
+func PasswordNeedsRehash(hash string) bool {
+	hasher := CurrentPasswordHasher()
+	return !hasher.Identify(hash) || hasher.NeedsRehash(hash)
+}
+
+// HashToken hash opaque token (refresh token, etc) before stored on database
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+const (
+	JWTKeyStatusActive   = "active"
+	JWTKeyStatusInactive = "inactive"
+	JWTKeyStatusRetired  = "retired"
+)
+
+// JWTKeyRing signing keys stored as json file on JWT_KEY_RING_PATH,
+// token signed using active key and verified using active or inactive key
+type JWTKeyRing struct {
+	Keys []JWTKeyRingEntry `json:"keys"`
+}
+
+type JWTKeyRingEntry struct {
+	KeyID      string    `json:"kid"`
+	Algorithm  string    `json:"algorithm"`
+	Status     string    `json:"status"`
+	Secret     string    `json:"secret,omitempty"`
+	PrivateKey string    `json:"private_key,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewJWTKeyRingEntry generate new random key for algorithm with inactive status
+func NewJWTKeyRingEntry(algorithm string, now time.Time) (JWTKeyRingEntry, error) {
+	entry := JWTKeyRingEntry{
+		Algorithm: algorithm,
+		Status:    JWTKeyStatusInactive,
+		CreatedAt: now,
+	}
+
+	var privateKey interface{}
+	var err error
+	switch jwa.SignatureAlgorithm(algorithm) {
+	case jwa.HS256:
+		entry.Secret, err = GenerateSecureToken(32)
+	case jwa.RS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwa.ES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwa.EdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return entry, errors.New("unsupported jwt algorithm " + algorithm)
+	}
+	if err != nil {
+		return entry, err
+	}
+	if privateKey != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return entry, err
+		}
+		entry.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	}
+
+	key, err := entry.JWTKey()
+	if err != nil {
+		return entry, err
+	}
+	entry.KeyID = key.KeyID
+	return entry, nil
+}
+
+// NewJWTKeyRingEntryFromSettings create active entry from JWT_SECRET or JWT_PRIVATE_KEY_PATH,
+// used as first key of new key ring so existing token still valid
+func NewJWTKeyRingEntryFromSettings(now time.Time) (JWTKeyRingEntry, error) {
+	key, err := GetJWTKey()
+	if err != nil {
+		return JWTKeyRingEntry{}, err
+	}
+	entry := JWTKeyRingEntry{
+		KeyID:     key.KeyID,
+		Algorithm: key.Algorithm.String(),
+		Status:    JWTKeyStatusActive,
+		CreatedAt: now,
+	}
+	if key.Algorithm == jwa.HS256 {
+		entry.Secret = settings.JWT_SECRET
+	} else {
+		privateKeyPEM, err := os.ReadFile(settings.JWT_PRIVATE_KEY_PATH)
+		if err != nil {
+			return entry, err
+		}
+		entry.PrivateKey = string(privateKeyPEM)
+	}
+	return entry, nil
+}
+
+func (entry JWTKeyRingEntry) JWTKey() (JWTKey, error) {
+	var privateKeyPEM []byte
+	if entry.PrivateKey != "" {
+		privateKeyPEM = []byte(entry.PrivateKey)
+	}
+	key, err := NewJWTKey(entry.Algorithm, entry.Secret, privateKeyPEM, nil)
+	if err != nil {
+		return key, err
+	}
+	key.Status = entry.Status
+	return key, nil
+}
+
+// ReadJWTKeyRing read key ring file, return empty key ring if file not exists
+func ReadJWTKeyRing(path string) (JWTKeyRing, error) {
+	ring := JWTKeyRing{Keys: []JWTKeyRingEntry{}}
+	ringBytes, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ring, nil
+		}
+		return ring, err
+	}
+	err = json.Unmarshal(ringBytes, &ring)
+	return ring, err
+}
+
+func WriteJWTKeyRing(path string, ring JWTKeyRing) error {
+	ringBytes, err := json.MarshalIndent(ring, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to temporary file first so server never read half written file
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, ringBytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (ring *JWTKeyRing) Add(entry JWTKeyRingEntry) {
+	ring.Keys = append(ring.Keys, entry)
+}
+
+// Promote make key active, previous active key become inactive (verify only)
+func (ring *JWTKeyRing) Promote(kid string) error {
+	index := ring.index(kid)
+	if index == -1 {
+		return errors.New("key " + kid + " not found")
+	}
+	if ring.Keys[index].Status == JWTKeyStatusRetired {
+		return errors.New("key " + kid + " already retired")
+	}
+	for i := range ring.Keys {
+		if ring.Keys[i].Status == JWTKeyStatusActive {
+			ring.Keys[i].Status = JWTKeyStatusInactive
+		}
+	}
+	ring.Keys[index].Status = JWTKeyStatusActive
+	return nil
+}
+
+// Retire key, token signed by retired key no longer valid
+func (ring *JWTKeyRing) Retire(kid string) error {
+	index := ring.index(kid)
+	if index == -1 {
+		return errors.New("key " + kid + " not found")
+	}
+	if ring.Keys[index].Status == JWTKeyStatusActive {
+		return errors.New("key " + kid + " is active, promote another key first")
+	}
+	ring.Keys[index].Status = JWTKeyStatusRetired
+	return nil
+}
+
+func (ring *JWTKeyRing) index(kid string) int {
+	for i, entry := range ring.Keys {
+		if entry.KeyID == kid {
+			return i
+		}
+	}
+	return -1
+}
+
+var jwtKeyRingCache struct {
+	mu       sync.Mutex
+	cacheKey string
+	keys     []JWTKey
+}
+
+// GetJWTKeys return every non retired key, active key first.
+// if JWT_KEY_RING_PATH not defined or file not exists return key from settings.
+// key ring file reloaded when changed
+func GetJWTKeys() ([]JWTKey, error) {
+	path := settings.JWT_KEY_RING_PATH
+	var ringBytes []byte
+	var err error
+	if path != "" {
+		ringBytes, err = os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if ringBytes == nil {
+		key, err := GetJWTKey()
+		if err != nil {
+			return nil, err
+		}
+		key.Status = JWTKeyStatusActive
+		return []JWTKey{key}, nil
+	}
+
+	jwtKeyRingCache.mu.Lock()
+	defer jwtKeyRingCache.mu.Unlock()
+	cacheKey := HashToken(string(ringBytes))
+	if jwtKeyRingCache.cacheKey == cacheKey {
+		return jwtKeyRingCache.keys, nil
+	}
+
+	ring := JWTKeyRing{}
+	if err := json.Unmarshal(ringBytes, &ring); err != nil {
+		return nil, err
+	}
+	activeKeys := []JWTKey{}
+	inactiveKeys := []JWTKey{}
+	for _, entry := range ring.Keys {
+		if entry.Status == JWTKeyStatusRetired {
+			continue
+		}
+		key, err := entry.JWTKey()
+		if err != nil {
+			return nil, err
+		}
+		if entry.Status == JWTKeyStatusActive {
+			activeKeys = append(activeKeys, key)
+		} else {
+			inactiveKeys = append(inactiveKeys, key)
+		}
+	}
+	if len(activeKeys) != 1 {
+		return nil, errors.New("key ring should have exactly one active key")
+	}
+
+	jwtKeyRingCache.cacheKey = cacheKey
+	jwtKeyRingCache.keys = append(activeKeys, inactiveKeys...)
+	return jwtKeyRingCache.keys, nil
+}
+
+func GetActiveJWTKey() (JWTKey, error) {
+	keys, err := GetJWTKeys()
+	if err != nil {
+		return JWTKey{}, err
+	}
+	return keys[0], nil
+}
+
+func getJWTVerifyKeySet() (jwk.Set, error) {
+	set := jwk.NewSet()
+	keys, err := GetJWTKeys()
+	if err != nil {
+		return set, err
+	}
+	for _, key := range keys {
+		if err := set.AddKey(key.VerifyKey); err != nil {
+			return set, err
+		}
+	}
+	return set, nil
 }
 
 func GenerateJWTToken(user_id string, user_email string) (string, error) {
+	return GenerateScopedJWTToken(user_id, user_email, "")
+}
+
+// GenerateScopedJWTToken generate jwt token limited to space separated scope,
+// token without scope is not limited
+func GenerateScopedJWTToken(user_id string, user_email string, scope string) (string, error) {
+	return generateAccessJWTToken(user_id, user_email, scope, "")
+}
+
+// generateAccessJWTToken generate access token, token issued for a login session carry sid claim
+func generateAccessJWTToken(user_id string, user_email string, scope string, sessionId string) (string, error) {
 	// Generate Payload
 	expiredAt := time.Now().Add(time.Minute * time.Duration(settings.ACCESS_TOKEN_EXPIRE_MINUTES))
 	tok, err := jwt.NewBuilder().
+		JwtID(uuid.NewString()).
 		IssuedAt(time.Now()).
 		Expiration(expiredAt).
 		Build()
@@ -37,24 +337,131 @@ func GenerateJWTToken(user_id string, user_email string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if scope != "" {
+		tok.Set("scope", scope)
+	}
+	if sessionId != "" {
+		tok.Set(sessionIdClaim, sessionId)
+	}
+
+	return signJWTToken(tok)
+}
 
-	// Sign a JWT!
-	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.HS256, []byte(settings.JWT_SECRET)))
+// signJWTToken sign jwt token using active key
+func signJWTToken(tok jwt.Token) (string, error) {
+	key, err := GetActiveJWTKey()
+	if err != nil {
+		return "", err
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(key.Algorithm, key.SigningKey))
 	if err != nil {
 		return "", err
 	}
 	return string(signed[:]), nil
-
 }
 
-func GetPayloadFromJWTToken(jwtToken string) (string, string, error) {
-	tok, err := jwt.Parse([]byte(jwtToken), jwt.WithKey(jwa.HS256, []byte(settings.JWT_SECRET)), jwt.WithValidate(false))
+// verifyJWTToken parse jwt token, verify the signature and validate expiration
+func verifyJWTToken(jwtToken string) (jwt.Token, error) {
+	// token without kid (issued before key ring) verified using every key
+	set, err := getJWTVerifyKeySet()
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	tok, err := jwt.Parse([]byte(jwtToken), jwt.WithKeySet(set, jws.WithRequireKid(false)), jwt.WithValidate(false))
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate token
 	err = jwt.Validate(tok)
+	if err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// generateTypedJWTToken generate short lived token for a step of login ceremony
+// (two factor challenge, webauthn session). The token has token_type claim and no id claim
+// so it could not be used as access token
+func generateTypedJWTToken(tokenType string, subject string, expireMinutes int, claims map[string]interface{}) (string, error) {
+	// Generate Payload
+	expiredAt := time.Now().Add(time.Minute * time.Duration(expireMinutes))
+	tok, err := jwt.NewBuilder().
+		JwtID(uuid.NewString()).
+		Subject(subject).
+		IssuedAt(time.Now()).
+		Expiration(expiredAt).
+		Build()
+	if err != nil {
+		return "", err
+	}
+	tok.Set("token_type", tokenType)
+	for key, value := range claims {
+		tok.Set(key, value)
+	}
+
+	return signJWTToken(tok)
+}
+
+// parseTypedJWTToken parse, validate and check revocation of token generated by generateTypedJWTToken
+func parseTypedJWTToken(jwtToken string, tokenType string) (jwt.Token, error) {
+	tok, err := verifyJWTToken(jwtToken)
+	if err != nil {
+		return nil, err
+	}
+	currentTokenType, _ := tok.Get("token_type")
+	if fmt.Sprint(currentTokenType) != tokenType || tok.JwtID() == "" {
+		return nil, errors.New("invalid " + tokenType + " token")
+	}
+
+	// Check revoked token (typed token is single use)
+	isRevoked, err := TokenRevocationStore.IsRevoked(tok.JwtID(), tok.Subject(), tok.IssuedAt())
+	if err != nil {
+		return nil, err
+	}
+	if isRevoked {
+		return nil, errors.New(tokenType + " token revoked")
+	}
+	return tok, nil
+}
+
+// revokeTypedJWTToken revoke used token generated by generateTypedJWTToken
+func revokeTypedJWTToken(jwtToken string) error {
+	tok, err := verifyJWTToken(jwtToken)
+	if err != nil {
+		return err
+	}
+	return TokenRevocationStore.RevokeToken(tok.JwtID(), tok.Subject(), tok.Expiration())
+}
+
+// ParseJWTToken parse, validate and check revocation of jwt token
+func ParseJWTToken(jwtToken string) (jwt.Token, error) {
+	tok, err := verifyJWTToken(jwtToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check revoked token
+	if tok.JwtID() == "" {
+		return nil, errors.New("jti not found on token payload")
+	}
+	id, err := getPrincipalIdFromJWTToken(tok)
+	if err != nil {
+		return nil, err
+	}
+	isRevoked, err := TokenRevocationStore.IsRevoked(tok.JwtID(), id, tok.IssuedAt())
+	if err != nil {
+		return nil, err
+	}
+	if isRevoked {
+		return nil, errors.New("token revoked")
+	}
+
+	return tok, nil
+}
+
+func GetPayloadFromJWTToken(jwtToken string) (string, string, error) {
+	tok, err := ParseJWTToken(jwtToken)
 	if err != nil {
 		return "", "", err
 	}
@@ -72,52 +479,125 @@ func GetPayloadFromJWTToken(jwtToken string) (string, string, error) {
 	return fmt.Sprint(id), fmt.Sprint(email), nil
 }
 
+// RevokeJWTToken revoke the given jwt token (logout)
+func RevokeJWTToken(jwtToken string) error {
+	tok, err := ParseJWTToken(jwtToken)
+	if err != nil {
+		return err
+	}
+	id, err := getPrincipalIdFromJWTToken(tok)
+	if err != nil {
+		return err
+	}
+	return TokenRevocationStore.RevokeToken(tok.JwtID(), id, tok.Expiration())
+}
+
 func GenerateJWTTokenFromUser(tx *gorm.DB, user models.User) (string, error) {
 	tok, err := GenerateJWTToken(user.ID, user.Email)
 	return tok, err
 }
 
+func GenerateScopedJWTTokenFromUser(tx *gorm.DB, user models.User, scope string) (string, error) {
+	return GenerateScopedJWTToken(user.ID, user.Email, scope)
+}
+
+var ErrUserInactive = errors.New("user inactive")
+
+// CheckUserStatus return ErrUserInactive if user deactivated or deleted,
+// checked on every login and every token use
+func CheckUserStatus(user models.User) error {
+	if !user.IsActive || user.DeletedAt != nil {
+		return ErrUserInactive
+	}
+	return nil
+}
+
 func GetUserFromJWTToken(tx *gorm.DB, jwtToken string) (models.User, error) {
+	user, _, err := GetUserAndImpersonatorFromJWTToken(tx, jwtToken)
+	return user, err
+}
+
+// GetUserAndImpersonatorFromJWTToken get user of jwt token and superuser impersonating the user
+// (act claim, nil if token is not impersonation token). Impersonator should still active superuser
+// and session of the token (sid claim) should not be terminated
+func GetUserAndImpersonatorFromJWTToken(tx *gorm.DB, jwtToken string) (models.User, *models.User, error) {
 	user := models.User{}
-	userId, _, err := GetPayloadFromJWTToken(jwtToken)
+	tok, err := ParseJWTToken(jwtToken)
 	if err != nil {
-		return user, err
+		return user, nil, err
+	}
+	userId, isIdFound := tok.Get("id")
+	if !isIdFound {
+		return user, nil, errors.New("id not found on token payload")
+	}
+	if _, isEmailFound := tok.Get("email"); !isEmailFound {
+		return user, nil, errors.New("email not found on token payload")
 	}
 
-	if err := models.DBConn.Where("id = ? AND deleted_at IS NULL", userId).First(&user).Error; err != nil {
-		return user, err
+	if err := models.DBConn.Where("id = ? AND deleted_at IS NULL", fmt.Sprint(userId)).First(&user).Error; err != nil {
+		return user, nil, err
+	}
+	if err := CheckUserStatus(user); err != nil {
+		return user, nil, err
 	}
 
-	return user, nil
+	if err := checkJWTTokenSession(tok, user.ID, time.Now()); err != nil {
+		return user, nil, err
+	}
+
+	impersonator, err := getImpersonatorFromJWTToken(tok)
+	if err != nil {
+		return user, nil, err
+	}
+	return user, impersonator, nil
 }
 
 type Header struct {
 	Authorization string `regHeader:"authorization"`
 }
 
-func GetUserFromAuthorizationHeader(tx *gorm.DB, c *fiber.Ctx) (models.User, error) {
+func GetTokenFromAuthorizationHeader(c *fiber.Ctx) (string, error) {
 	header := new(Header)
 
 	if err := c.ReqHeaderParser(header); err != nil {
-		return models.User{}, err
+		return "", err
 	}
 	authHeader := header.Authorization
 
 	arrayHeader := strings.Fields(authHeader)
 	if len(arrayHeader) != 2 {
-		return models.User{}, errors.New("invalid token key lenght no 2")
+		return "", errors.New("invalid token key lenght no 2")
 	}
 
 	key := arrayHeader[0]
 	token := arrayHeader[1]
 	if key != "Bearer" {
-		return models.User{}, errors.New("invalid token key not Bearer")
+		return "", errors.New("invalid token key not Bearer")
+	}
+	return token, nil
+}
+
+// GetUserFromAuthorizationHeader get user from Bearer jwt token or api key (ApiKey scheme or X-API-Key header).
+// scope of the token or api key is discarded, use GetPrincipalFromAuthorizationHeader on route enforcing scope
+func GetUserFromAuthorizationHeader(tx *gorm.DB, c *fiber.Ctx) (models.User, error) {
+	if apiKey, isFound := GetApiKeyFromRequest(c); isFound {
+		user, _, err := GetUserFromApiKey(tx, apiKey)
+		if err != nil {
+			return models.User{}, errors.New("invalid api key")
+		}
+		return user, nil
+	}
+
+	token, err := GetTokenFromAuthorizationHeader(c)
+	if err != nil {
+		return models.User{}, err
 	}
 
-	user, err := GetUserFromJWTToken(tx, token)
+	user, impersonator, err := GetUserAndImpersonatorFromJWTToken(tx, token)
 	if err != nil {
 		return models.User{}, errors.New("invalid token")
 	}
+	setImpersonationLocals(c, user, impersonator)
 
 	return user, nil
 }
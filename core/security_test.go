@@ -1,12 +1,15 @@
 package core_test
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
 	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -27,6 +30,105 @@ func TestExpiredJWTToken(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestRevokeJWTToken(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	token, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+	assert.Nil(t, err)
+	otherToken, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+	assert.Nil(t, err)
+
+	err = core.RevokeJWTToken(token)
+	assert.Nil(t, err)
+	_, _, err = core.GetPayloadFromJWTToken(token)
+	assert.NotNil(t, err)
+	_, _, err = core.GetPayloadFromJWTToken(otherToken)
+	assert.Nil(t, err)
+
+	err = core.TokenRevocationStore.RevokeAllBefore("aaaaa-bbbbb-ccccc", time.Now().Add(time.Second))
+	assert.Nil(t, err)
+	_, _, err = core.GetPayloadFromJWTToken(otherToken)
+	assert.NotNil(t, err)
+
+	// token issued on the same second after revoke all (iat has second precision) not revoked
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	now := time.Now()
+	err = core.TokenRevocationStore.RevokeAllBefore("aaaaa-bbbbb-ccccc", now)
+	assert.Nil(t, err)
+	isRevoked, err := core.TokenRevocationStore.IsRevoked("other-jti", "aaaaa-bbbbb-ccccc", now.Truncate(time.Second))
+	assert.Nil(t, err)
+	assert.False(t, isRevoked)
+	isRevoked, err = core.TokenRevocationStore.IsRevoked("other-jti", "aaaaa-bbbbb-ccccc", now.Truncate(time.Second).Add(-time.Second))
+	assert.Nil(t, err)
+	assert.True(t, isRevoked)
+}
+
+func TestSessionJWTToken(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	user := models.User{ID: "aaaaa-bbbbb-ccccc", Email: "bimaadi419@gmail.com"}
+	token, err := core.GenerateSessionJWTTokenFromUser(models.DBConn, user, "", "ddddd-eeeee-fffff")
+	assert.Nil(t, err)
+	assert.Equal(t, "ddddd-eeeee-fffff", core.GetSessionIdFromJWTToken(token))
+
+	// token not issued for a login session has no session id
+	token, err = core.GenerateJWTTokenFromUser(models.DBConn, user)
+	assert.Nil(t, err)
+	assert.Equal(t, "", core.GetSessionIdFromJWTToken(token))
+}
+
+func TestCheckUserStatus(t *testing.T) {
+	deletedAt := time.Now()
+	assert.Nil(t, core.CheckUserStatus(models.User{IsActive: true}))
+	assert.ErrorIs(t, core.CheckUserStatus(models.User{IsActive: false}), core.ErrUserInactive)
+	assert.ErrorIs(t, core.CheckUserStatus(models.User{IsActive: true, DeletedAt: &deletedAt}), core.ErrUserInactive)
+}
+
+func TestJWTKeyRing(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	defer settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	settings.JWT_KEY_RING_PATH = filepath.Join(t.TempDir(), "jwt_key_ring.json")
+
+	// token signed before key ring created
+	legacyToken, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+	assert.Nil(t, err)
+
+	// create key ring
+	ring := core.JWTKeyRing{}
+	oldEntry, err := core.NewJWTKeyRingEntryFromSettings(time.Now())
+	assert.Nil(t, err)
+	ring.Add(oldEntry)
+	newEntry, err := core.NewJWTKeyRingEntry("ES256", time.Now())
+	assert.Nil(t, err)
+	ring.Add(newEntry)
+	assert.Nil(t, core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring))
+	oldToken, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+	assert.Nil(t, err)
+
+	// promote new key
+	assert.Nil(t, ring.Promote(newEntry.KeyID))
+	assert.Nil(t, core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring))
+	newToken, err := core.GenerateJWTToken("aaaaa-bbbbb-ccccc", "bimaadi419@gmail.com")
+	assert.Nil(t, err)
+	message, err := jws.Parse([]byte(newToken))
+	assert.Nil(t, err)
+	assert.Equal(t, newEntry.KeyID, message.Signatures()[0].ProtectedHeaders().KeyID())
+	for _, token := range []string{legacyToken, oldToken, newToken} {
+		_, _, err = core.GetPayloadFromJWTToken(token)
+		assert.Nil(t, err)
+	}
+
+	// retire old key
+	assert.NotNil(t, ring.Retire(newEntry.KeyID))
+	assert.Nil(t, ring.Retire(oldEntry.KeyID))
+	assert.Nil(t, core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring))
+	_, _, err = core.GetPayloadFromJWTToken(oldToken)
+	assert.NotNil(t, err)
+	_, _, err = core.GetPayloadFromJWTToken(newToken)
+	assert.Nil(t, err)
+}
+
 type MigrateTestSuite struct {
 	suite.Suite
 }
@@ -75,6 +177,41 @@ func (suite *MigrateTestSuite) TestGetUserFromJwtTokenInvalidToken() {
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *MigrateTestSuite) TestDatabaseRevocationStoreNonUserToken() {
+	// Given
+	store := core.TokenRevocationStore
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	defer func() { core.TokenRevocationStore = store }()
+	user := models.User{
+		Email:       "bimaadi419@gmail.com",
+		Password:    "hashpassword",
+		IsActive:    true,
+		IsSuperuser: true,
+	}
+	models.DBConn.Create(&user)
+	userToken, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	assert.Nil(suite.T(), err)
+	clientToken, err := core.GenerateJWTTokenFromClient(models.OAuthClient{ClientID: "ci-client"}, "user:read")
+	assert.Nil(suite.T(), err)
+
+	// When
+	errUser := core.RevokeJWTToken(userToken)
+	errClient := core.RevokeJWTToken(clientToken)
+
+	// Expect
+	assert.Nil(suite.T(), errUser)
+	assert.Nil(suite.T(), errClient)
+	_, err = core.ParseJWTToken(userToken)
+	assert.NotNil(suite.T(), err)
+	_, err = core.ParseJWTToken(clientToken)
+	assert.NotNil(suite.T(), err)
+	revokedTokens := []models.RevokedToken{}
+	models.DBConn.Order("user_id NULLS LAST").Find(&revokedTokens)
+	assert.Len(suite.T(), revokedTokens, 2)
+	assert.Equal(suite.T(), user.ID, *revokedTokens[0].UserID)
+	assert.Nil(suite.T(), revokedTokens[1].UserID)
+}
+
 // ==========================================
 
 func (suite *MigrateTestSuite) TearDownTest() {
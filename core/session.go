@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"gorm.io/gorm"
+)
+
+// sessionIdClaim claim of access token keeping id of the login session
+const sessionIdClaim = "sid"
+
+// sessionLastSeenInterval last seen of session updated at most once per interval,
+// so not every request write to database
+const sessionLastSeenInterval = time.Minute
+
+var ErrSessionTerminated = errors.New("session terminated")
+
+// SessionInfo device of the login, recorded on the session
+type SessionInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// GetSessionInfo get user agent and ip address of the request
+func GetSessionInfo(c *fiber.Ctx) SessionInfo {
+	return SessionInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+	}
+}
+
+// GenerateSessionJWTTokenFromUser generate access token of the login session limited to space separated scope
+func GenerateSessionJWTTokenFromUser(tx *gorm.DB, user models.User, scope string, sessionId string) (string, error) {
+	return generateAccessJWTToken(user.ID, user.Email, scope, sessionId)
+}
+
+// GetSessionIdFromJWTToken get session id (sid claim) of valid jwt token,
+// empty if token not issued for a login session (impersonation, client credentials, ...)
+func GetSessionIdFromJWTToken(jwtToken string) string {
+	tok, err := ParseJWTToken(jwtToken)
+	if err != nil {
+		return ""
+	}
+	sessionId, _ := tok.Get(sessionIdClaim)
+	if sessionId == nil {
+		return ""
+	}
+	return fmt.Sprint(sessionId)
+}
+
+// checkJWTTokenSession return ErrSessionTerminated if session on sid claim terminated or not belong to the user,
+// token without sid claim is not checked. Last seen of the session updated
+func checkJWTTokenSession(tok jwt.Token, userId string, now time.Time) error {
+	sessionId, isSessionIdFound := tok.Get(sessionIdClaim)
+	if !isSessionIdFound {
+		return nil
+	}
+
+	session := models.UserSession{}
+	if err := models.DBConn.Where("id = ? AND user_id = ?", fmt.Sprint(sessionId), userId).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionTerminated
+		}
+		return err
+	}
+	if session.TerminatedAt != nil {
+		return ErrSessionTerminated
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionLastSeenInterval {
+		return models.DBConn.Model(&models.UserSession{}).
+			Where("id = ?", session.ID).
+			Update("last_seen_at", now).Error
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+// TOTP parameter (RFC 6238), the default supported by every authenticator app
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	// TOTPSkew number of step before and after current step still accepted (clock drift)
+	TOTPSkew = 1
+)
+
+const twoFactorChallengeTokenType = "2fa_challenge"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret generate random 160 bit base32 encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI otpauth uri for authenticator app (usually shown as QR code)
+func TOTPProvisioningURI(secret string, accountName string) string {
+	issuer := settings.TOTP_ISSUER
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(TOTPPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+accountName) + "?" + query.Encode()
+}
+
+// TOTPStep time step of t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// GenerateTOTPCode generate code of the given time step (HOTP of RFC 4226 using the step as counter)
+func GenerateTOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// VerifyTOTPCode verify code at now within TOTPSkew step, step not after lastUsedStep is rejected (replay).
+// return the matched step
+func VerifyTOTPCode(secret string, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	currentStep := TOTPStep(now)
+	for step := currentStep - TOTPSkew; step <= currentStep+TOTPSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected, err := GenerateTOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes generate n random one time recovery code formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := []string{}
+	for i := 0; i < n; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(b)
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode normalize user input before hashed, so code is case and dash insensitive
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+// GenerateTwoFactorChallengeToken generate short lived token proving user passed password check,
+// exchanged with TOTP or recovery code for access token
+func GenerateTwoFactorChallengeToken(user models.User, scope string) (string, error) {
+	claims := map[string]interface{}{}
+	if scope != "" {
+		claims["scope"] = scope
+	}
+	return generateTypedJWTToken(
+		twoFactorChallengeTokenType, user.ID, settings.TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES, claims,
+	)
+}
+
+// ParseTwoFactorChallengeToken validate challenge token,
+// return (user_id, scope, error)
+func ParseTwoFactorChallengeToken(challengeToken string) (string, string, error) {
+	tok, err := parseTypedJWTToken(challengeToken, twoFactorChallengeTokenType)
+	if err != nil {
+		return "", "", err
+	}
+	if tok.Subject() == "" {
+		return "", "", errors.New("invalid challenge token")
+	}
+
+	scope, isScopeFound := tok.Get("scope")
+	if !isScopeFound {
+		return tok.Subject(), "", nil
+	}
+	return tok.Subject(), fmt.Sprint(scope), nil
+}
+
+// RevokeTwoFactorChallengeToken revoke used challenge token
+func RevokeTwoFactorChallengeToken(challengeToken string) error {
+	return revokeTypedJWTToken(challengeToken)
+}
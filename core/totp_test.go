@@ -0,0 +1,99 @@
+package core_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// RFC 6238 test vector (SHA1), truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	testCases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range testCases {
+		code, err := core.GenerateTOTPCode(secret, core.TOTPStep(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestVerifyTOTPCode(t *testing.T) {
+	secret, err := core.GenerateTOTPSecret()
+	assert.Nil(t, err)
+	now := time.Date(2022, 10, 5, 10, 0, 0, 0, time.UTC)
+	code, err := core.GenerateTOTPCode(secret, core.TOTPStep(now))
+	assert.Nil(t, err)
+
+	step, isMatch := core.VerifyTOTPCode(secret, code, now, 0)
+	assert.True(t, isMatch)
+	assert.Equal(t, core.TOTPStep(now), step)
+
+	// clock drift
+	_, isMatch = core.VerifyTOTPCode(secret, code, now.Add(30*time.Second), 0)
+	assert.True(t, isMatch)
+	_, isMatch = core.VerifyTOTPCode(secret, code, now.Add(2*time.Minute), 0)
+	assert.False(t, isMatch)
+
+	// replay
+	_, isMatch = core.VerifyTOTPCode(secret, code, now, step)
+	assert.False(t, isMatch)
+
+	_, isMatch = core.VerifyTOTPCode(secret, "12345", now, 0)
+	assert.False(t, isMatch)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	settings.TOTP_ISSUER = "Example"
+	uri := core.TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "test")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Example:test?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Example")
+}
+
+func TestRecoveryCode(t *testing.T) {
+	codes, err := core.GenerateRecoveryCodes(10)
+	assert.Nil(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, codes[0], 11)
+	assert.Equal(t, codes[0], core.NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))))
+}
+
+func TestTwoFactorChallengeToken(t *testing.T) {
+	settings.InitiateSettings("../.env")
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	user := models.User{ID: "5b1c1a8e-3f0e-4e8e-9a57-0f2d7c1b6a10", Email: "test@test.com"}
+	challengeToken, err := core.GenerateTwoFactorChallengeToken(user, "user:read")
+	assert.Nil(t, err)
+
+	userId, scope, err := core.ParseTwoFactorChallengeToken(challengeToken)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userId)
+	assert.Equal(t, "user:read", scope)
+
+	// challenge token is not access token
+	_, err = core.ParseJWTToken(challengeToken)
+	assert.NotNil(t, err)
+
+	// access token is not challenge token
+	token, err := core.GenerateJWTToken(user.ID, user.Email)
+	assert.Nil(t, err)
+	_, _, err = core.ParseTwoFactorChallengeToken(token)
+	assert.NotNil(t, err)
+
+	// single use
+	err = core.RevokeTwoFactorChallengeToken(challengeToken)
+	assert.Nil(t, err)
+	_, _, err = core.ParseTwoFactorChallengeToken(challengeToken)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const webAuthnRegistrationTokenType = "webauthn_registration"
+const webAuthnLoginTokenType = "webauthn_login"
+
+// WebAuthnUser user and it's registered credentials, implement webauthn.User.
+// user handle of the credential is the user id
+type WebAuthnUser struct {
+	User        models.User
+	Credentials []models.WebAuthnCredential
+}
+
+func (user WebAuthnUser) WebAuthnID() []byte {
+	return []byte(user.User.ID)
+}
+
+func (user WebAuthnUser) WebAuthnName() string {
+	return user.User.Username
+}
+
+func (user WebAuthnUser) WebAuthnDisplayName() string {
+	return user.User.Username
+}
+
+func (user WebAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (user WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := []webauthn.Credential{}
+	for _, item := range user.Credentials {
+		credentialId, err := base64.RawURLEncoding.DecodeString(item.CredentialID)
+		if err != nil {
+			continue
+		}
+		transports := []protocol.AuthenticatorTransport{}
+		for _, transport := range SplitSpaceSeparated(item.Transport) {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              credentialId,
+			PublicKey:       item.PublicKey,
+			AttestationType: item.AttestationType,
+			Transport:       transports,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    item.AAGUID,
+				SignCount: uint32(item.SignCount),
+			},
+		})
+	}
+	return credentials
+}
+
+// EncodeWebAuthnCredentialID encode raw credential id as stored on database
+func EncodeWebAuthnCredentialID(credentialId []byte) string {
+	return base64.RawURLEncoding.EncodeToString(credentialId)
+}
+
+// EncodeWebAuthnTransport encode credential transports as stored on database
+func EncodeWebAuthnTransport(transports []protocol.AuthenticatorTransport) string {
+	items := []string{}
+	for _, transport := range transports {
+		items = append(items, string(transport))
+	}
+	return strings.Join(items, " ")
+}
+
+// GetWebAuthn relying party configured from settings
+func GetWebAuthn() (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          settings.WEBAUTHN_RP_ID,
+		RPDisplayName: settings.WEBAUTHN_RP_DISPLAY_NAME,
+		RPOrigins:     SplitSpaceSeparated(settings.WEBAUTHN_RP_ORIGINS),
+	})
+}
+
+// generateWebAuthnSessionToken keep ceremony session data on short lived signed token
+// so the server keep no state between begin and finish
+func generateWebAuthnSessionToken(tokenType string, subject string, session *webauthn.SessionData) (string, error) {
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	return generateTypedJWTToken(tokenType, subject, settings.WEBAUTHN_SESSION_EXPIRE_MINUTES, map[string]interface{}{
+		"session": string(sessionJSON),
+	})
+}
+
+// useWebAuthnSessionToken parse session token and revoke it, session only used once.
+// return (subject, session, error)
+func useWebAuthnSessionToken(sessionToken string, tokenType string) (string, webauthn.SessionData, error) {
+	session := webauthn.SessionData{}
+	tok, err := parseTypedJWTToken(sessionToken, tokenType)
+	if err != nil {
+		return "", session, err
+	}
+	sessionJSON, isFound := tok.Get("session")
+	if !isFound {
+		return "", session, errors.New("session not found on token payload")
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprint(sessionJSON)), &session); err != nil {
+		return "", session, err
+	}
+	if err := revokeTypedJWTToken(sessionToken); err != nil {
+		return "", session, err
+	}
+	return tok.Subject(), session, nil
+}
+
+// BeginWebAuthnRegistration return credential creation options for navigator.credentials.create()
+// and session token needed to finish the registration
+func BeginWebAuthnRegistration(user WebAuthnUser) (*protocol.CredentialCreation, string, error) {
+	relyingParty, err := GetWebAuthn()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Registered credential should not registered twice
+	excludeList := []protocol.CredentialDescriptor{}
+	for _, credential := range user.WebAuthnCredentials() {
+		excludeList = append(excludeList, credential.Descriptor())
+	}
+	options, session, err := relyingParty.BeginRegistration(
+		user,
+		webauthn.WithExclusions(excludeList),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionToken, err := generateWebAuthnSessionToken(webAuthnRegistrationTokenType, user.User.ID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, sessionToken, nil
+}
+
+// FinishWebAuthnRegistration verify attestation of new credential (PublicKeyCredential json)
+// created using options from BeginWebAuthnRegistration
+func FinishWebAuthnRegistration(user WebAuthnUser, sessionToken string, credentialJSON []byte) (*webauthn.Credential, error) {
+	relyingParty, err := GetWebAuthn()
+	if err != nil {
+		return nil, err
+	}
+	userId, session, err := useWebAuthnSessionToken(sessionToken, webAuthnRegistrationTokenType)
+	if err != nil {
+		return nil, err
+	}
+	if userId != user.User.ID {
+		return nil, errors.New("session is not for the user")
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(credentialJSON))
+	if err != nil {
+		return nil, err
+	}
+	return relyingParty.CreateCredential(user, session, parsedResponse)
+}
+
+// BeginWebAuthnLogin return credential request options for navigator.credentials.get()
+// and session token needed to finish the login. Nil user start discoverable login (passkey without username)
+func BeginWebAuthnLogin(user *WebAuthnUser) (*protocol.CredentialAssertion, string, error) {
+	relyingParty, err := GetWebAuthn()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var options *protocol.CredentialAssertion
+	var session *webauthn.SessionData
+	subject := ""
+	if user == nil {
+		options, session, err = relyingParty.BeginDiscoverableLogin()
+	} else {
+		options, session, err = relyingParty.BeginLogin(user)
+		subject = user.User.ID
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionToken, err := generateWebAuthnSessionToken(webAuthnLoginTokenType, subject, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, sessionToken, nil
+}
+
+// FinishWebAuthnLogin verify assertion (PublicKeyCredential json) created using options from BeginWebAuthnLogin.
+// getUser get user and it's credentials by user id (from session or user handle of discoverable credential).
+// Credential with sign count not increased rejected because the authenticator may be cloned.
+// return the user and used credential with new sign count
+func FinishWebAuthnLogin(sessionToken string, credentialJSON []byte, getUser func(userId string) (WebAuthnUser, error)) (WebAuthnUser, *webauthn.Credential, error) {
+	relyingParty, err := GetWebAuthn()
+	if err != nil {
+		return WebAuthnUser{}, nil, err
+	}
+	userId, session, err := useWebAuthnSessionToken(sessionToken, webAuthnLoginTokenType)
+	if err != nil {
+		return WebAuthnUser{}, nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(credentialJSON))
+	if err != nil {
+		return WebAuthnUser{}, nil, err
+	}
+
+	var user WebAuthnUser
+	var credential *webauthn.Credential
+	if userId != "" {
+		user, err = getUser(userId)
+		if err != nil {
+			return user, nil, err
+		}
+		credential, err = relyingParty.ValidateLogin(user, session, parsedResponse)
+	} else {
+		credential, err = relyingParty.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			user, err = getUser(string(userHandle))
+			return user, err
+		}, session, parsedResponse)
+	}
+	if err != nil {
+		return user, nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return user, nil, errors.New("sign count not increased, authenticator may be cloned")
+	}
+	return user, credential, nil
+}
@@ -0,0 +1,121 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/webauthntest"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+// credentialToModel store credential as repository.CreateWebAuthnCredential
+func credentialToModel(userId string, credential *webauthn.Credential) models.WebAuthnCredential {
+	return models.WebAuthnCredential{
+		UserID:          userId,
+		Name:            "laptop",
+		CredentialID:    core.EncodeWebAuthnCredentialID(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       core.EncodeWebAuthnTransport(credential.Transport),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		CreatedAt:       time.Now(),
+	}
+}
+
+func TestWebAuthnCeremony(t *testing.T) {
+	// Given
+	settings.InitiateSettings("../.env")
+	settings.WEBAUTHN_RP_ID = "localhost"
+	settings.WEBAUTHN_RP_ORIGINS = "http://localhost:8000"
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	authenticator := webauthntest.NewAuthenticator("http://localhost:8000")
+	user := core.WebAuthnUser{
+		User: models.User{ID: "5b1c1a8e-3f0e-4e8e-9a57-0f2d7c1b6a10", Username: "test"},
+	}
+
+	// When register
+	creationOptions, sessionToken, err := core.BeginWebAuthnRegistration(user)
+	assert.Nil(t, err)
+	credentialJSON, err := authenticator.CreateCredential(*creationOptions)
+	assert.Nil(t, err)
+	credential, err := core.FinishWebAuthnRegistration(user, sessionToken, credentialJSON)
+
+	// Expect
+	assert.Nil(t, err)
+	assert.Equal(t, authenticator.Credentials[0].ID, credential.ID)
+	user.Credentials = append(user.Credentials, credentialToModel(user.User.ID, credential))
+
+	// Expect session token single use
+	_, err = core.FinishWebAuthnRegistration(user, sessionToken, credentialJSON)
+	assert.NotNil(t, err)
+
+	// When login with username
+	getUser := func(userId string) (core.WebAuthnUser, error) {
+		if userId != user.User.ID {
+			return core.WebAuthnUser{}, errors.New("user not found")
+		}
+		return user, nil
+	}
+	assertionOptions, sessionToken, err := core.BeginWebAuthnLogin(&user)
+	assert.Nil(t, err)
+	assertionJSON, err := authenticator.GetAssertion(*assertionOptions)
+	assert.Nil(t, err)
+	loginUser, usedCredential, err := core.FinishWebAuthnLogin(sessionToken, assertionJSON, getUser)
+
+	// Expect
+	assert.Nil(t, err)
+	assert.Equal(t, user.User.ID, loginUser.User.ID)
+	assert.Equal(t, uint32(1), usedCredential.Authenticator.SignCount)
+	user.Credentials[0].SignCount = int64(usedCredential.Authenticator.SignCount)
+
+	// When discoverable login
+	assertionOptions, sessionToken, err = core.BeginWebAuthnLogin(nil)
+	assert.Nil(t, err)
+	assertionJSON, err = authenticator.GetAssertion(*assertionOptions)
+	assert.Nil(t, err)
+	loginUser, usedCredential, err = core.FinishWebAuthnLogin(sessionToken, assertionJSON, getUser)
+
+	// Expect
+	assert.Nil(t, err)
+	assert.Equal(t, user.User.ID, loginUser.User.ID)
+	assert.Equal(t, uint32(2), usedCredential.Authenticator.SignCount)
+
+	// When sign count not increased (cloned authenticator)
+	authenticator.Credentials[0].SignCount = 0
+	assertionOptions, sessionToken, err = core.BeginWebAuthnLogin(&user)
+	assert.Nil(t, err)
+	assertionJSON, err = authenticator.GetAssertion(*assertionOptions)
+	assert.Nil(t, err)
+	_, _, err = core.FinishWebAuthnLogin(sessionToken, assertionJSON, getUser)
+
+	// Expect
+	assert.NotNil(t, err)
+}
+
+func TestWebAuthnWrongOrigin(t *testing.T) {
+	// Given
+	settings.InitiateSettings("../.env")
+	settings.WEBAUTHN_RP_ID = "localhost"
+	settings.WEBAUTHN_RP_ORIGINS = "http://localhost:8000"
+	core.TokenRevocationStore = core.NewMemoryRevocationStore()
+	authenticator := webauthntest.NewAuthenticator("http://evil.example.com")
+	user := core.WebAuthnUser{
+		User: models.User{ID: "5b1c1a8e-3f0e-4e8e-9a57-0f2d7c1b6a10", Username: "test"},
+	}
+
+	// When
+	creationOptions, sessionToken, err := core.BeginWebAuthnRegistration(user)
+	assert.Nil(t, err)
+	credentialJSON, err := authenticator.CreateCredential(*creationOptions)
+	assert.Nil(t, err)
+	_, err = core.FinishWebAuthnRegistration(user, sessionToken, credentialJSON)
+
+	// Expect
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,178 @@
+// Package webauthntest provide software authenticator to test webauthn ceremonies without browser
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// Authenticator software authenticator using ES256 key and none attestation,
+// every credential is discoverable (resident key) and user always verified
+type Authenticator struct {
+	Origin      string
+	Credentials []*Credential
+}
+
+type Credential struct {
+	ID         []byte
+	UserHandle []byte
+	PrivateKey *ecdsa.PrivateKey
+	SignCount  uint32
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+// CreateCredential act as navigator.credentials.create(), return PublicKeyCredential json
+func (authenticator *Authenticator) CreateCredential(options protocol.CredentialCreation) ([]byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	credentialId := make([]byte, 32)
+	if _, err := rand.Read(credentialId); err != nil {
+		return nil, err
+	}
+	credential := &Credential{
+		ID:         credentialId,
+		UserHandle: options.Response.User.ID,
+		PrivateKey: privateKey,
+	}
+
+	// COSE encoded public key
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: privateKey.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: privateKey.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// authenticator data with attested credential data
+	attestedCredentialData := make([]byte, 16) // zero aaguid
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(credentialId)))
+	attestedCredentialData = append(attestedCredentialData, credentialId...)
+	attestedCredentialData = append(attestedCredentialData, publicKey...)
+	authData := authenticator.authenticatorData(
+		options.Response.RelyingParty.ID,
+		flagUserPresent|flagUserVerified|flagAttestedCredentialData,
+		credential.SignCount,
+	)
+	authData = append(authData, attestedCredentialData...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clientDataJSON, err := authenticator.clientDataJSON("webauthn.create", options.Response.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator.Credentials = append(authenticator.Credentials, credential)
+	return json.Marshal(map[string]interface{}{
+		"id":    encode(credentialId),
+		"rawId": encode(credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientDataJSON),
+			"attestationObject": encode(attestationObject),
+		},
+		"transports": []string{"internal"},
+	})
+}
+
+// GetAssertion act as navigator.credentials.get(), return PublicKeyCredential json.
+// credential chosen from allowed credentials or first credential for discoverable login
+func (authenticator *Authenticator) GetAssertion(options protocol.CredentialAssertion) ([]byte, error) {
+	credential := authenticator.findCredential(options.Response.AllowedCredentials)
+	if credential == nil {
+		return nil, errors.New("no credential found")
+	}
+	credential.SignCount++
+
+	authData := authenticator.authenticatorData(
+		options.Response.RelyingPartyID, flagUserPresent|flagUserVerified, credential.SignCount,
+	)
+	clientDataJSON, err := authenticator.clientDataJSON("webauthn.get", options.Response.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, credential.PrivateKey, signedData[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"id":    encode(credential.ID),
+		"rawId": encode(credential.ID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientDataJSON),
+			"authenticatorData": encode(authData),
+			"signature":         encode(signature),
+			"userHandle":        encode(credential.UserHandle),
+		},
+	})
+}
+
+func (authenticator *Authenticator) findCredential(allowedCredentials []protocol.CredentialDescriptor) *Credential {
+	if len(allowedCredentials) == 0 && len(authenticator.Credentials) > 0 {
+		return authenticator.Credentials[0]
+	}
+	for _, allowed := range allowedCredentials {
+		for _, credential := range authenticator.Credentials {
+			if string(allowed.CredentialID) == string(credential.ID) {
+				return credential
+			}
+		}
+	}
+	return nil
+}
+
+func (authenticator *Authenticator) authenticatorData(rpId string, flags byte, signCount uint32) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	authData := append([]byte{}, rpIdHash[:]...)
+	authData = append(authData, flags)
+	return binary.BigEndian.AppendUint32(authData, signCount)
+}
+
+func (authenticator *Authenticator) clientDataJSON(ceremonyType string, challenge protocol.URLEncodedBase64) ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": encode(challenge),
+		"origin":    authenticator.Origin,
+	})
+}
+
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
 	"github.com/BimaAdi/fiberGormBoilerplate/tasks"
@@ -17,6 +19,22 @@ import (
 //
 //	@securitydefinitions.oauth2.password	OAuth2Password
 //	@tokenurl								/auth/login
+//	@scope.user:read list and get detail user
+//	@scope.user:create create user
+//	@scope.user:update update user
+//	@scope.user:delete delete user
+//
+//	@securitydefinitions.oauth2.application	OAuth2Application
+//	@tokenurl								/oauth/token
+//	@scope.user:read list and get detail user
+//	@scope.user:create create user
+//	@scope.user:update update user
+//	@scope.user:delete delete user
+//
+//	@securitydefinitions.apikey				ApiKeyAuth
+//	@in										header
+//	@name									X-API-Key
+//
 //	@BasePath								/
 func main() {
 	app := &cli.App{
@@ -121,6 +139,291 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "jwt-key",
+				Aliases: []string{"jk"},
+				Usage:   "manage jwt signing key ring (JWT_KEY_RING_PATH)",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "list all key on key ring",
+						Action: func(cCtx *cli.Context) error {
+							keys, err := tasks.ListJWTKey(".env")
+							if err != nil {
+								return err
+							}
+							for _, key := range keys {
+								fmt.Println(key.KeyID + " " + key.Algorithm + " " + key.Status + " " + key.CreatedAt.Format(time.RFC3339))
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "generate",
+						Aliases: []string{"g"},
+						Usage:   "generate new inactive key (published on jwks but not used for signing yet)",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "algorithm",
+								Value: "",
+								Usage: "HS256/RS256/ES256/EdDSA (default: JWT_ALGORITHM)",
+							},
+							&cli.BoolFlag{
+								Name:  "promote",
+								Value: false,
+								Usage: "promote generated key to active key",
+							},
+						},
+						Action: func(cCtx *cli.Context) error {
+							key, err := tasks.GenerateJWTKey(".env", cCtx.String("algorithm"), cCtx.Bool("promote"))
+							if err != nil {
+								return err
+							}
+							fmt.Println("generated key " + key.KeyID + " " + key.Algorithm + " " + key.Status)
+							return nil
+						},
+					},
+					{
+						Name:    "promote",
+						Aliases: []string{"p"},
+						Usage:   "promote key to active key, jwt-key promote {kid}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.PromoteJWTKey(".env", cCtx.Args().First()); err != nil {
+								return err
+							}
+							fmt.Println("promoted key " + cCtx.Args().First())
+							return nil
+						},
+					},
+					{
+						Name:    "retire",
+						Aliases: []string{"r"},
+						Usage:   "retire key, token signed by retired key become invalid, jwt-key retire {kid}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.RetireJWTKey(".env", cCtx.Args().First()); err != nil {
+								return err
+							}
+							fmt.Println("retired key " + cCtx.Args().First())
+							return nil
+						},
+					},
+					{
+						Name:  "rotate",
+						Usage: "generate and promote new key, retire every inactive key",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "algorithm",
+								Value: "",
+								Usage: "HS256/RS256/ES256/EdDSA (default: JWT_ALGORITHM)",
+							},
+						},
+						Action: func(cCtx *cli.Context) error {
+							key, retiredKeyIds, err := tasks.RotateJWTKey(".env", cCtx.String("algorithm"))
+							if err != nil {
+								return err
+							}
+							fmt.Println("active key " + key.KeyID + " " + key.Algorithm)
+							for _, kid := range retiredKeyIds {
+								fmt.Println("retired key " + kid)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:    "oauth-client",
+				Aliases: []string{"oc"},
+				Usage:   "manage oauth2 client",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "create",
+						Aliases: []string{"c"},
+						Usage:   "register new oauth2 client, client secret only shown once",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "name",
+								Usage:    "client name",
+								Required: true,
+							},
+							&cli.StringSliceFlag{
+								Name:  "redirect-uri",
+								Usage: "allowed redirect uri, can be defined multiple times",
+							},
+							&cli.StringSliceFlag{
+								Name:  "scope",
+								Usage: "allowed scope, can be defined multiple times (at least one for authorization_code grant)",
+							},
+							&cli.StringSliceFlag{
+								Name:  "grant-type",
+								Usage: "authorization_code/refresh_token/client_credentials, can be defined multiple times (default: authorization_code and refresh_token)",
+							},
+							&cli.BoolFlag{
+								Name:  "public",
+								Value: false,
+								Usage: "public client (spa, mobile app) without client secret",
+							},
+						},
+						Action: func(cCtx *cli.Context) error {
+							client, clientSecret, err := tasks.CreateOAuthClient(
+								".env", cCtx.String("name"), cCtx.StringSlice("redirect-uri"),
+								cCtx.StringSlice("scope"), cCtx.StringSlice("grant-type"), !cCtx.Bool("public"),
+							)
+							if err != nil {
+								return err
+							}
+							fmt.Println("client_id: " + client.ClientID)
+							if clientSecret != "" {
+								fmt.Println("client_secret: " + clientSecret)
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "list all oauth2 client",
+						Action: func(cCtx *cli.Context) error {
+							clients, err := tasks.ListOAuthClient(".env")
+							if err != nil {
+								return err
+							}
+							for _, client := range clients {
+								clientType := "confidential"
+								if client.ClientSecret == nil {
+									clientType = "public"
+								}
+								fmt.Println(client.ClientID + " " + client.Name + " " + clientType + " [" + client.GrantTypes + "] [" + client.RedirectURIs + "] [" + client.Scopes + "]")
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "delete",
+						Aliases: []string{"d"},
+						Usage:   "delete oauth2 client, oauth-client delete {client_id}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.DeleteOAuthClient(".env", cCtx.Args().First()); err != nil {
+								return err
+							}
+							fmt.Println("deleted client " + cCtx.Args().First())
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "role",
+				Usage: "manage role and permission",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "create",
+						Aliases: []string{"c"},
+						Usage:   "create role, role create {name}",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "description",
+								Value: "",
+								Usage: "role description",
+							},
+						},
+						Action: func(cCtx *cli.Context) error {
+							role, err := tasks.CreateRole(".env", cCtx.Args().First(), cCtx.String("description"))
+							if err != nil {
+								return err
+							}
+							fmt.Println("created role " + role.Name)
+							return nil
+						},
+					},
+					{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "list all role with it's permissions",
+						Action: func(cCtx *cli.Context) error {
+							roles, rolePermissions, err := tasks.ListRole(".env")
+							if err != nil {
+								return err
+							}
+							for _, role := range roles {
+								fmt.Println(role.Name + " [" + strings.Join(rolePermissions[role.ID], " ") + "] " + role.Description)
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "delete",
+						Aliases: []string{"d"},
+						Usage:   "delete role, role delete {name}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.DeleteRole(".env", cCtx.Args().First()); err != nil {
+								return err
+							}
+							fmt.Println("deleted role " + cCtx.Args().First())
+							return nil
+						},
+					},
+					{
+						Name:  "permissions",
+						Usage: "list all permission",
+						Action: func(cCtx *cli.Context) error {
+							permissions, err := tasks.ListPermission(".env")
+							if err != nil {
+								return err
+							}
+							for _, permission := range permissions {
+								fmt.Println(permission.Name + " " + permission.Description)
+							}
+							return nil
+						},
+					},
+					{
+						Name:  "grant",
+						Usage: "grant permission to role, role grant {role} {permission}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.GrantPermissionToRole(".env", cCtx.Args().Get(0), cCtx.Args().Get(1)); err != nil {
+								return err
+							}
+							fmt.Println("granted " + cCtx.Args().Get(1) + " to role " + cCtx.Args().Get(0))
+							return nil
+						},
+					},
+					{
+						Name:  "revoke",
+						Usage: "revoke permission from role, role revoke {role} {permission}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.RevokePermissionFromRole(".env", cCtx.Args().Get(0), cCtx.Args().Get(1)); err != nil {
+								return err
+							}
+							fmt.Println("revoked " + cCtx.Args().Get(1) + " from role " + cCtx.Args().Get(0))
+							return nil
+						},
+					},
+					{
+						Name:  "assign",
+						Usage: "assign role to user, role assign {role} {username}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.AssignRoleToUser(".env", cCtx.Args().Get(0), cCtx.Args().Get(1)); err != nil {
+								return err
+							}
+							fmt.Println("assigned role " + cCtx.Args().Get(0) + " to " + cCtx.Args().Get(1))
+							return nil
+						},
+					},
+					{
+						Name:  "unassign",
+						Usage: "unassign role from user, role unassign {role} {username}",
+						Action: func(cCtx *cli.Context) error {
+							if err := tasks.UnassignRoleFromUser(".env", cCtx.Args().Get(0), cCtx.Args().Get(1)); err != nil {
+								return err
+							}
+							fmt.Println("unassigned role " + cCtx.Args().Get(0) + " from " + cCtx.Args().Get(1))
+							return nil
+						},
+					},
+				},
+			},
 			{
 				Name:    "init-superuser",
 				Aliases: []string{"is"},
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_refresh_token_token_hash;
+DROP INDEX IF EXISTS idx_refresh_token_family_id;
+DROP INDEX IF EXISTS idx_refresh_token_user_id;
+DROP INDEX IF EXISTS idx_refresh_token_id;
+DROP TABLE IF EXISTS public.refresh_token;
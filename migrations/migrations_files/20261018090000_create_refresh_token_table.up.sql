@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS public.refresh_token (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	family_id uuid NOT NULL,
+	token_hash varchar NOT NULL,
+	expired_at timestamptz NOT NULL,
+	revoked_at timestamptz NULL,
+	replaced_by_id uuid NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT refresh_token_pkey PRIMARY KEY (id),
+	CONSTRAINT refresh_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_id ON public.refresh_token USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_user_id ON public.refresh_token USING btree (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_family_id ON public.refresh_token USING btree (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_token_token_hash ON public.refresh_token USING btree (token_hash);
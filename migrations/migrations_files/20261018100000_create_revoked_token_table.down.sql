@@ -0,0 +1,4 @@
+ALTER TABLE public."user" DROP COLUMN IF EXISTS token_revoked_before;
+DROP INDEX IF EXISTS idx_revoked_token_expired_at;
+DROP INDEX IF EXISTS idx_revoked_token_user_id;
+DROP TABLE IF EXISTS public.revoked_token;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.revoked_token (
+	jti varchar NOT NULL,
+	user_id uuid NOT NULL,
+	expired_at timestamptz NOT NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT revoked_token_pkey PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_token_user_id ON public.revoked_token USING btree (user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_token_expired_at ON public.revoked_token USING btree (expired_at);
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS token_revoked_before timestamptz NULL;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_oauth2_authorization_code_user_id;
+DROP INDEX IF EXISTS idx_oauth2_authorization_code_client_id;
+DROP INDEX IF EXISTS idx_oauth2_authorization_code_code_hash;
+DROP INDEX IF EXISTS idx_oauth2_authorization_code_id;
+DROP TABLE IF EXISTS public.oauth2_authorization_code;
+DROP INDEX IF EXISTS idx_oauth2_client_client_id;
+DROP INDEX IF EXISTS idx_oauth2_client_id;
+DROP TABLE IF EXISTS public.oauth2_client;
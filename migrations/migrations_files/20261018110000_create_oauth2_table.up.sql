@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS public.oauth2_client (
+	id uuid NOT NULL,
+	"name" varchar NOT NULL,
+	client_id varchar NOT NULL,
+	client_secret varchar NULL,
+	redirect_uris text NOT NULL,
+	scopes text NOT NULL,
+	created_at timestamptz NULL,
+	updated_at timestamptz NULL,
+	deleted_at timestamptz NULL,
+	CONSTRAINT oauth2_client_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_oauth2_client_id ON public.oauth2_client USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth2_client_client_id ON public.oauth2_client USING btree (client_id);
+
+CREATE TABLE IF NOT EXISTS public.oauth2_authorization_code (
+	id uuid NOT NULL,
+	code_hash varchar NOT NULL,
+	client_id varchar NOT NULL,
+	user_id uuid NOT NULL,
+	redirect_uri text NOT NULL,
+	"scope" text NOT NULL,
+	code_challenge varchar NOT NULL,
+	code_challenge_method varchar NOT NULL,
+	refresh_token_family_id uuid NULL,
+	expired_at timestamptz NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT oauth2_authorization_code_pkey PRIMARY KEY (id),
+	CONSTRAINT oauth2_authorization_code_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_oauth2_authorization_code_id ON public.oauth2_authorization_code USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth2_authorization_code_code_hash ON public.oauth2_authorization_code USING btree (code_hash);
+CREATE INDEX IF NOT EXISTS idx_oauth2_authorization_code_client_id ON public.oauth2_authorization_code USING btree (client_id);
+CREATE INDEX IF NOT EXISTS idx_oauth2_authorization_code_user_id ON public.oauth2_authorization_code USING btree (user_id);
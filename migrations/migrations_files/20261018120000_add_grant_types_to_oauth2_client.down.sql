@@ -0,0 +1 @@
+ALTER TABLE public.oauth2_client DROP COLUMN IF EXISTS grant_types;
@@ -0,0 +1 @@
+ALTER TABLE public.oauth2_client ADD COLUMN IF NOT EXISTS grant_types text NOT NULL DEFAULT 'authorization_code refresh_token';
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_user_role_user_id_role_id;
+DROP INDEX IF EXISTS idx_user_role_id;
+DROP TABLE IF EXISTS public.user_role;
+DROP INDEX IF EXISTS idx_role_permission_role_id_permission_id;
+DROP INDEX IF EXISTS idx_role_permission_id;
+DROP TABLE IF EXISTS public.role_permission;
+DROP INDEX IF EXISTS idx_permission_name;
+DROP INDEX IF EXISTS idx_permission_id;
+DROP TABLE IF EXISTS public."permission";
+DROP INDEX IF EXISTS idx_role_name;
+DROP INDEX IF EXISTS idx_role_id;
+DROP TABLE IF EXISTS public."role";
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS public."role" (
+	id uuid NOT NULL,
+	"name" varchar NOT NULL,
+	description text NOT NULL DEFAULT '',
+	created_at timestamptz NULL,
+	updated_at timestamptz NULL,
+	CONSTRAINT role_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_role_id ON public."role" USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_name ON public."role" USING btree ("name");
+
+CREATE TABLE IF NOT EXISTS public."permission" (
+	id uuid NOT NULL,
+	"name" varchar NOT NULL,
+	description text NOT NULL DEFAULT '',
+	created_at timestamptz NULL,
+	CONSTRAINT permission_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_permission_id ON public."permission" USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permission_name ON public."permission" USING btree ("name");
+
+CREATE TABLE IF NOT EXISTS public.role_permission (
+	id uuid NOT NULL,
+	role_id uuid NOT NULL,
+	permission_id uuid NOT NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT role_permission_pkey PRIMARY KEY (id),
+	CONSTRAINT role_permission_role_id_fkey FOREIGN KEY (role_id) REFERENCES public."role"(id) ON DELETE CASCADE,
+	CONSTRAINT role_permission_permission_id_fkey FOREIGN KEY (permission_id) REFERENCES public."permission"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_role_permission_id ON public.role_permission USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_permission_role_id_permission_id ON public.role_permission USING btree (role_id, permission_id);
+
+CREATE TABLE IF NOT EXISTS public.user_role (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	role_id uuid NOT NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT user_role_pkey PRIMARY KEY (id),
+	CONSTRAINT user_role_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE,
+	CONSTRAINT user_role_role_id_fkey FOREIGN KEY (role_id) REFERENCES public."role"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_role_id ON public.user_role USING btree (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_role_user_id_role_id ON public.user_role USING btree (user_id, role_id);
+
+INSERT INTO public."permission" (id, "name", description, created_at) VALUES
+	('6f1c2a52-6d0e-4c55-9a3e-0b7f4a1d2c01', 'user:read', 'list and get detail user', now()),
+	('6f1c2a52-6d0e-4c55-9a3e-0b7f4a1d2c02', 'user:create', 'create user', now()),
+	('6f1c2a52-6d0e-4c55-9a3e-0b7f4a1d2c03', 'user:update', 'update user', now()),
+	('6f1c2a52-6d0e-4c55-9a3e-0b7f4a1d2c04', 'user:delete', 'delete user', now())
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_api_key_key_hash;
+DROP INDEX IF EXISTS idx_api_key_user_id;
+DROP INDEX IF EXISTS idx_api_key_id;
+DROP TABLE IF EXISTS public.api_key;
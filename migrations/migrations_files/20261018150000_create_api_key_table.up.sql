@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS public.api_key (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	"name" varchar NOT NULL,
+	key_prefix varchar NOT NULL,
+	key_hash varchar NOT NULL,
+	"scope" text NOT NULL DEFAULT '',
+	expired_at timestamptz NULL,
+	last_used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	updated_at timestamptz NULL,
+	deleted_at timestamptz NULL,
+	CONSTRAINT api_key_pkey PRIMARY KEY (id),
+	CONSTRAINT api_key_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_id ON public.api_key USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_api_key_user_id ON public.api_key USING btree (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_key_hash ON public.api_key USING btree (key_hash);
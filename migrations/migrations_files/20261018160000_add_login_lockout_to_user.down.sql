@@ -0,0 +1,3 @@
+ALTER TABLE public."user" DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS failed_login_count;
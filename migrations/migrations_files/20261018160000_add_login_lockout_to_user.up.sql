@@ -0,0 +1,3 @@
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS failed_login_count int4 NOT NULL DEFAULT 0;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS last_failed_login_at timestamptz NULL;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS locked_until timestamptz NULL;
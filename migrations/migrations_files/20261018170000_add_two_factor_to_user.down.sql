@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_user_recovery_code_user_id;
+DROP INDEX IF EXISTS idx_user_recovery_code_id;
+DROP TABLE IF EXISTS public.user_recovery_code;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS totp_last_used_step;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS totp_secret;
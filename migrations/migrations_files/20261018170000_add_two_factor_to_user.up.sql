@@ -0,0 +1,14 @@
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS totp_secret varchar NULL;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS totp_enabled_at timestamptz NULL;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS totp_last_used_step int8 NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS public.user_recovery_code (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	code_hash varchar NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT user_recovery_code_pkey PRIMARY KEY (id),
+	CONSTRAINT user_recovery_code_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_code_id ON public.user_recovery_code USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_code_user_id ON public.user_recovery_code USING btree (user_id);
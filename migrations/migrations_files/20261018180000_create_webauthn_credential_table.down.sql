@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_webauthn_credential_credential_id;
+DROP INDEX IF EXISTS idx_webauthn_credential_user_id;
+DROP INDEX IF EXISTS idx_webauthn_credential_id;
+DROP TABLE IF EXISTS public.webauthn_credential;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.webauthn_credential (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	"name" varchar NOT NULL,
+	credential_id varchar NOT NULL,
+	public_key bytea NOT NULL,
+	attestation_type varchar NOT NULL DEFAULT '',
+	transport text NOT NULL DEFAULT '',
+	aaguid bytea NULL,
+	sign_count int8 NOT NULL DEFAULT 0,
+	last_used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	updated_at timestamptz NULL,
+	CONSTRAINT webauthn_credential_pkey PRIMARY KEY (id),
+	CONSTRAINT webauthn_credential_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credential_id ON public.webauthn_credential USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credential_user_id ON public.webauthn_credential USING btree (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webauthn_credential_credential_id ON public.webauthn_credential USING btree (credential_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_password_reset_token_token_hash;
+DROP INDEX IF EXISTS idx_password_reset_token_user_id;
+DROP INDEX IF EXISTS idx_password_reset_token_id;
+DROP TABLE IF EXISTS public.password_reset_token;
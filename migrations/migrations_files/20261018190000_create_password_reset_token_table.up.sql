@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS public.password_reset_token (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	token_hash varchar NOT NULL,
+	expired_at timestamptz NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT password_reset_token_pkey PRIMARY KEY (id),
+	CONSTRAINT password_reset_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_token_id ON public.password_reset_token USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_password_reset_token_user_id ON public.password_reset_token USING btree (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_token_token_hash ON public.password_reset_token USING btree (token_hash);
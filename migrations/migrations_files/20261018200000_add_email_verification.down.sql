@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_email_verification_token_token_hash;
+DROP INDEX IF EXISTS idx_email_verification_token_user_id;
+DROP INDEX IF EXISTS idx_email_verification_token_id;
+DROP TABLE IF EXISTS public.email_verification_token;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,15 @@
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS email_verified_at timestamptz NULL;
+CREATE TABLE IF NOT EXISTS public.email_verification_token (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	email varchar NOT NULL,
+	token_hash varchar NOT NULL,
+	expired_at timestamptz NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT email_verification_token_pkey PRIMARY KEY (id),
+	CONSTRAINT email_verification_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_email_verification_token_id ON public.email_verification_token USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_email_verification_token_user_id ON public.email_verification_token USING btree (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_verification_token_token_hash ON public.email_verification_token USING btree (token_hash);
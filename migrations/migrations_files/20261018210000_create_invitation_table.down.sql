@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_invitation_token_hash;
+DROP INDEX IF EXISTS idx_invitation_email;
+DROP INDEX IF EXISTS idx_invitation_id;
+DROP TABLE IF EXISTS public.invitation;
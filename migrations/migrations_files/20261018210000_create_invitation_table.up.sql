@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.invitation (
+	id uuid NOT NULL,
+	email varchar NOT NULL,
+	token_hash varchar NOT NULL,
+	inviter_id uuid NULL,
+	role_id uuid NULL,
+	expired_at timestamptz NOT NULL,
+	accepted_at timestamptz NULL,
+	accepted_user_id uuid NULL,
+	revoked_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT invitation_pkey PRIMARY KEY (id),
+	CONSTRAINT invitation_inviter_id_fkey FOREIGN KEY (inviter_id) REFERENCES public."user"(id) ON DELETE SET NULL,
+	CONSTRAINT invitation_role_id_fkey FOREIGN KEY (role_id) REFERENCES public."role"(id) ON DELETE CASCADE,
+	CONSTRAINT invitation_accepted_user_id_fkey FOREIGN KEY (accepted_user_id) REFERENCES public."user"(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_invitation_id ON public.invitation USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_invitation_email ON public.invitation USING btree (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitation_token_hash ON public.invitation USING btree (token_hash);
@@ -0,0 +1,4 @@
+ALTER TABLE public."user" DROP CONSTRAINT IF EXISTS user_deactivated_by_id_fkey;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS deactivated_by_id;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS deactivated_reason;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS deactivated_at;
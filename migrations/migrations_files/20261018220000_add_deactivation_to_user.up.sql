@@ -0,0 +1,4 @@
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS deactivated_at timestamptz NULL;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS deactivated_reason text NULL;
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS deactivated_by_id uuid NULL;
+ALTER TABLE public."user" ADD CONSTRAINT user_deactivated_by_id_fkey FOREIGN KEY (deactivated_by_id) REFERENCES public."user"(id) ON DELETE SET NULL;
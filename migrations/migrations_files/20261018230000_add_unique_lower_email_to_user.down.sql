@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_user_email_lower_unique;
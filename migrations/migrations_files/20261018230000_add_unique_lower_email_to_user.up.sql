@@ -0,0 +1,15 @@
+DO $$
+DECLARE
+	duplicates text;
+BEGIN
+	SELECT string_agg(lower_email, ', ') INTO duplicates FROM (
+		SELECT lower(email) AS lower_email FROM public."user"
+		WHERE deleted_at IS NULL
+		GROUP BY lower(email)
+		HAVING count(*) > 1
+	) AS duplicate_email;
+	IF duplicates IS NOT NULL THEN
+		RAISE EXCEPTION 'email of not deleted user should unique case insensitive, change email or soft delete (set deleted_at) user with duplicate email before migrating: %', duplicates;
+	END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_email_lower_unique ON public."user" USING btree (lower(email)) WHERE deleted_at IS NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.password_history (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	"password" varchar NOT NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT password_history_pkey PRIMARY KEY (id),
+	CONSTRAINT password_history_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_password_history_id ON public.password_history USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON public.password_history USING btree (user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_ldap_dn_lower_unique;
+ALTER TABLE public."user" DROP COLUMN IF EXISTS ldap_dn;
@@ -0,0 +1,2 @@
+ALTER TABLE public."user" ADD COLUMN IF NOT EXISTS ldap_dn varchar NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_ldap_dn_lower_unique ON public."user" USING btree (lower(ldap_dn)) WHERE deleted_at IS NULL;
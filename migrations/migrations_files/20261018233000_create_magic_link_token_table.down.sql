@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_magic_link_token_token_hash;
+DROP INDEX IF EXISTS idx_magic_link_token_user_id;
+DROP INDEX IF EXISTS idx_magic_link_token_id;
+DROP TABLE IF EXISTS public.magic_link_token;
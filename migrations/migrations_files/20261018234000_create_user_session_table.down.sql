@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_session_user_id;
+DROP INDEX IF EXISTS idx_user_session_id;
+DROP TABLE IF EXISTS public.user_session;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.user_session (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	user_agent text NOT NULL DEFAULT '',
+	ip_address varchar NOT NULL DEFAULT '',
+	expired_at timestamptz NOT NULL,
+	last_seen_at timestamptz NOT NULL,
+	terminated_at timestamptz NULL,
+	created_at timestamptz NULL,
+	CONSTRAINT user_session_pkey PRIMARY KEY (id),
+	CONSTRAINT user_session_user_id_fkey FOREIGN KEY (user_id) REFERENCES public."user"(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_session_id ON public.user_session USING btree (id);
+CREATE INDEX IF NOT EXISTS idx_user_session_user_id ON public.user_session USING btree (user_id);
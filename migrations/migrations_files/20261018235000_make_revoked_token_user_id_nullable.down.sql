@@ -0,0 +1,2 @@
+DELETE FROM public.revoked_token WHERE user_id IS NULL;
+ALTER TABLE public.revoked_token ALTER COLUMN user_id SET NOT NULL;
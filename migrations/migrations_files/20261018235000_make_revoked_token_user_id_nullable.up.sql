@@ -0,0 +1 @@
+ALTER TABLE public.revoked_token ALTER COLUMN user_id DROP NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE public.refresh_token DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1 @@
+ALTER TABLE public.refresh_token ADD COLUMN IF NOT EXISTS client_id varchar NULL;
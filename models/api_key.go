@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// ApiKey long lived credential of user, only the hash of the key is stored.
+// KeyPrefix is the first characters of the key to help user identify it,
+// Scope is space separated (empty is not limited) and null ExpiredAt never expired
+type ApiKey struct {
+	ID         string     `gorm:"primaryKey;type:uuid;index"`
+	UserID     string     `gorm:"column:user_id;type:uuid;not null;index"`
+	Name       string     `gorm:"column:name;type:varchar;not null"`
+	KeyPrefix  string     `gorm:"column:key_prefix;type:varchar;not null"`
+	KeyHash    string     `gorm:"column:key_hash;type:varchar;not null;uniqueIndex"`
+	Scope      string     `gorm:"column:scope;type:text;not null;default:''"`
+	ExpiredAt  *time.Time `gorm:"column:expired_at;type:timestamp with time zone;default null"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:timestamp with time zone;default null"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+	UpdatedAt  *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
+	DeletedAt  *time.Time `gorm:"column:deleted_at;type:timestamp with time zone;default null"`
+}
+
+func (ApiKey) TableName() string {
+	return "api_key"
+}
+
+func (apiKey *ApiKey) BeforeCreate(tx *gorm.DB) error {
+	apiKey.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationToken single use token sent to verify user email, only the hash is stored.
+// Email is the address the token sent to, token invalid once user email changed
+type EmailVerificationToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid;index"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index"`
+	Email     string     `gorm:"column:email;type:varchar;not null"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar;not null;uniqueIndex"`
+	ExpiredAt time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone;default null"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_token"
+}
+
+func (emailVerificationToken *EmailVerificationToken) BeforeCreate(tx *gorm.DB) error {
+	emailVerificationToken.ID = uuid.NewV4().String()
+	return nil
+}
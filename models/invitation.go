@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Invitation single use token sent to invited email, only the hash is stored.
+// Invitee become active user with Role (optional) once accepted
+type Invitation struct {
+	ID             string     `gorm:"primaryKey;type:uuid;index"`
+	Email          string     `gorm:"column:email;type:varchar;not null;index"`
+	TokenHash      string     `gorm:"column:token_hash;type:varchar;not null;uniqueIndex"`
+	InviterID      *string    `gorm:"column:inviter_id;type:uuid;default null"`
+	Inviter        *User      `gorm:"foreignKey:InviterID;constraint:OnDelete:SET NULL"`
+	RoleID         *string    `gorm:"column:role_id;type:uuid;default null"`
+	Role           *Role      `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+	ExpiredAt      time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	AcceptedAt     *time.Time `gorm:"column:accepted_at;type:timestamp with time zone;default null"`
+	AcceptedUserID *string    `gorm:"column:accepted_user_id;type:uuid;default null"`
+	RevokedAt      *time.Time `gorm:"column:revoked_at;type:timestamp with time zone;default null"`
+	CreatedAt      time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (Invitation) TableName() string {
+	return "invitation"
+}
+
+func (invitation *Invitation) BeforeCreate(tx *gorm.DB) error {
+	invitation.ID = uuid.NewV4().String()
+	return nil
+}
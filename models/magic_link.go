@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkToken single use token sent to user email to login without password,
+// NonceHash bind the token to the device requested it (nonce kept on cookie). Only the hashes are stored
+type MagicLinkToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid;index"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar;not null;uniqueIndex"`
+	NonceHash string     `gorm:"column:nonce_hash;type:varchar;not null"`
+	ExpiredAt time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone;default null"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (MagicLinkToken) TableName() string {
+	return "magic_link_token"
+}
+
+func (magicLinkToken *MagicLinkToken) BeforeCreate(tx *gorm.DB) error {
+	magicLinkToken.ID = uuid.NewV4().String()
+	return nil
+}
@@ -41,17 +41,70 @@ func Initiate() {
 func AutoMigrate() {
 	// add models here
 	fmt.Println("Migrate Database")
-	DBConn.AutoMigrate(&User{})
+	DBConn.AutoMigrate(
+		&User{},
+		&RefreshToken{},
+		&RevokedToken{},
+		&OAuthClient{},
+		&OAuthAuthorizationCode{},
+		&Role{},
+		&Permission{},
+		&RolePermission{},
+		&UserRole{},
+		&ApiKey{},
+		&UserRecoveryCode{},
+		&WebAuthnCredential{},
+		&PasswordResetToken{},
+		&EmailVerificationToken{},
+		&Invitation{},
+		&PasswordHistory{},
+		&MagicLinkToken{},
+		&UserSession{},
+	)
 }
 
 func AutoRollback() {
 	fmt.Println("Rollback Database")
-	DBConn.Migrator().DropTable(&User{})
+	DBConn.Migrator().DropTable(
+		&UserSession{},
+		&MagicLinkToken{},
+		&PasswordHistory{},
+		&Invitation{},
+		&EmailVerificationToken{},
+		&PasswordResetToken{},
+		&WebAuthnCredential{},
+		&UserRecoveryCode{},
+		&ApiKey{},
+		&UserRole{},
+		&RolePermission{},
+		&Permission{},
+		&Role{},
+		&OAuthAuthorizationCode{},
+		&OAuthClient{},
+		&RevokedToken{},
+		&RefreshToken{},
+		&User{},
+	)
 }
 
 func ClearAllData() {
 	fmt.Println("Clear All Data")
-	// DBConn.Exec("DELETE FROM public.oauth2_token")
-	// DBConn.Exec("DELETE FROM public.oauth2_session")
+	DBConn.Exec("DELETE FROM public.user_session")
+	DBConn.Exec("DELETE FROM public.magic_link_token")
+	DBConn.Exec("DELETE FROM public.password_history")
+	DBConn.Exec("DELETE FROM public.invitation")
+	DBConn.Exec("DELETE FROM public.email_verification_token")
+	DBConn.Exec("DELETE FROM public.password_reset_token")
+	DBConn.Exec("DELETE FROM public.webauthn_credential")
+	DBConn.Exec("DELETE FROM public.user_recovery_code")
+	DBConn.Exec("DELETE FROM public.api_key")
+	// permission is seeded by migration, keep it
+	DBConn.Exec("DELETE FROM public.user_role")
+	DBConn.Exec("DELETE FROM public.role_permission")
+	DBConn.Exec("DELETE FROM public.role")
+	DBConn.Exec("DELETE FROM public.oauth2_authorization_code")
+	DBConn.Exec("DELETE FROM public.oauth2_client")
+	DBConn.Exec("DELETE FROM public.refresh_token")
+	DBConn.Exec("DELETE FROM public.revoked_token")
 	DBConn.Exec("DELETE FROM public.user")
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthClient registered oauth2 client, ClientSecret is hashed and null for public client,
+// RedirectURIs, Scopes and GrantTypes are space separated.
+// machine client (service to service) only has client_credentials grant
+type OAuthClient struct {
+	ID           string     `gorm:"primaryKey;type:uuid;index"`
+	Name         string     `gorm:"column:name;type:varchar;not null"`
+	ClientID     string     `gorm:"column:client_id;type:varchar;not null;uniqueIndex"`
+	ClientSecret *string    `gorm:"column:client_secret;type:varchar;default null"`
+	RedirectURIs string     `gorm:"column:redirect_uris;type:text;not null"`
+	Scopes       string     `gorm:"column:scopes;type:text;not null"`
+	GrantTypes   string     `gorm:"column:grant_types;type:text;not null;default:'authorization_code refresh_token'"`
+	CreatedAt    time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+	UpdatedAt    *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
+	DeletedAt    *time.Time `gorm:"column:deleted_at;type:timestamp with time zone;default null"`
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth2_client"
+}
+
+func (client *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	client.ID = uuid.NewV4().String()
+	return nil
+}
+
+// OAuthAuthorizationCode issued on authorization code flow,
+// RefreshTokenFamilyID is revoked when the code reused
+type OAuthAuthorizationCode struct {
+	ID                   string     `gorm:"primaryKey;type:uuid;index"`
+	CodeHash             string     `gorm:"column:code_hash;type:varchar;not null;uniqueIndex"`
+	ClientID             string     `gorm:"column:client_id;type:varchar;not null;index"`
+	UserID               string     `gorm:"column:user_id;type:uuid;not null;index"`
+	RedirectURI          string     `gorm:"column:redirect_uri;type:text;not null"`
+	Scope                string     `gorm:"column:scope;type:text;not null"`
+	CodeChallenge        string     `gorm:"column:code_challenge;type:varchar;not null"`
+	CodeChallengeMethod  string     `gorm:"column:code_challenge_method;type:varchar;not null"`
+	RefreshTokenFamilyID *string    `gorm:"column:refresh_token_family_id;type:uuid;default null"`
+	ExpiredAt            time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	UsedAt               *time.Time `gorm:"column:used_at;type:timestamp with time zone;default null"`
+	CreatedAt            time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (OAuthAuthorizationCode) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+func (code *OAuthAuthorizationCode) BeforeCreate(tx *gorm.DB) error {
+	code.ID = uuid.NewV4().String()
+	return nil
+}
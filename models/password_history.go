@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory hash of password previously set by user, used to prevent password reuse
+type PasswordHistory struct {
+	ID        string    `gorm:"primaryKey;type:uuid;index"`
+	UserID    string    `gorm:"column:user_id;type:uuid;not null;index"`
+	Password  string    `gorm:"column:password;type:varchar;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func (passwordHistory *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	passwordHistory.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken single use token sent to user email to reset forgotten password, only the hash is stored
+type PasswordResetToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid;index"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar;not null;uniqueIndex"`
+	ExpiredAt time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone;default null"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_token"
+}
+
+func (passwordResetToken *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	passwordResetToken.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type Role struct {
+	ID          string     `gorm:"primaryKey;type:uuid;index"`
+	Name        string     `gorm:"column:name;type:varchar;not null;uniqueIndex"`
+	Description string     `gorm:"column:description;type:text;not null;default:''"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+	UpdatedAt   *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
+}
+
+func (Role) TableName() string {
+	return "role"
+}
+
+func (role *Role) BeforeCreate(tx *gorm.DB) error {
+	role.ID = uuid.NewV4().String()
+	return nil
+}
+
+// Permission checked by route, name formatted as resource:action (user:create),
+// default permissions seeded by migration
+type Permission struct {
+	ID          string    `gorm:"primaryKey;type:uuid;index"`
+	Name        string    `gorm:"column:name;type:varchar;not null;uniqueIndex"`
+	Description string    `gorm:"column:description;type:text;not null;default:''"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (Permission) TableName() string {
+	return "permission"
+}
+
+func (permission *Permission) BeforeCreate(tx *gorm.DB) error {
+	if permission.ID == "" {
+		permission.ID = uuid.NewV4().String()
+	}
+	return nil
+}
+
+type RolePermission struct {
+	ID           string    `gorm:"primaryKey;type:uuid;index"`
+	RoleID       string    `gorm:"column:role_id;type:uuid;not null"`
+	PermissionID string    `gorm:"column:permission_id;type:uuid;not null"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permission"
+}
+
+func (rolePermission *RolePermission) BeforeCreate(tx *gorm.DB) error {
+	rolePermission.ID = uuid.NewV4().String()
+	return nil
+}
+
+type UserRole struct {
+	ID        string    `gorm:"primaryKey;type:uuid;index"`
+	UserID    string    `gorm:"column:user_id;type:uuid;not null"`
+	RoleID    string    `gorm:"column:role_id;type:uuid;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (UserRole) TableName() string {
+	return "user_role"
+}
+
+func (userRole *UserRole) BeforeCreate(tx *gorm.DB) error {
+	userRole.ID = uuid.NewV4().String()
+	return nil
+}
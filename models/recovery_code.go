@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// UserRecoveryCode one time code to login when TOTP device lost, only the hash is stored
+type UserRecoveryCode struct {
+	ID        string     `gorm:"primaryKey;type:uuid;index"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index"`
+	CodeHash  string     `gorm:"column:code_hash;type:varchar;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone;default null"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_code"
+}
+
+func (recoveryCode *UserRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	recoveryCode.ID = uuid.NewV4().String()
+	return nil
+}
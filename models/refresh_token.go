@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken Scope is space separated scope of access token issued by the refresh token,
+// empty scope is not limited. ClientID is OAuth2 client the token issued to, empty if issued by /auth
+// endpoints, the token only redeemed by the same client
+type RefreshToken struct {
+	ID           string     `gorm:"primaryKey;type:uuid;index"`
+	UserID       string     `gorm:"column:user_id;type:uuid;not null;index"`
+	FamilyID     string     `gorm:"column:family_id;type:uuid;not null;index"`
+	TokenHash    string     `gorm:"column:token_hash;type:varchar;not null;uniqueIndex"`
+	Scope        string     `gorm:"column:scope;type:text;not null;default:''"`
+	ClientID     *string    `gorm:"column:client_id;type:varchar;default null"`
+	ExpiredAt    time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	RevokedAt    *time.Time `gorm:"column:revoked_at;type:timestamp with time zone;default null"`
+	ReplacedByID *string    `gorm:"column:replaced_by_id;type:uuid;default null"`
+	CreatedAt    time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_token"
+}
+
+func (refreshToken *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	refreshToken.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken UserID is empty for token not issued to a user (machine client, login ceremony)
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;primaryKey;type:varchar"`
+	UserID    *string   `gorm:"column:user_id;type:uuid;default null;index"`
+	ExpiredAt time.Time `gorm:"column:expired_at;type:timestamp with time zone;not null;index"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_token"
+}
@@ -7,16 +7,34 @@ import (
 	"gorm.io/gorm"
 )
 
+// User TotpSecret is base32 secret of TOTP two factor authentication,
+// two factor enabled once TotpEnabledAt set (enrollment confirmed).
+// TotpLastUsedStep prevent the same code used twice.
+// EmailVerifiedAt is nil until email verified, reset when email changed.
+// Deactivated fields record who deactivated the user (nil for machine client) and why.
+// LdapDn is dn of LDAP entry the user synced from, nil for local user
 type User struct {
-	ID          string     `gorm:"primaryKey;type:uuid;index"`
-	Email       string     `gorm:"column:email;type:varchar;not null;index"`
-	Username    string     `gorm:"column:username;type:varchar;not null;uniqueIndex;index"`
-	Password    string     `gorm:"column:password;type:varchar;not null;"`
-	IsActive    bool       `gorm:"column:is_active;default:true"`
-	IsSuperuser bool       `gorm:"column:is_superuser;default:false"`
-	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
-	UpdatedAt   *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
-	DeletedAt   *time.Time `gorm:"column:deleted_at;type:timestamp with time zone;default null"`
+	ID                 string     `gorm:"primaryKey;type:uuid;index"`
+	Email              string     `gorm:"column:email;type:varchar;not null;index"`
+	Username           string     `gorm:"column:username;type:varchar;not null;uniqueIndex;index"`
+	Password           string     `gorm:"column:password;type:varchar;not null;"`
+	IsActive           bool       `gorm:"column:is_active;default:true"`
+	IsSuperuser        bool       `gorm:"column:is_superuser;default:false"`
+	CreatedAt          time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+	UpdatedAt          *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
+	DeletedAt          *time.Time `gorm:"column:deleted_at;type:timestamp with time zone;default null"`
+	TokenRevokedBefore *time.Time `gorm:"column:token_revoked_before;type:timestamp with time zone;default null"`
+	FailedLoginCount   int        `gorm:"column:failed_login_count;not null;default:0"`
+	LastFailedLoginAt  *time.Time `gorm:"column:last_failed_login_at;type:timestamp with time zone;default null"`
+	LockedUntil        *time.Time `gorm:"column:locked_until;type:timestamp with time zone;default null"`
+	TotpSecret         *string    `gorm:"column:totp_secret;type:varchar;default null"`
+	TotpEnabledAt      *time.Time `gorm:"column:totp_enabled_at;type:timestamp with time zone;default null"`
+	TotpLastUsedStep   int64      `gorm:"column:totp_last_used_step;not null;default:0"`
+	EmailVerifiedAt    *time.Time `gorm:"column:email_verified_at;type:timestamp with time zone;default null"`
+	DeactivatedAt      *time.Time `gorm:"column:deactivated_at;type:timestamp with time zone;default null"`
+	DeactivatedReason  *string    `gorm:"column:deactivated_reason;type:text;default null"`
+	DeactivatedByID    *string    `gorm:"column:deactivated_by_id;type:uuid;default null"`
+	LdapDn             *string    `gorm:"column:ldap_dn;type:varchar;default null"`
 }
 
 func (User) TableName() string {
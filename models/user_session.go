@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// UserSession issued login, refresh token family of the login use session id as family id.
+// Access token of the session carry sid claim and refused once the session terminated
+type UserSession struct {
+	ID           string     `gorm:"primaryKey;type:uuid;index"`
+	UserID       string     `gorm:"column:user_id;type:uuid;not null;index"`
+	UserAgent    string     `gorm:"column:user_agent;type:text;not null;default:''"`
+	IPAddress    string     `gorm:"column:ip_address;type:varchar;not null;default:''"`
+	ExpiredAt    time.Time  `gorm:"column:expired_at;type:timestamp with time zone;not null"`
+	LastSeenAt   time.Time  `gorm:"column:last_seen_at;type:timestamp with time zone;not null"`
+	TerminatedAt *time.Time `gorm:"column:terminated_at;type:timestamp with time zone;default null"`
+	CreatedAt    time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+}
+
+func (UserSession) TableName() string {
+	return "user_session"
+}
+
+func (userSession *UserSession) BeforeCreate(tx *gorm.DB) error {
+	userSession.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential passkey or security key registered by user.
+// CredentialID is base64url encoded credential id, PublicKey is COSE encoded public key,
+// Transport is space separated and SignCount is the last signature counter of the authenticator
+type WebAuthnCredential struct {
+	ID              string     `gorm:"primaryKey;type:uuid;index"`
+	UserID          string     `gorm:"column:user_id;type:uuid;not null;index"`
+	Name            string     `gorm:"column:name;type:varchar;not null"`
+	CredentialID    string     `gorm:"column:credential_id;type:varchar;not null;uniqueIndex"`
+	PublicKey       []byte     `gorm:"column:public_key;type:bytea;not null"`
+	AttestationType string     `gorm:"column:attestation_type;type:varchar;not null;default:''"`
+	Transport       string     `gorm:"column:transport;type:text;not null;default:''"`
+	AAGUID          []byte     `gorm:"column:aaguid;type:bytea;default null"`
+	SignCount       int64      `gorm:"column:sign_count;not null;default:0"`
+	LastUsedAt      *time.Time `gorm:"column:last_used_at;type:timestamp with time zone;default null"`
+	CreatedAt       time.Time  `gorm:"column:created_at;type:timestamp with time zone;"`
+	UpdatedAt       *time.Time `gorm:"column:updated_at;type:timestamp with time zone;default null"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+func (credential *WebAuthnCredential) BeforeCreate(tx *gorm.DB) error {
+	credential.ID = uuid.NewV4().String()
+	return nil
+}
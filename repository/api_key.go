@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"gorm.io/gorm"
+)
+
+// CreateApiKey create api key for user, raw key only returned once
+// Return value (api_key_model, raw_key, error)
+func CreateApiKey(tx *gorm.DB, userId string, name string, scopes []string, expiredAt *time.Time, now time.Time) (models.ApiKey, string, error) {
+	rawKey, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.ApiKey{}, "", err
+	}
+
+	apiKey := models.ApiKey{
+		UserID:    userId,
+		Name:      name,
+		KeyPrefix: rawKey[:8],
+		KeyHash:   core.HashToken(rawKey),
+		Scope:     strings.Join(scopes, " "),
+		ExpiredAt: expiredAt,
+		CreatedAt: now,
+		UpdatedAt: &now,
+	}
+	if err := tx.Create(&apiKey).Error; err != nil {
+		return apiKey, "", err
+	}
+	return apiKey, rawKey, nil
+}
+
+func GetAllUserApiKey(tx *gorm.DB, userId string) ([]models.ApiKey, error) {
+	apiKeys := []models.ApiKey{}
+	if err := tx.Where("user_id = ? AND deleted_at IS NULL", userId).
+		Order("created_at desc").
+		Find(&apiKeys).Error; err != nil {
+		return apiKeys, err
+	}
+	return apiKeys, nil
+}
+
+func GetUserApiKeyById(tx *gorm.DB, userId string, id string) (models.ApiKey, error) {
+	apiKey := models.ApiKey{}
+	if err := tx.Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userId).
+		First(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+	return apiKey, nil
+}
+
+func UpdateApiKey(tx *gorm.DB, apiKey models.ApiKey, name string, now time.Time) (models.ApiKey, error) {
+	apiKey.Name = name
+	apiKey.UpdatedAt = &now
+	if err := tx.Save(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+	return apiKey, nil
+}
+
+func DeleteApiKey(tx *gorm.DB, apiKey models.ApiKey, now time.Time) (models.ApiKey, error) {
+	apiKey.DeletedAt = &now
+	if err := tx.Save(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+	return apiKey, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var ErrEmailVerificationTokenInvalid = errors.New("email verification token invalid, expired or already used")
+
+// CreateEmailVerificationToken create verification token for current user email, previous unused token invalidated
+// Return value (email_verification_token_model, raw_token, error)
+func CreateEmailVerificationToken(tx *gorm.DB, user models.User, now time.Time) (models.EmailVerificationToken, string, error) {
+	rawToken, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.EmailVerificationToken{}, "", err
+	}
+
+	verificationToken := models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: core.HashToken(rawToken),
+		ExpiredAt: now.Add(time.Minute * time.Duration(settings.EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES)),
+		CreatedAt: now,
+	}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&verificationToken).Error
+	})
+	if err != nil {
+		return verificationToken, "", err
+	}
+	return verificationToken, rawToken, nil
+}
+
+// VerifyUserEmail use verification token and mark user email verified,
+// return ErrEmailVerificationTokenInvalid if token not found, expired, already used
+// or user email changed after the token sent
+func VerifyUserEmail(tx *gorm.DB, rawToken string, now time.Time) (models.User, error) {
+	user := models.User{}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		verificationToken := models.EmailVerificationToken{}
+		if err := tx.Where("token_hash = ?", core.HashToken(rawToken)).First(&verificationToken).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailVerificationTokenInvalid
+			}
+			return err
+		}
+
+		// only one request can use the same token
+		result := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL AND expired_at > ?", verificationToken.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmailVerificationTokenInvalid
+		}
+
+		if err := tx.Where("id = ? AND deleted_at IS NULL", verificationToken.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailVerificationTokenInvalid
+			}
+			return err
+		}
+		if user.Email != verificationToken.Email {
+			return ErrEmailVerificationTokenInvalid
+		}
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+		user.EmailVerifiedAt = &now
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// MarkUserEmailVerified mark user email verified without token (trusted email, superuser created from cli)
+func MarkUserEmailVerified(tx *gorm.DB, user models.User, now time.Time) (models.User, error) {
+	user.EmailVerifiedAt = &now
+	err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("email_verified_at", now).Error
+	return user, err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var ErrInvitationInvalid = errors.New("invitation invalid, expired, revoked or already accepted")
+
+// CreateInvitation invite email with optional pre-assigned role, raw token only returned once
+// Return value (invitation_model, raw_token, error)
+func CreateInvitation(tx *gorm.DB, email string, inviter models.User, role *models.Role, now time.Time) (models.Invitation, string, error) {
+	rawToken, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.Invitation{}, "", err
+	}
+
+	invitation := models.Invitation{
+		Email:     email,
+		TokenHash: core.HashToken(rawToken),
+		InviterID: &inviter.ID,
+		ExpiredAt: now.Add(time.Minute * time.Duration(settings.INVITATION_EXPIRE_MINUTES)),
+		CreatedAt: now,
+	}
+	if role != nil {
+		invitation.RoleID = &role.ID
+	}
+	if err := tx.Omit("Inviter", "Role").Create(&invitation).Error; err != nil {
+		return invitation, "", err
+	}
+	invitation.Inviter = &inviter
+	invitation.Role = role
+	return invitation, rawToken, nil
+}
+
+func GetAllInvitation(tx *gorm.DB) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	if err := tx.Preload("Inviter").Preload("Role").
+		Order("created_at desc").
+		Find(&invitations).Error; err != nil {
+		return invitations, err
+	}
+	return invitations, nil
+}
+
+func GetInvitationById(tx *gorm.DB, id string) (models.Invitation, error) {
+	invitation := models.Invitation{}
+	if err := tx.Preload("Inviter").Preload("Role").
+		Where("id = ?", id).
+		First(&invitation).Error; err != nil {
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+// RevokeInvitation revoke pending invitation, return ErrInvitationInvalid if already accepted or revoked
+func RevokeInvitation(tx *gorm.DB, invitation models.Invitation, now time.Time) (models.Invitation, error) {
+	result := tx.Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return invitation, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return invitation, ErrInvitationInvalid
+	}
+	invitation.RevokedAt = &now
+	return invitation, nil
+}
+
+// AcceptInvitation use invitation token and create active user with the invited email
+// (considered verified) and pre-assigned role.
+// return ErrInvitationInvalid if token not found, expired, revoked or already accepted
+func AcceptInvitation(tx *gorm.DB, rawToken string, username string, password string, now time.Time) (models.User, error) {
+	user := models.User{}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		invitation := models.Invitation{}
+		if err := tx.Preload("Role").Where("token_hash = ?", core.HashToken(rawToken)).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationInvalid
+			}
+			return err
+		}
+
+		// only one request can accept the same invitation
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expired_at > ?", invitation.ID, now).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationInvalid
+		}
+
+		var err error
+		user, err = CreateUser(tx, username, invitation.Email, password, true, false, now, &now)
+		if err != nil {
+			return err
+		}
+		user, err = MarkUserEmailVerified(tx, user, now)
+		if err != nil {
+			return err
+		}
+		if invitation.Role != nil {
+			if err := AssignRoleToUser(tx, user, *invitation.Role, now); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Invitation{}).Where("id = ?", invitation.ID).Update("accepted_user_id", user.ID).Error
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// GetInvitationByToken get invitation by raw token
+func GetInvitationByToken(tx *gorm.DB, rawToken string) (models.Invitation, error) {
+	invitation := models.Invitation{}
+	if err := tx.Where("token_hash = ?", core.HashToken(rawToken)).First(&invitation).Error; err != nil {
+		return invitation, err
+	}
+	return invitation, nil
+}
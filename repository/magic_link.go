@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var ErrMagicLinkTokenInvalid = errors.New("magic link token invalid, expired, already used or requested from other device")
+
+// CreateMagicLinkToken create magic link token for user bound to nonce, previous unused token invalidated
+// Return value (magic_link_token_model, raw_token, error)
+func CreateMagicLinkToken(tx *gorm.DB, userId string, nonce string, now time.Time) (models.MagicLinkToken, string, error) {
+	rawToken, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.MagicLinkToken{}, "", err
+	}
+
+	magicLinkToken := models.MagicLinkToken{
+		UserID:    userId,
+		TokenHash: core.HashToken(rawToken),
+		NonceHash: core.HashToken(nonce),
+		ExpiredAt: now.Add(time.Minute * time.Duration(settings.MAGIC_LINK_TOKEN_EXPIRE_MINUTES)),
+		CreatedAt: now,
+	}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MagicLinkToken{}).
+			Where("user_id = ? AND used_at IS NULL", userId).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&magicLinkToken).Error
+	})
+	if err != nil {
+		return magicLinkToken, "", err
+	}
+	return magicLinkToken, rawToken, nil
+}
+
+// RebindRecentMagicLinkToken bind unused magic link token of user created after given time to new nonce,
+// so the link already sent works on the device requested it again.
+// return false if no unused token created after given time
+func RebindRecentMagicLinkToken(tx *gorm.DB, userId string, nonce string, after time.Time) (bool, error) {
+	result := tx.Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL AND created_at > ?", userId, after).
+		Update("nonce_hash", core.HashToken(nonce))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UseMagicLinkToken use magic link token and get the user, token with other nonce left unused.
+// return ErrMagicLinkTokenInvalid if token not found, expired, already used or nonce not match
+func UseMagicLinkToken(tx *gorm.DB, rawToken string, nonce string, now time.Time) (models.User, error) {
+	user := models.User{}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// only one request can use the same token
+		magicLinkToken := models.MagicLinkToken{}
+		result := tx.Model(&magicLinkToken).
+			Where("token_hash = ? AND nonce_hash = ? AND used_at IS NULL AND expired_at > ?", core.HashToken(rawToken), core.HashToken(nonce), now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMagicLinkTokenInvalid
+		}
+
+		if err := tx.Where("token_hash = ?", core.HashToken(rawToken)).First(&magicLinkToken).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND deleted_at IS NULL", magicLinkToken.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMagicLinkTokenInvalid
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
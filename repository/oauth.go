@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var ErrAuthorizationCodeUsed = errors.New("authorization code already used")
+
+// CreateOAuthClient create oauth client, public client (not confidential) has no client secret
+// Return value (oauth_client_model, raw_client_secret, error)
+func CreateOAuthClient(tx *gorm.DB, name string, redirectURIs []string, scopes []string, grantTypes []string, isConfidential bool, now time.Time) (models.OAuthClient, string, error) {
+	clientId, err := core.GenerateSecureToken(16)
+	if err != nil {
+		return models.OAuthClient{}, "", err
+	}
+
+	newClient := models.OAuthClient{
+		Name:         name,
+		ClientID:     clientId,
+		ClientSecret: nil,
+		RedirectURIs: strings.Join(redirectURIs, " "),
+		Scopes:       strings.Join(scopes, " "),
+		GrantTypes:   strings.Join(grantTypes, " "),
+		CreatedAt:    now,
+		UpdatedAt:    &now,
+	}
+
+	rawClientSecret := ""
+	if isConfidential {
+		rawClientSecret, err = core.GenerateSecureToken(32)
+		if err != nil {
+			return newClient, "", err
+		}
+		hashedClientSecret := core.HashToken(rawClientSecret)
+		newClient.ClientSecret = &hashedClientSecret
+	}
+
+	if err := tx.Create(&newClient).Error; err != nil {
+		return newClient, "", err
+	}
+	return newClient, rawClientSecret, nil
+}
+
+func GetAllOAuthClient(tx *gorm.DB) ([]models.OAuthClient, error) {
+	clients := []models.OAuthClient{}
+	if err := tx.Where("deleted_at IS NULL").Order("created_at desc").Find(&clients).Error; err != nil {
+		return clients, err
+	}
+	return clients, nil
+}
+
+func GetOAuthClientByClientId(tx *gorm.DB, clientId string) (models.OAuthClient, error) {
+	client := models.OAuthClient{}
+	if err := tx.Where("client_id = ? AND deleted_at IS NULL", clientId).First(&client).Error; err != nil {
+		return client, err
+	}
+	return client, nil
+}
+
+func DeleteOAuthClient(tx *gorm.DB, client models.OAuthClient) (models.OAuthClient, error) {
+	now := time.Now()
+	client.DeletedAt = &now
+	if err := tx.Save(&client).Error; err != nil {
+		return client, err
+	}
+	return client, nil
+}
+
+// CreateOAuthAuthorizationCode create single use authorization code
+// Return value (authorization_code_model, raw_code, error)
+func CreateOAuthAuthorizationCode(tx *gorm.DB, clientId string, userId string, redirectURI string, scope string, codeChallenge string, codeChallengeMethod string, now time.Time) (models.OAuthAuthorizationCode, string, error) {
+	rawCode, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.OAuthAuthorizationCode{}, "", err
+	}
+
+	code := models.OAuthAuthorizationCode{
+		CodeHash:            core.HashToken(rawCode),
+		ClientID:            clientId,
+		UserID:              userId,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		ExpiredAt:           now.Add(time.Minute * time.Duration(settings.OAUTH_AUTHORIZATION_CODE_EXPIRE_MINUTES)),
+		CreatedAt:           now,
+	}
+	if err := tx.Create(&code).Error; err != nil {
+		return code, "", err
+	}
+	return code, rawCode, nil
+}
+
+func GetOAuthAuthorizationCodeByCode(tx *gorm.DB, rawCode string) (models.OAuthAuthorizationCode, error) {
+	code := models.OAuthAuthorizationCode{}
+	if err := tx.Where("code_hash = ?", core.HashToken(rawCode)).First(&code).Error; err != nil {
+		return code, err
+	}
+	return code, nil
+}
+
+// UseOAuthAuthorizationCode mark authorization code as used
+// return ErrAuthorizationCodeUsed if code already used
+func UseOAuthAuthorizationCode(tx *gorm.DB, code models.OAuthAuthorizationCode, now time.Time) error {
+	result := tx.Model(&models.OAuthAuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", code.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAuthorizationCodeUsed
+	}
+	return nil
+}
+
+func SetOAuthAuthorizationCodeRefreshTokenFamily(tx *gorm.DB, code models.OAuthAuthorizationCode, familyId string) error {
+	return tx.Model(&models.OAuthAuthorizationCode{}).
+		Where("id = ?", code.ID).
+		Update("refresh_token_family_id", familyId).Error
+}
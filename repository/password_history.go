@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+// AddPasswordHistory record password hash set to user and keep only last PASSWORD_HISTORY_COUNT password,
+// nothing recorded when PASSWORD_HISTORY_COUNT is 0
+func AddPasswordHistory(tx *gorm.DB, userId string, hashedPassword string, now time.Time) error {
+	if settings.PASSWORD_HISTORY_COUNT <= 0 {
+		return nil
+	}
+	return tx.Transaction(func(tx *gorm.DB) error {
+		passwordHistory := models.PasswordHistory{
+			UserID:    userId,
+			Password:  hashedPassword,
+			CreatedAt: now,
+		}
+		if err := tx.Create(&passwordHistory).Error; err != nil {
+			return err
+		}
+		keptIds := tx.Model(&models.PasswordHistory{}).Select("id").
+			Where("user_id = ?", userId).
+			Order("created_at DESC").
+			Limit(settings.PASSWORD_HISTORY_COUNT)
+		return tx.Where("user_id = ? AND id NOT IN (?)", userId, keptIds).Delete(&models.PasswordHistory{}).Error
+	})
+}
+
+// IsPasswordReused check password same as current password of user or the last PASSWORD_HISTORY_COUNT password,
+// always false when PASSWORD_HISTORY_COUNT is 0
+func IsPasswordReused(tx *gorm.DB, user models.User, password string) (bool, error) {
+	if settings.PASSWORD_HISTORY_COUNT <= 0 {
+		return false, nil
+	}
+	if core.CheckPasswordHash(password, user.Password) {
+		return true, nil
+	}
+	passwordHistories := []models.PasswordHistory{}
+	if err := tx.Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Limit(settings.PASSWORD_HISTORY_COUNT).
+		Find(&passwordHistories).Error; err != nil {
+		return false, err
+	}
+	for _, passwordHistory := range passwordHistories {
+		if core.CheckPasswordHash(password, passwordHistory.Password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var ErrPasswordResetTokenInvalid = errors.New("password reset token invalid, expired or already used")
+
+// CreatePasswordResetToken create reset token for user, previous unused token invalidated
+// Return value (password_reset_token_model, raw_token, error)
+func CreatePasswordResetToken(tx *gorm.DB, userId string, now time.Time) (models.PasswordResetToken, string, error) {
+	rawToken, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.PasswordResetToken{}, "", err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserID:    userId,
+		TokenHash: core.HashToken(rawToken),
+		ExpiredAt: now.Add(time.Minute * time.Duration(settings.PASSWORD_RESET_TOKEN_EXPIRE_MINUTES)),
+		CreatedAt: now,
+	}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", userId).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&resetToken).Error
+	})
+	if err != nil {
+		return resetToken, "", err
+	}
+	return resetToken, rawToken, nil
+}
+
+// GetUserByPasswordResetToken get user of unused and not expired reset token without using the token,
+// return ErrPasswordResetTokenInvalid if token not found, expired or already used
+func GetUserByPasswordResetToken(tx *gorm.DB, rawToken string, now time.Time) (models.User, error) {
+	user := models.User{}
+	resetToken := models.PasswordResetToken{}
+	if err := tx.Where("token_hash = ? AND used_at IS NULL AND expired_at > ?", core.HashToken(rawToken), now).
+		First(&resetToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, ErrPasswordResetTokenInvalid
+		}
+		return user, err
+	}
+	if err := tx.Where("id = ? AND deleted_at IS NULL", resetToken.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, ErrPasswordResetTokenInvalid
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// ResetUserPassword use reset token and replace user password,
+// return ErrPasswordResetTokenInvalid if token not found, expired or already used
+func ResetUserPassword(tx *gorm.DB, rawToken string, password string, now time.Time) (models.User, error) {
+	hashedPassword, err := core.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		resetToken := models.PasswordResetToken{}
+		if err := tx.Where("token_hash = ?", core.HashToken(rawToken)).First(&resetToken).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPasswordResetTokenInvalid
+			}
+			return err
+		}
+
+		// only one request can use the same token
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL AND expired_at > ?", resetToken.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetTokenInvalid
+		}
+
+		if err := tx.Where("id = ? AND deleted_at IS NULL", resetToken.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPasswordResetTokenInvalid
+			}
+			return err
+		}
+		user.Password = hashedPassword
+		user.UpdatedAt = &now
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := AddPasswordHistory(tx, user.ID, hashedPassword, now); err != nil {
+			return err
+		}
+		return RevokeUserRefreshTokens(tx, user.ID, now, now)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"gorm.io/gorm"
+)
+
+func CreateRole(tx *gorm.DB, name string, description string, now time.Time) (models.Role, error) {
+	newRole := models.Role{
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   &now,
+	}
+	if err := tx.Create(&newRole).Error; err != nil {
+		return newRole, err
+	}
+	return newRole, nil
+}
+
+func GetAllRole(tx *gorm.DB) ([]models.Role, error) {
+	roles := []models.Role{}
+	if err := tx.Order("name asc").Find(&roles).Error; err != nil {
+		return roles, err
+	}
+	return roles, nil
+}
+
+func GetRoleByName(tx *gorm.DB, name string) (models.Role, error) {
+	role := models.Role{}
+	if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+		return role, err
+	}
+	return role, nil
+}
+
+func DeleteRole(tx *gorm.DB, role models.Role) error {
+	return tx.Delete(&role).Error
+}
+
+func GetAllPermission(tx *gorm.DB) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	if err := tx.Order("name asc").Find(&permissions).Error; err != nil {
+		return permissions, err
+	}
+	return permissions, nil
+}
+
+func GetPermissionByName(tx *gorm.DB, name string) (models.Permission, error) {
+	permission := models.Permission{}
+	if err := tx.Where("name = ?", name).First(&permission).Error; err != nil {
+		return permission, err
+	}
+	return permission, nil
+}
+
+// GrantPermissionToRole add permission to role, granting the same permission twice is no-op
+func GrantPermissionToRole(tx *gorm.DB, role models.Role, permission models.Permission, now time.Time) error {
+	rolePermission := models.RolePermission{}
+	err := tx.Where("role_id = ? AND permission_id = ?", role.ID, permission.ID).
+		Attrs(models.RolePermission{RoleID: role.ID, PermissionID: permission.ID, CreatedAt: now}).
+		FirstOrCreate(&rolePermission).Error
+	return err
+}
+
+func RevokePermissionFromRole(tx *gorm.DB, role models.Role, permission models.Permission) error {
+	return tx.Where("role_id = ? AND permission_id = ?", role.ID, permission.ID).
+		Delete(&models.RolePermission{}).Error
+}
+
+// GetRolePermissions return permission names of role
+func GetRolePermissions(tx *gorm.DB, role models.Role) ([]string, error) {
+	permissions := []string{}
+	err := tx.Model(&models.Permission{}).
+		Joins("JOIN public.role_permission ON role_permission.permission_id = permission.id").
+		Where("role_permission.role_id = ?", role.ID).
+		Order("permission.name asc").
+		Pluck("permission.name", &permissions).Error
+	return permissions, err
+}
+
+// AssignRoleToUser add role to user, assigning the same role twice is no-op
+func AssignRoleToUser(tx *gorm.DB, user models.User, role models.Role, now time.Time) error {
+	userRole := models.UserRole{}
+	err := tx.Where("user_id = ? AND role_id = ?", user.ID, role.ID).
+		Attrs(models.UserRole{UserID: user.ID, RoleID: role.ID, CreatedAt: now}).
+		FirstOrCreate(&userRole).Error
+	return err
+}
+
+func UnassignRoleFromUser(tx *gorm.DB, user models.User, role models.Role) error {
+	return tx.Where("user_id = ? AND role_id = ?", user.ID, role.ID).
+		Delete(&models.UserRole{}).Error
+}
+
+// GetUserPermissions return distinct permission names from every role of user
+func GetUserPermissions(tx *gorm.DB, userId string) ([]string, error) {
+	permissions := []string{}
+	err := tx.Model(&models.Permission{}).
+		Distinct("permission.name").
+		Joins("JOIN public.role_permission ON role_permission.permission_id = permission.id").
+		Joins("JOIN public.user_role ON user_role.role_id = role_permission.role_id").
+		Where("user_role.user_id = ?", userId).
+		Order("permission.name asc").
+		Pluck("permission.name", &permissions).Error
+	return permissions, err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+// CreateRefreshToken create new refresh token for user
+// if familyId is nil new token family will be created, clientId is nil if not issued to OAuth2 client
+// Return value (refresh_token_model, raw_refresh_token, error)
+func CreateRefreshToken(tx *gorm.DB, userId string, familyId *string, clientId *string, scope string, now time.Time) (models.RefreshToken, string, error) {
+	rawToken, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.RefreshToken{}, "", err
+	}
+
+	newFamilyId := uuid.NewV4().String()
+	if familyId != nil {
+		newFamilyId = *familyId
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:    userId,
+		FamilyID:  newFamilyId,
+		ClientID:  clientId,
+		TokenHash: core.HashToken(rawToken),
+		Scope:     scope,
+		ExpiredAt: now.Add(time.Minute * time.Duration(settings.REFRESH_TOKEN_EXPIRE_MINUTES)),
+		CreatedAt: now,
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return refreshToken, "", err
+	}
+	return refreshToken, rawToken, nil
+}
+
+func GetRefreshTokenByToken(tx *gorm.DB, rawToken string) (models.RefreshToken, error) {
+	refreshToken := models.RefreshToken{}
+	if err := tx.Where("token_hash = ?", core.HashToken(rawToken)).First(&refreshToken).Error; err != nil {
+		return refreshToken, err
+	}
+	return refreshToken, nil
+}
+
+// RotateRefreshToken revoke oldToken and create new refresh token on the same family
+// return ErrRefreshTokenRevoked if oldToken already revoked (reused)
+func RotateRefreshToken(tx *gorm.DB, oldToken models.RefreshToken, now time.Time) (models.RefreshToken, string, error) {
+	var newToken models.RefreshToken
+	var rawToken string
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var err error
+		newToken, rawToken, err = CreateRefreshToken(tx, oldToken.UserID, &oldToken.FamilyID, oldToken.ClientID, oldToken.Scope, now)
+		if err != nil {
+			return err
+		}
+
+		// only one request can rotate the same token
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldToken.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "replaced_by_id": newToken.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRevoked
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RefreshToken{}, "", err
+	}
+	return newToken, rawToken, nil
+}
+
+// RevokeRefreshTokenFamily revoke every refresh token on the family and terminate session of the family
+func RevokeRefreshTokenFamily(tx *gorm.DB, familyId string, now time.Time) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyId).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("id = ? AND terminated_at IS NULL", familyId).
+			Update("terminated_at", now).Error
+	})
+}
+
+// RevokeUserRefreshTokens revoke every refresh token and terminate every session of user created before given time
+func RevokeUserRefreshTokens(tx *gorm.DB, userId string, before time.Time, now time.Time) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND created_at < ? AND revoked_at IS NULL", userId, before).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND created_at < ? AND terminated_at IS NULL", userId, before).
+			Update("terminated_at", now).Error
+	})
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeCount number of recovery code generated when two factor enabled
+const RecoveryCodeCount = 10
+
+// SetUserTotpSecret start (or restart) TOTP enrollment, two factor not enabled until confirmed
+func SetUserTotpSecret(tx *gorm.DB, user models.User, secret string) (models.User, error) {
+	user.TotpSecret = &secret
+	user.TotpEnabledAt = nil
+	user.TotpLastUsedStep = 0
+	err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"totp_secret":         secret,
+		"totp_enabled_at":     nil,
+		"totp_last_used_step": 0,
+	}).Error
+	return user, err
+}
+
+// EnableUserTotp confirm TOTP enrollment and replace recovery codes,
+// raw recovery codes only returned once
+// Return value (user, raw_recovery_codes, error)
+func EnableUserTotp(tx *gorm.DB, user models.User, usedStep int64, now time.Time) (models.User, []string, error) {
+	rawCodes, err := core.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return user, nil, err
+	}
+
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"totp_enabled_at":     now,
+			"totp_last_used_step": usedStep,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		recoveryCodes := []models.UserRecoveryCode{}
+		for _, code := range rawCodes {
+			recoveryCodes = append(recoveryCodes, models.UserRecoveryCode{
+				UserID:    user.ID,
+				CodeHash:  core.HashToken(code),
+				CreatedAt: now,
+			})
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+	if err != nil {
+		return user, nil, err
+	}
+	user.TotpEnabledAt = &now
+	user.TotpLastUsedStep = usedStep
+	return user, rawCodes, nil
+}
+
+// UseUserTotpStep mark TOTP step as used, return false if the step (or later step) already used
+func UseUserTotpStep(tx *gorm.DB, user models.User, step int64) (bool, error) {
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseUserRecoveryCode mark unused recovery code as used, return false if code not found or already used
+func UseUserRecoveryCode(tx *gorm.DB, userId string, code string, now time.Time) (bool, error) {
+	result := tx.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, core.HashToken(core.NormalizeRecoveryCode(code))).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func CountUnusedUserRecoveryCode(tx *gorm.DB, userId string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Count(&count).Error
+	return count, err
+}
+
+// ResetUserTotp disable two factor and remove recovery codes
+func ResetUserTotp(tx *gorm.DB, user models.User) (models.User, error) {
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"totp_secret":         nil,
+			"totp_enabled_at":     nil,
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error
+	})
+	if err != nil {
+		return user, err
+	}
+	user.TotpSecret = nil
+	user.TotpEnabledAt = nil
+	user.TotpLastUsedStep = 0
+	return user, nil
+}
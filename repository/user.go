@@ -1,11 +1,14 @@
 package repository
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
 	"gorm.io/gorm"
 )
 
@@ -64,6 +67,38 @@ func CreateUser(tx *gorm.DB, username string, email string, password string, isA
 		DeletedAt:   nil,
 	}
 
+	if err := tx.Create(&newUser).Error; err != nil {
+		return newUser, err
+	}
+	if err := AddPasswordHistory(tx, newUser.ID, hashedPassword, createdAt); err != nil {
+		return newUser, err
+	}
+	return newUser, nil
+}
+
+// CreateLDAPUser create active user synced from LDAP entry, email verified by the directory.
+// Password is random, LDAP user authenticated by LDAP bind
+func CreateLDAPUser(tx *gorm.DB, username string, email string, ldapDn string, now time.Time) (models.User, error) {
+	password, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := core.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	newUser := models.User{
+		Email:           email,
+		Username:        username,
+		Password:        hashedPassword,
+		IsActive:        true,
+		IsSuperuser:     false,
+		CreatedAt:       now,
+		UpdatedAt:       &now,
+		EmailVerifiedAt: &now,
+		LdapDn:          &ldapDn,
+	}
 	if err := tx.Create(&newUser).Error; err != nil {
 		return newUser, err
 	}
@@ -81,6 +116,11 @@ func UpdateUser(tx *gorm.DB, updatedUser models.User, email string, username str
 		updatedUser.Password = hashedPassword
 	}
 
+	// Changed email need to be verified again, email compared case insensitive
+	if !strings.EqualFold(updatedUser.Email, email) {
+		updatedUser.EmailVerifiedAt = nil
+	}
+
 	// Update data
 	updatedUser.Email = email
 	updatedUser.Username = username
@@ -91,6 +131,11 @@ func UpdateUser(tx *gorm.DB, updatedUser models.User, email string, username str
 	if err := tx.Save(&updatedUser).Error; err != nil {
 		return updatedUser, err
 	}
+	if password != nil {
+		if err := AddPasswordHistory(tx, updatedUser.ID, updatedUser.Password, now); err != nil {
+			return updatedUser, err
+		}
+	}
 	return updatedUser, nil
 }
 
@@ -111,11 +156,141 @@ func GetUserByUsername(tx *gorm.DB, username string) (models.User, error) {
 	return user, nil
 }
 
-func GetUserByUsernameOrEmail(tx *gorm.DB, usernameOrEmail string) (models.User, error) {
+// GetUserByLdapDn get not deleted user synced from LDAP entry (dn case insensitive)
+func GetUserByLdapDn(tx *gorm.DB, ldapDn string) (models.User, error) {
 	user := models.User{}
-	if err := tx.Where("(username = ? OR email = ? ) AND deleted_at IS NULL", usernameOrEmail, usernameOrEmail).
-		First(&user).Error; err != nil {
+	if err := tx.Where("lower(ldap_dn) = lower(?) AND deleted_at IS NULL", ldapDn).First(&user).Error; err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// IsUsernameUsed check username used by any user, including deleted user (username is unique)
+func IsUsernameUsed(tx *gorm.DB, username string) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetUserByEmail get not deleted user by email (case insensitive)
+func GetUserByEmail(tx *gorm.DB, email string) (models.User, error) {
+	user := models.User{}
+	if err := tx.Where("lower(email) = lower(?) AND deleted_at IS NULL", email).First(&user).Error; err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// GetUserByUsernameOrEmail get not deleted user by username or email (case insensitive),
+// username take precedence when identifier match username of a user and email of another user
+func GetUserByUsernameOrEmail(tx *gorm.DB, usernameOrEmail string) (models.User, error) {
+	user, err := GetUserByUsername(tx, usernameOrEmail)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	return GetUserByEmail(tx, usernameOrEmail)
+}
+
+// RehashUserPassword replace password hash of user with hash of PASSWORD_HASHER configuration,
+// password not changed so updated_at and tokens of user kept
+func RehashUserPassword(tx *gorm.DB, user models.User, password string) (models.User, error) {
+	hashedPassword, err := core.HashPassword(password)
+	if err != nil {
+		return user, err
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("password", hashedPassword).Error; err != nil {
+		return user, err
+	}
+	user.Password = hashedPassword
+	return user, nil
+}
+
+// RecordFailedLogin increase failed login count of user and lock the user
+// with exponential backoff after LOGIN_MAX_FAILED_ATTEMPTS failure,
+// count restarted if previous failure older than core.LoginFailureWindow
+func RecordFailedLogin(tx *gorm.DB, user models.User, now time.Time) (models.User, error) {
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// count restarted when last failure is older than failure window
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"failed_login_count": gorm.Expr(
+				"CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1 ELSE failed_login_count + 1 END",
+				now.Add(-core.LoginFailureWindow()),
+			),
+			"last_failed_login_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", user.ID).First(&user).Error; err != nil {
+			return err
+		}
+
+		duration := core.LoginLockoutDuration(user.FailedLoginCount, settings.LOGIN_MAX_FAILED_ATTEMPTS)
+		if duration == 0 {
+			return nil
+		}
+		lockedUntil := now.Add(duration)
+		user.LockedUntil = &lockedUntil
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("locked_until", lockedUntil).Error
+	})
+	return user, err
+}
+
+// ClearLoginLockout reset failed login count and unlock user
+func ClearLoginLockout(tx *gorm.DB, user models.User) (models.User, error) {
+	user.FailedLoginCount = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}).Error
+	return user, err
+}
+
+// DeactivateUser deactivate user and revoke every refresh token of the user,
+// deactivatedById is nil when deactivated by machine client
+func DeactivateUser(tx *gorm.DB, user models.User, deactivatedById *string, reason *string, now time.Time) (models.User, error) {
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"is_active":          false,
+			"deactivated_at":     now,
+			"deactivated_reason": reason,
+			"deactivated_by_id":  deactivatedById,
+			"updated_at":         now,
+		}).Error; err != nil {
+			return err
+		}
+		return RevokeUserRefreshTokens(tx, user.ID, now, now)
+	})
+	if err != nil {
+		return user, err
+	}
+	user.IsActive = false
+	user.DeactivatedAt = &now
+	user.DeactivatedReason = reason
+	user.DeactivatedByID = deactivatedById
+	user.UpdatedAt = &now
+	return user, nil
+}
+
+// ActivateUser activate user and clear deactivation
+func ActivateUser(tx *gorm.DB, user models.User, now time.Time) (models.User, error) {
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"is_active":          true,
+		"deactivated_at":     nil,
+		"deactivated_reason": nil,
+		"deactivated_by_id":  nil,
+		"updated_at":         now,
+	}).Error; err != nil {
 		return user, err
 	}
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	user.DeactivatedReason = nil
+	user.DeactivatedByID = nil
+	user.UpdatedAt = &now
 	return user, nil
 }
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+// CreateUserSession create login session of user and the first refresh token,
+// session id used as family id of the refresh token, session expired together with the refresh token.
+// clientId is OAuth2 client the refresh token issued to (nil for /auth login)
+// Return value (user_session_model, refresh_token_model, raw_refresh_token, error)
+func CreateUserSession(tx *gorm.DB, userId string, info core.SessionInfo, clientId *string, scope string, now time.Time) (models.UserSession, models.RefreshToken, string, error) {
+	session := models.UserSession{
+		UserID:     userId,
+		UserAgent:  info.UserAgent,
+		IPAddress:  info.IPAddress,
+		ExpiredAt:  now.Add(time.Minute * time.Duration(settings.REFRESH_TOKEN_EXPIRE_MINUTES)),
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+	var refreshToken models.RefreshToken
+	var rawRefreshToken string
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		refreshToken, rawRefreshToken, err = CreateRefreshToken(tx, userId, &session.ID, clientId, scope, now)
+		return err
+	})
+	if err != nil {
+		return session, refreshToken, "", err
+	}
+	return session, refreshToken, rawRefreshToken, nil
+}
+
+// GetActiveUserSessions get session of user not terminated nor expired, last seen first
+func GetActiveUserSessions(tx *gorm.DB, userId string, now time.Time) ([]models.UserSession, error) {
+	sessions := []models.UserSession{}
+	if err := tx.Where("user_id = ? AND terminated_at IS NULL AND expired_at > ?", userId, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return sessions, err
+	}
+	return sessions, nil
+}
+
+// GetActiveUserSessionById get session of user not terminated nor expired
+func GetActiveUserSessionById(tx *gorm.DB, userId string, sessionId string, now time.Time) (models.UserSession, error) {
+	session := models.UserSession{}
+	if err := tx.Where("id = ? AND user_id = ? AND terminated_at IS NULL AND expired_at > ?", sessionId, userId, now).
+		First(&session).Error; err != nil {
+		return session, err
+	}
+	return session, nil
+}
+
+// GetUserSessionById get session by id, terminated or expired session included
+func GetUserSessionById(tx *gorm.DB, sessionId string) (models.UserSession, error) {
+	session := models.UserSession{}
+	if err := tx.Where("id = ?", sessionId).First(&session).Error; err != nil {
+		return session, err
+	}
+	return session, nil
+}
+
+// RefreshUserSession update last seen of session and extend it to expiry of the rotated refresh token
+func RefreshUserSession(tx *gorm.DB, sessionId string, expiredAt time.Time, now time.Time) error {
+	return tx.Model(&models.UserSession{}).
+		Where("id = ? AND terminated_at IS NULL", sessionId).
+		Updates(map[string]interface{}{"last_seen_at": now, "expired_at": expiredAt}).Error
+}
+
+// TerminateUserSession terminate session and revoke refresh token of the session,
+// access token of the session refused from now on
+func TerminateUserSession(tx *gorm.DB, session models.UserSession, now time.Time) error {
+	return RevokeRefreshTokenFamily(tx, session.ID, now)
+}
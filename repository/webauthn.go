@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+func CreateWebAuthnCredential(tx *gorm.DB, userId string, name string, credential webauthn.Credential, now time.Time) (models.WebAuthnCredential, error) {
+	webAuthnCredential := models.WebAuthnCredential{
+		UserID:          userId,
+		Name:            name,
+		CredentialID:    core.EncodeWebAuthnCredentialID(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       core.EncodeWebAuthnTransport(credential.Transport),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		CreatedAt:       now,
+		UpdatedAt:       &now,
+	}
+	if err := tx.Create(&webAuthnCredential).Error; err != nil {
+		return webAuthnCredential, err
+	}
+	return webAuthnCredential, nil
+}
+
+func GetAllUserWebAuthnCredential(tx *gorm.DB, userId string) ([]models.WebAuthnCredential, error) {
+	credentials := []models.WebAuthnCredential{}
+	if err := tx.Where("user_id = ?", userId).
+		Order("created_at desc").
+		Find(&credentials).Error; err != nil {
+		return credentials, err
+	}
+	return credentials, nil
+}
+
+func GetUserWebAuthnCredentialById(tx *gorm.DB, userId string, id string) (models.WebAuthnCredential, error) {
+	credential := models.WebAuthnCredential{}
+	if err := tx.Where("id = ? AND user_id = ?", id, userId).First(&credential).Error; err != nil {
+		return credential, err
+	}
+	return credential, nil
+}
+
+// GetWebAuthnUser get user and it's credentials for webauthn ceremony
+func GetWebAuthnUser(tx *gorm.DB, userId string) (core.WebAuthnUser, error) {
+	user, err := GetUserById(tx, userId)
+	if err != nil {
+		return core.WebAuthnUser{}, err
+	}
+	credentials, err := GetAllUserWebAuthnCredential(tx, user.ID)
+	if err != nil {
+		return core.WebAuthnUser{}, err
+	}
+	return core.WebAuthnUser{User: user, Credentials: credentials}, nil
+}
+
+func UpdateWebAuthnCredential(tx *gorm.DB, credential models.WebAuthnCredential, name string, now time.Time) (models.WebAuthnCredential, error) {
+	credential.Name = name
+	credential.UpdatedAt = &now
+	if err := tx.Save(&credential).Error; err != nil {
+		return credential, err
+	}
+	return credential, nil
+}
+
+// UpdateWebAuthnCredentialSignCount store new sign count after credential used to login
+func UpdateWebAuthnCredentialSignCount(tx *gorm.DB, userId string, credentialId []byte, signCount uint32, now time.Time) error {
+	return tx.Model(&models.WebAuthnCredential{}).
+		Where("user_id = ? AND credential_id = ?", userId, core.EncodeWebAuthnCredentialID(credentialId)).
+		Updates(map[string]interface{}{
+			"sign_count":   int64(signCount),
+			"last_used_at": now,
+		}).Error
+}
+
+func DeleteWebAuthnCredential(tx *gorm.DB, credential models.WebAuthnCredential) error {
+	return tx.Delete(&credential).Error
+}
@@ -0,0 +1,354 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Get All Api Key
+//
+//	@Summary		Get All Api Key
+//	@Description	Get all api key of current user
+//	@Tags			Api Key
+//	@Produce		json
+//	@Success		200	{object}	schemas.ApiKeyListResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/me/api-keys [get]
+func GetAllApiKeyRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage api key",
+		})
+	}
+
+	apiKeys, err := repository.GetAllUserApiKey(models.DBConn, principal.User.ID)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	results := []schemas.ApiKeyDetailResponse{}
+	for _, item := range apiKeys {
+		results = append(results, apiKeyDetailResponse(item))
+	}
+	return c.Status(200).JSON(schemas.ApiKeyListResponse{
+		Results: results,
+	})
+}
+
+// Get Detail Api Key
+//
+//	@Summary		Get Detail Api Key
+//	@Description	Get detail api key of current user
+//	@Tags			Api Key
+//	@Produce		json
+//	@Param			id	path		string	true	"Api Key ID"
+//	@Success		200	{object}	schemas.ApiKeyDetailResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/me/api-keys/{id} [get]
+func GetDetailApiKeyRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage api key",
+		})
+	}
+
+	// Get Params
+	apiKeyId := c.Params("apiKeyId")
+	if !core.IsValidUUID(apiKeyId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "api key not found",
+		})
+	}
+
+	apiKey, err := repository.GetUserApiKeyById(models.DBConn, principal.User.ID, apiKeyId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "api key not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(apiKeyDetailResponse(apiKey))
+}
+
+// Create Api Key
+//
+//	@Summary		Create Api Key
+//	@Description	Create api key for current user, the key only shown once.
+//	@Description	scope is space separated permission (empty is not limited), scoped caller only could create api key within it's scope
+//	@Tags			Api Key
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.ApiKeyCreateRequest	true	"Create Api Key"
+//	@Success		201		{object}	schemas.ApiKeyCreateResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/me/api-keys [post]
+func CreateApiKeyRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage api key",
+		})
+	}
+	// api key could not mint another (possibly wider or longer lived) api key
+	if principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "api key could not create api key",
+		})
+	}
+
+	// validation
+	request := schemas.ApiKeyCreateRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+	now := time.Now()
+	if request.ExpiredAt != nil && !request.ExpiredAt.After(now) {
+		return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+			Message: []map[string]string{
+				{"expired_at": "invalid expired_at, expired_at should in the future"},
+			},
+		})
+	}
+	scope, err := validateScope(request.Scope)
+	if err != nil {
+		if errors.Is(err, errInvalidScope) {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: []map[string]string{
+					{"scope": err.Error()},
+				},
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// scoped caller could not create api key with wider scope
+	scopes := core.SplitSpaceSeparated(scope)
+	if principal.Scopes != nil && (len(scopes) == 0 || !core.IsSubset(scopes, principal.Scopes)) {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "scope should within current token scope",
+		})
+	}
+
+	apiKey, rawKey, err := repository.CreateApiKey(
+		models.DBConn, principal.User.ID, request.Name, scopes, request.ExpiredAt, now,
+	)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(schemas.ApiKeyCreateResponse{
+		Id:         apiKey.ID,
+		Name:       apiKey.Name,
+		Key:        rawKey,
+		KeyPrefix:  apiKey.KeyPrefix,
+		Scope:      apiKey.Scope,
+		ExpiredAt:  apiKey.ExpiredAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	})
+}
+
+// Update Api Key
+//
+//	@Summary		Update Api Key
+//	@Description	Rename api key of current user
+//	@Tags			Api Key
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Api Key ID"
+//	@Param			payload	body		schemas.ApiKeyUpdateRequest	true	"Update Api Key"
+//	@Success		200		{object}	schemas.ApiKeyDetailResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		404		{object}	schemas.NotFoundResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/me/api-keys/{id} [put]
+func UpdateApiKeyRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage api key",
+		})
+	}
+
+	// Get Params
+	apiKeyId := c.Params("apiKeyId")
+	if !core.IsValidUUID(apiKeyId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "api key not found",
+		})
+	}
+
+	// validation
+	request := schemas.ApiKeyUpdateRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// get existing api key
+	apiKey, err := repository.GetUserApiKeyById(models.DBConn, principal.User.ID, apiKeyId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "api key not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	updatedApiKey, err := repository.UpdateApiKey(models.DBConn, apiKey, request.Name, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(apiKeyDetailResponse(updatedApiKey))
+}
+
+// Delete Api Key
+//
+//	@Summary		Delete Api Key
+//	@Description	Revoke api key of current user
+//	@Tags			Api Key
+//	@Param			id	path	string	true	"Api Key ID"
+//	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/me/api-keys/{id} [delete]
+func DeleteApiKeyRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage api key",
+		})
+	}
+
+	// Get Params
+	apiKeyId := c.Params("apiKeyId")
+	if !core.IsValidUUID(apiKeyId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "api key not found",
+		})
+	}
+
+	// get existing api key
+	apiKey, err := repository.GetUserApiKeyById(models.DBConn, principal.User.ID, apiKeyId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "api key not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	_, err = repository.DeleteApiKey(models.DBConn, apiKey, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	return c.Status(204).JSON(nil)
+}
+
+func apiKeyDetailResponse(apiKey models.ApiKey) schemas.ApiKeyDetailResponse {
+	return schemas.ApiKeyDetailResponse{
+		Id:         apiKey.ID,
+		Name:       apiKey.Name,
+		KeyPrefix:  apiKey.KeyPrefix,
+		Scope:      apiKey.Scope,
+		ExpiredAt:  apiKey.ExpiredAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
@@ -0,0 +1,226 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateApiKeyTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateApiKeyTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+}
+
+func (suite *MigrateApiKeyTestSuite) SetupTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateApiKeyTestSuite) createUser() models.User {
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	return user
+}
+
+func (suite *MigrateApiKeyTestSuite) TestCreateApiKey() {
+	// Given
+	request_user := suite.createUser()
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	requestJson := schemas.ApiKeyCreateRequest{
+		Name:  "ci",
+		Scope: "user:read",
+	}
+	requestJsonByte, _ := json.Marshal(requestJson)
+	req, _ := http.NewRequest("POST", "/user/me/api-keys", bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	jsonResponse := schemas.ApiKeyCreateResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "ci", jsonResponse.Name)
+	assert.Equal(suite.T(), "user:read", jsonResponse.Scope)
+	apiKey := models.ApiKey{}
+	models.DBConn.Where("id = ?", jsonResponse.Id).First(&apiKey)
+	assert.Equal(suite.T(), request_user.ID, apiKey.UserID)
+	assert.NotEqual(suite.T(), jsonResponse.Key, apiKey.KeyHash)
+
+	// Expect api key accepted on X-API-Key header and ApiKey scheme
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("X-API-Key", jsonResponse.Key)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("authorization", "ApiKey "+jsonResponse.Key)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	models.DBConn.Where("id = ?", jsonResponse.Id).First(&apiKey)
+	assert.NotNil(suite.T(), apiKey.LastUsedAt)
+
+	// Expect api key limited to it's scope
+	req, _ = http.NewRequest("DELETE", "/user/"+request_user.ID, nil)
+	req.Header.Set("X-API-Key", jsonResponse.Key)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+}
+
+func (suite *MigrateApiKeyTestSuite) TestGetAllApiKey() {
+	// Given
+	request_user := suite.createUser()
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	now := time.Now()
+	for _, name := range []string{"a", "b"} {
+		if _, _, err := repository.CreateApiKey(models.DBConn, request_user.ID, name, []string{}, nil, now); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	// When
+	req, _ := http.NewRequest("GET", "/user/me/api-keys", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.ApiKeyListResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Len(suite.T(), jsonResponse.Results, 2)
+}
+
+func (suite *MigrateApiKeyTestSuite) TestDeleteApiKey() {
+	// Given
+	request_user := suite.createUser()
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	apiKey, rawKey, err := repository.CreateApiKey(models.DBConn, request_user.ID, "ci", []string{}, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ := http.NewRequest("DELETE", "/user/me/api-keys/"+apiKey.ID, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+}
+
+func (suite *MigrateApiKeyTestSuite) TestExpiredApiKey() {
+	// Given
+	request_user := suite.createUser()
+	expiredAt := time.Now().Add(-time.Minute)
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, request_user.ID, "ci", []string{}, &expiredAt, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ := http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+}
+
+func (suite *MigrateApiKeyTestSuite) TestCreateApiKeyWithApiKey() {
+	// Given short lived scoped api key
+	request_user := suite.createUser()
+	expiredAt := time.Now().Add(time.Hour)
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, request_user.ID, "ci", []string{"user:read"}, &expiredAt, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	requestJsonByte, _ := json.Marshal(schemas.ApiKeyCreateRequest{Name: "forever"})
+	req, _ := http.NewRequest("POST", "/user/me/api-keys", bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	var count int64
+	models.DBConn.Model(&models.ApiKey{}).Where("user_id = ?", request_user.ID).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *MigrateApiKeyTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateApiKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateApiKeyTestSuite))
+}
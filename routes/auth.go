@@ -1,22 +1,37 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
 	"github.com/BimaAdi/fiberGormBoilerplate/repository"
 	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // Login
 //
 //	@Summary		Login
-//	@Description	login
+//	@Description	login with username or email (see LOGIN_IDENTIFIER), checked by authenticators of AUTHENTICATORS (password and/or ldap).
+//	@Description	optional space separated scope (for example user:read) limit the access token.
+//	@Description	user with two factor enabled get challenge token (202) to be exchanged on /auth/login/2fa
 //	@Tags			Auth
 //	@Produce		json
 //	@Param			payload	formData	schemas.LoginFormRequest	true	"form data"
 //	@Success		200		{object}	schemas.LoginResponse
+//	@Success		202		{object}	schemas.TwoFactorChallengeResponse
 //	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
 //	@Failure		500		{object}	schemas.InternalServerErrorResponse
 //	@Router			/auth/login [post]
 func authLoginRoute(c *fiber.Ctx) error {
@@ -28,57 +43,1338 @@ func authLoginRoute(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get User
-	user, err := repository.GetUserByUsername(models.DBConn, formRequest.Username)
+	// Scope should known permission
+	scope, err := validateScope(formRequest.Scope)
+	if err != nil {
+		if errors.Is(err, errInvalidScope) {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: []map[string]string{
+					{"scope": err.Error()},
+				},
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Get User and Check Password
+	user, err := authenticateUser(formRequest.Username, formRequest.Password, c.IP())
+	if err != nil {
+		if errors.Is(err, errInvalidCredentials) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "invalid credentials",
+			})
+		}
+		if errors.Is(err, core.ErrUserInactive) {
+			return userInactiveResponse(c)
+		}
+		if errors.Is(err, errLDAPUserConflict) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: err.Error(),
+			})
+		}
+		var lockedErr loginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(c, lockedErr)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Unverified email refused or limited scope
+	scope, err = core.ApplyEmailVerificationPolicy(user, scope)
+	if err != nil {
+		return emailNotVerifiedResponse(c)
+	}
+
+	// Two factor enabled, exchange challenge token on /auth/login/2fa
+	if user.TotpEnabledAt != nil {
+		challengeToken, err := core.GenerateTwoFactorChallengeToken(user, scope)
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(202).JSON(schemas.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         settings.TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES * 60,
+		})
+	}
+
+	// Generate JWT token and refresh token
+	loginResponse, _, err := generateLoginResponse(user, scope, nil, core.GetSessionInfo(c))
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
+}
+
+// Login Two Factor
+//
+//	@Summary		Login Two Factor
+//	@Description	exchange challenge token from /auth/login and TOTP code (or recovery code) for access token
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.LoginTwoFactorFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/login/2fa [post]
+func authLoginTwoFactorRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.LoginTwoFactorFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Check challenge token
+	userId, scope, err := core.ParseTwoFactorChallengeToken(formRequest.ChallengeToken)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired challenge token",
+		})
+	}
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(401).JSON(schemas.UnauthorizedResponse{
+				Message: "Invalid/Expired challenge token",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.CheckUserStatus(user); err != nil {
+		return userInactiveResponse(c)
+	}
+
+	// Check TOTP or recovery code
+	user, err = checkTwoFactorCode(user, formRequest.Code, c.IP())
+	if err != nil {
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+		var lockedErr loginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(c, lockedErr)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Challenge token is single use
+	if err := core.RevokeTwoFactorChallengeToken(formRequest.ChallengeToken); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Generate JWT token and refresh token
+	loginResponse, _, err := generateLoginResponse(user, scope, nil, core.GetSessionInfo(c))
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
+}
+
+// WebAuthn Register Begin
+//
+//	@Summary		WebAuthn Register Begin
+//	@Description	start passkey registration of current user, pass options to navigator.credentials.create()
+//	@Description	then send the credential with session token to /auth/webauthn/register/finish
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	schemas.WebAuthnRegisterBeginResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/auth/webauthn/register/begin [post]
+func authWebAuthnRegisterBeginRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key could not register passkey since passkey login is not limited
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could register passkey",
+		})
+	}
+	user := principal.User
+
+	webAuthnUser, err := repository.GetWebAuthnUser(models.DBConn, user.ID)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	options, sessionToken, err := core.BeginWebAuthnRegistration(webAuthnUser)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.WebAuthnRegisterBeginResponse{
+		Options:      *options,
+		SessionToken: sessionToken,
+	})
+}
+
+// WebAuthn Register Finish
+//
+//	@Summary		WebAuthn Register Finish
+//	@Description	verify and store passkey created by navigator.credentials.create()
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.WebAuthnRegisterFinishRequest	true	"credential"
+//	@Success		201		{object}	schemas.WebAuthnCredentialDetailResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/auth/webauthn/register/finish [post]
+func authWebAuthnRegisterFinishRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key could not register passkey since passkey login is not limited
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could register passkey",
+		})
+	}
+	user := principal.User
+
+	// validation
+	request := schemas.WebAuthnRegisterFinishRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	webAuthnUser, err := repository.GetWebAuthnUser(models.DBConn, user.ID)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	credential, err := core.FinishWebAuthnRegistration(webAuthnUser, request.SessionToken, request.Credential)
+	if err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "invalid credential",
+		})
+	}
+
+	webAuthnCredential, err := repository.CreateWebAuthnCredential(
+		models.DBConn, user.ID, request.Name, *credential, time.Now(),
+	)
 	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(webAuthnCredentialDetailResponse(webAuthnCredential))
+}
+
+// WebAuthn Login Begin
+//
+//	@Summary		WebAuthn Login Begin
+//	@Description	start passkey login, pass options to navigator.credentials.get()
+//	@Description	then send the credential with session token to /auth/webauthn/login/finish.
+//	@Description	without username any passkey (discoverable credential) could be used,
+//	@Description	unknown username or username without passkey get the same challenge as without username
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.WebAuthnLoginBeginRequest	false	"username"
+//	@Success		200		{object}	schemas.WebAuthnLoginBeginResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/webauthn/login/begin [post]
+func authWebAuthnLoginBeginRoute(c *fiber.Ctx) error {
+	// Get data from body (optional)
+	request := schemas.WebAuthnLoginBeginRequest{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Unknown user, user without passkey and LDAP user get challenge of discoverable credential
+	// so the response not reveal registered username, the assertion refused on finish
+	var webAuthnUser *core.WebAuthnUser
+	if request.Username != "" {
+		user, err := getUserByLoginIdentifier(request.Username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		if err == nil && user.LdapDn == nil {
+			currentUser, err := repository.GetWebAuthnUser(models.DBConn, user.ID)
+			if err != nil {
+				return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+					Error: err.Error(),
+				})
+			}
+			if len(currentUser.Credentials) > 0 {
+				webAuthnUser = &currentUser
+			}
+		}
+	}
+
+	options, sessionToken, err := core.BeginWebAuthnLogin(webAuthnUser)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.WebAuthnLoginBeginResponse{
+		Options:      *options,
+		SessionToken: sessionToken,
+	})
+}
+
+// WebAuthn Login Finish
+//
+//	@Summary		WebAuthn Login Finish
+//	@Description	verify passkey assertion created by navigator.credentials.get() and login,
+//	@Description	optional space separated scope (for example user:read) limit the access token
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.WebAuthnLoginFinishRequest	true	"credential"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/webauthn/login/finish [post]
+func authWebAuthnLoginFinishRoute(c *fiber.Ctx) error {
+	// validation
+	request := schemas.WebAuthnLoginFinishRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Scope should known permission
+	scope, err := validateScope(request.Scope)
+	if err != nil {
+		if errors.Is(err, errInvalidScope) {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: []map[string]string{
+					{"scope": err.Error()},
+				},
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Client ip locked
+	now := time.Now()
+	if retryAfter := core.IPLoginThrottle.RetryAfter(c.IP(), now); retryAfter > 0 {
+		return loginLockedResponse(c, loginLockedError{RetryAfter: retryAfter})
+	}
+
+	// Verify assertion
+	webAuthnUser, credential, err := core.FinishWebAuthnLogin(
+		request.SessionToken, request.Credential,
+		func(userId string) (core.WebAuthnUser, error) {
+			if !core.IsValidUUID(userId) {
+				return core.WebAuthnUser{}, gorm.ErrRecordNotFound
+			}
+			return repository.GetWebAuthnUser(models.DBConn, userId)
+		},
+	)
+	if err != nil {
+		core.IPLoginThrottle.RecordFailure(c.IP(), now)
 		return c.Status(400).JSON(schemas.BadRequestResponse{
 			Message: "invalid credentials",
 		})
 	}
 
-	// Check Password
-	if !core.CheckPasswordHash(formRequest.Password, user.Password) {
+	// LDAP user only authenticated by LDAP, so disabling the entry on directory disable the login
+	if webAuthnUser.User.LdapDn != nil {
 		return c.Status(400).JSON(schemas.BadRequestResponse{
 			Message: "invalid credentials",
 		})
 	}
+	if err := core.CheckUserStatus(webAuthnUser.User); err != nil {
+		return userInactiveResponse(c)
+	}
+
+	// User locked by failed login, passkey not bypass the lockout
+	if lockedUntil := webAuthnUser.User.LockedUntil; lockedUntil != nil && now.Before(*lockedUntil) {
+		return loginLockedResponse(c, loginLockedError{RetryAfter: lockedUntil.Sub(now)})
+	}
+	if err := repository.UpdateWebAuthnCredentialSignCount(
+		models.DBConn, webAuthnUser.User.ID, credential.ID, credential.Authenticator.SignCount, now,
+	); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Unverified email refused or limited scope
+	scope, err = core.ApplyEmailVerificationPolicy(webAuthnUser.User, scope)
+	if err != nil {
+		return emailNotVerifiedResponse(c)
+	}
 
-	// Generate JWT token
-	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	// Generate JWT token and refresh token
+	loginResponse, _, err := generateLoginResponse(webAuthnUser.User, scope, nil, core.GetSessionInfo(c))
 	if err != nil {
 		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
-	return c.Status(200).JSON(schemas.LoginResponse{
-		AccessToken: token,
-		TokenType:   "Bearer",
-	})
+	return c.Status(200).JSON(loginResponse)
+}
+
+// Refresh Token
+//
+//	@Summary		Refresh Token
+//	@Description	exchange refresh token with new access token and refresh token,
+//	@Description	refresh token is rotated and reusing old refresh token revoke all token on the same family
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.RefreshTokenFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/refresh [post]
+func authRefreshRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.RefreshTokenFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Rotate refresh token
+	loginResponse, err := refreshLoginResponse(formRequest.RefreshToken, nil)
+	if err != nil {
+		if errors.Is(err, errInvalidRefreshToken) {
+			return c.Status(401).JSON(schemas.UnauthorizedResponse{
+				Message: "Invalid/Expired refresh token",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
 }
 
 // Logout
 //
 //	@Summary		Logout
-//	@Description	logout
+//	@Description	logout, revoke current access token and terminate its session, refresh token without session revoked when given. Api key could not logout
 //	@Tags			Auth
 //	@Produce		json
-//	@Success		200	{object}	schemas.LogoutResponse
-//	@Failure		400	{object}	schemas.UnauthorizedResponse
-//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Param			payload	formData	schemas.LogoutFormRequest	false	"form data"
+//	@Success		200		{object}	schemas.LogoutResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
 //	@Security		OAuth2Password
 //	@Router			/auth/logout [post]
 func authLogoutRoute(c *fiber.Ctx) error {
-	// Authorize User
-	user, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
+	// Authorize User, scoped token could logout since only the token itself revoked
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could logout",
+		})
+	}
+	if principal.IsApiKey() {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "api key can not logout",
+		})
+	}
+	user := principal.User
+
+	// Get data from form (optional)
+	formRequest := schemas.LogoutFormRequest{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&formRequest); err != nil {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Revoke access token
+	token, err := core.GetTokenFromAuthorizationHeader(c)
 	if err != nil {
 		return c.Status(401).JSON(schemas.UnauthorizedResponse{
 			Message: "Invalid/Expired token",
 		})
 	}
+	if err := core.RevokeJWTToken(token); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Terminate session of the access token, token without sid (impersonation, client credentials) has no session
+	now := time.Now()
+	if sessionId := core.GetSessionIdFromJWTToken(token); sessionId != "" {
+		session, err := repository.GetActiveUserSessionById(models.DBConn, user.ID, sessionId, now)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		if err == nil {
+			if err := repository.TerminateUserSession(models.DBConn, session, now); err != nil {
+				return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+					Error: err.Error(),
+				})
+			}
+		}
+	}
+
+	// Revoke refresh token
+	if formRequest.RefreshToken != "" {
+		refreshToken, err := repository.GetRefreshTokenByToken(models.DBConn, formRequest.RefreshToken)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		if err == nil && refreshToken.UserID == user.ID {
+			if err := repository.RevokeRefreshTokenFamily(models.DBConn, refreshToken.FamilyID, now); err != nil {
+				return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+					Error: err.Error(),
+				})
+			}
+		}
+	}
 
 	return c.Status(200).JSON(schemas.LogoutResponse{
 		Email:    user.Email,
 		Username: user.Username,
 	})
 }
+
+// Logout Everywhere
+//
+//	@Summary		Logout Everywhere
+//	@Description	revoke every access token and refresh token of current user issued before given timestamp (default now)
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.LogoutAllFormRequest	false	"form data"
+//	@Success		200		{object}	schemas.LogoutResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/auth/logout-all [post]
+func authLogoutAllRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token not allowed since scope never cover other session
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped token could logout everywhere",
+		})
+	}
+	user := principal.User
+
+	// Get data from form (optional)
+	formRequest := schemas.LogoutAllFormRequest{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&formRequest); err != nil {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+	}
+
+	now := time.Now()
+	before := now
+	if formRequest.Before != "" {
+		before, err = time.Parse(time.RFC3339, formRequest.Before)
+		if err != nil || before.After(now) {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: []map[string]string{
+					{"before": "invalid before, before should RFC3339 timestamp not in the future"},
+				},
+			})
+		}
+	}
+
+	// Revoke access token and refresh token
+	if err := core.TokenRevocationStore.RevokeAllBefore(user.ID, before); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := repository.RevokeUserRefreshTokens(models.DBConn, user.ID, before, now); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.LogoutResponse{
+		Email:    user.Email,
+		Username: user.Username,
+	})
+}
+
+// Register
+//
+//	@Summary		Register
+//	@Description	register new user, registered user always active and never superuser.
+//	@Description	controlled by REGISTRATION_POLICY and REGISTRATION_EMAIL_DOMAINS, verification link sent to the email
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.RegisterFormRequest	true	"form data"
+//	@Success		201		{object}	schemas.RegisterResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/register [post]
+func authRegisterRoute(c *fiber.Ctx) error {
+	// Registration policy
+	switch settings.REGISTRATION_POLICY {
+	case core.RegistrationPolicyOpen:
+	case core.RegistrationPolicyInviteOnly:
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "registration is invite only, accept invitation on /auth/invitation/accept",
+		})
+	default:
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "registration disabled",
+		})
+	}
+
+	// Get data from form
+	formRequest := schemas.RegisterFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Check captcha
+	isHuman, err := core.RegistrationCaptchaVerifier.Verify(formRequest.CaptchaToken, c.IP())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if !isHuman {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "invalid captcha",
+		})
+	}
+
+	// Check email domain, username and email
+	validation_errors, err = validateNewUser(formRequest.Username, formRequest.Email)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if !core.IsEmailDomainAllowed(formRequest.Email) {
+		validation_errors.Message = append([]map[string]string{
+			{"email": "email domain not allowed"},
+		}, validation_errors.Message...)
+	}
+	passwordErrors, err := validatePassword(formRequest.Password, formRequest.Username, formRequest.Email, nil)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	validation_errors.Message = append(validation_errors.Message, passwordErrors...)
+	if len(validation_errors.Message) > 0 {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Create active non superuser user
+	now := time.Now()
+	createdUser, err := repository.CreateUser(
+		models.DBConn,
+		formRequest.Username,
+		formRequest.Email,
+		formRequest.Password,
+		true,
+		false,
+		now,
+		&now,
+	)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Send email verification link
+	if err := sendEmailVerification(createdUser); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(schemas.RegisterResponse{
+		Id:       createdUser.ID,
+		Username: createdUser.Username,
+		Email:    createdUser.Email,
+	})
+}
+
+// Forgot Password
+//
+//	@Summary		Forgot Password
+//	@Description	send password reset link to user email, always respond 200 whether the email registered or not
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.ForgotPasswordFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.ForgotPasswordResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/password/forgot [post]
+func authForgotPasswordRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.ForgotPasswordFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Same response for unknown email, prevent email enumeration
+	response := schemas.ForgotPasswordResponse{
+		Message: "if the email registered, password reset link has been sent",
+	}
+	user, err := repository.GetUserByEmail(models.DBConn, formRequest.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(200).JSON(response)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if !user.IsActive {
+		return c.Status(200).JSON(response)
+	}
+
+	// Create reset token and send it to user
+	_, rawToken, err := repository.CreatePasswordResetToken(models.DBConn, user.ID, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.UserNotifier.Send(core.NewPasswordResetNotification(user, rawToken)); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(response)
+}
+
+// Reset Password
+//
+//	@Summary		Reset Password
+//	@Description	set new password using token from password reset link,
+//	@Description	every access token and refresh token of the user revoked
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.ResetPasswordFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.ResetPasswordResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/password/reset [post]
+func authResetPasswordRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.ResetPasswordFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Check password policy of token user
+	now := time.Now()
+	user, err := repository.GetUserByPasswordResetToken(models.DBConn, formRequest.Token, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasswordResetTokenInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired reset token",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	validation_errors.Message, err = validatePassword(formRequest.Password, user.Username, user.Email, &user)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if len(validation_errors.Message) > 0 {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Use reset token and update password
+	user, err = repository.ResetUserPassword(models.DBConn, formRequest.Token, formRequest.Password, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasswordResetTokenInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired reset token",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Revoke access token, refresh token already revoked by ResetUserPassword
+	if err := core.TokenRevocationStore.RevokeAllBefore(user.ID, now); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.ResetPasswordResponse{
+		Message: "password has been reset",
+	})
+}
+
+// Verify Email
+//
+//	@Summary		Verify Email
+//	@Description	verify user email using token from email verification link
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.VerifyEmailFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.VerifyEmailResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/verify-email [post]
+func authVerifyEmailRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.VerifyEmailFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Use verification token
+	user, err := repository.VerifyUserEmail(models.DBConn, formRequest.Token, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailVerificationTokenInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired verification token",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.VerifyEmailResponse{
+		Email:           user.Email,
+		EmailVerifiedAt: *user.EmailVerifiedAt,
+	})
+}
+
+// Resend Email Verification
+//
+//	@Summary		Resend Email Verification
+//	@Description	send new email verification link, always respond 200 whether the email registered (and unverified) or not
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.ResendEmailVerificationFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.ResendEmailVerificationResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/verify-email/resend [post]
+func authResendEmailVerificationRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.ResendEmailVerificationFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Same response for unknown or verified email, prevent email enumeration
+	response := schemas.ResendEmailVerificationResponse{
+		Message: "if the email registered and not verified, verification link has been sent",
+	}
+	user, err := repository.GetUserByEmail(models.DBConn, formRequest.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(200).JSON(response)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if user.EmailVerifiedAt != nil {
+		return c.Status(200).JSON(response)
+	}
+
+	if err := sendEmailVerification(user); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(response)
+}
+
+var errInvalidCredentials = errors.New("invalid credentials")
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+var errInvalidScope = errors.New("invalid scope")
+var errInvalidTwoFactorCode = errors.New("invalid two factor code")
+
+// loginLockedError returned by authenticateUser when user or client ip locked after too many failed login
+type loginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err loginLockedError) Error() string {
+	return "too many failed login, retry after " + err.RetryAfter.Round(time.Second).String()
+}
+
+// getUserByLoginIdentifier get user by username and/or email allowed by LOGIN_IDENTIFIER
+func getUserByLoginIdentifier(identifier string) (models.User, error) {
+	switch settings.LOGIN_IDENTIFIER {
+	case core.LoginIdentifierUsername:
+		return repository.GetUserByUsername(models.DBConn, identifier)
+	case core.LoginIdentifierEmail:
+		return repository.GetUserByEmail(models.DBConn, identifier)
+	default:
+		return repository.GetUserByUsernameOrEmail(models.DBConn, identifier)
+	}
+}
+
+// authenticateUser check the credentials with authenticators of AUTHENTICATORS,
+// failed login tracked per user (found by LOGIN_IDENTIFIER) and per client ip.
+// return errInvalidCredentials if no authenticator accept the credentials,
+// loginLockedError if user or client ip locked and core.ErrUserInactive if user deactivated
+func authenticateUser(username string, password string, ip string) (models.User, error) {
+	// Client ip locked
+	now := time.Now()
+	if retryAfter := core.IPLoginThrottle.RetryAfter(ip, now); retryAfter > 0 {
+		return models.User{}, loginLockedError{RetryAfter: retryAfter}
+	}
+
+	// Existing user locked, credentials not checked until unlocked
+	user, err := getUserByLoginIdentifier(username)
+	isUserFound := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	if isUserFound && user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return user, loginLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	authenticatedUser, err := checkCredentials(username, password)
+	if errors.Is(err, errInvalidCredentials) {
+		core.IPLoginThrottle.RecordFailure(ip, now)
+		if !isUserFound {
+			return models.User{}, errInvalidCredentials
+		}
+		user, err = repository.RecordFailedLogin(models.DBConn, user, now)
+		if err != nil {
+			return user, err
+		}
+		if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+			return user, loginLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+		}
+		return user, errInvalidCredentials
+	}
+	if err != nil {
+		return authenticatedUser, err
+	}
+	user = authenticatedUser
+
+	// Deactivated user, checked after credentials so account status not leaked
+	if err := core.CheckUserStatus(user); err != nil {
+		return user, err
+	}
+
+	// Reset failed login, for two factor user reset after two factor code checked
+	if user.TotpEnabledAt == nil && (user.FailedLoginCount > 0 || user.LockedUntil != nil) {
+		user, err = repository.ClearLoginLockout(models.DBConn, user)
+		if err != nil {
+			return user, err
+		}
+	}
+	return user, nil
+}
+
+// checkTwoFactorCode check TOTP code or unused recovery code of user,
+// wrong code counted as failed login of the user.
+// return errInvalidTwoFactorCode if code invalid and loginLockedError if user or client ip locked
+func checkTwoFactorCode(user models.User, code string, ip string) (models.User, error) {
+	// User or client ip locked
+	now := time.Now()
+	if retryAfter := core.IPLoginThrottle.RetryAfter(ip, now); retryAfter > 0 {
+		return user, loginLockedError{RetryAfter: retryAfter}
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return user, loginLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	isValid := false
+	var err error
+	if user.TotpSecret != nil {
+		if step, isMatch := core.VerifyTOTPCode(*user.TotpSecret, code, now, user.TotpLastUsedStep); isMatch {
+			isValid, err = repository.UseUserTotpStep(models.DBConn, user, step)
+			if err != nil {
+				return user, err
+			}
+		}
+	}
+	if !isValid && code != "" {
+		isValid, err = repository.UseUserRecoveryCode(models.DBConn, user.ID, code, now)
+		if err != nil {
+			return user, err
+		}
+	}
+
+	if !isValid {
+		core.IPLoginThrottle.RecordFailure(ip, now)
+		user, err = repository.RecordFailedLogin(models.DBConn, user, now)
+		if err != nil {
+			return user, err
+		}
+		if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+			return user, loginLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+		}
+		return user, errInvalidTwoFactorCode
+	}
+
+	// Reset failed login
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		user, err = repository.ClearLoginLockout(models.DBConn, user)
+		if err != nil {
+			return user, err
+		}
+	}
+	return user, nil
+}
+
+// loginLockedResponse 429 response with Retry-After header (seconds)
+func loginLockedResponse(c *fiber.Ctx, err loginLockedError) error {
+	c.Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	return c.Status(429).JSON(schemas.TooManyRequestsResponse{
+		Message: err.Error(),
+	})
+}
+
+// userInactiveResponse response when deactivated user login
+func userInactiveResponse(c *fiber.Ctx) error {
+	return c.Status(403).JSON(schemas.ForbiddenResponse{
+		Message: "user inactive",
+	})
+}
+
+// emailNotVerifiedResponse response when login refused by EMAIL_VERIFICATION_POLICY
+func emailNotVerifiedResponse(c *fiber.Ctx) error {
+	return c.Status(403).JSON(schemas.ForbiddenResponse{
+		Message: "email not verified, verify your email using link sent to your email",
+	})
+}
+
+// sendEmailVerification send email verification link to current user email
+func sendEmailVerification(user models.User) error {
+	_, rawToken, err := repository.CreateEmailVerificationToken(models.DBConn, user, time.Now())
+	if err != nil {
+		return err
+	}
+	return core.UserNotifier.Send(core.NewEmailVerificationNotification(user, rawToken))
+}
+
+// validateNewUser check username or email not used by other user
+func validateNewUser(username string, email string) (schemas.UnprocessableEntityResponse, error) {
+	validation_errors := schemas.UnprocessableEntityResponse{
+		Message: []map[string]string{},
+	}
+	isUsernameUsed, err := repository.IsUsernameUsed(models.DBConn, username)
+	if err != nil {
+		return validation_errors, err
+	}
+	if isUsernameUsed {
+		validation_errors.Message = append(validation_errors.Message, map[string]string{
+			"username": "username already used",
+		})
+	}
+	_, err = repository.GetUserByEmail(models.DBConn, email)
+	if err == nil {
+		validation_errors.Message = append(validation_errors.Message, map[string]string{
+			"email": "email already used",
+		})
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return validation_errors, err
+	}
+	return validation_errors, nil
+}
+
+// validatePassword check password against password policy and password history of user (nil for new user),
+// return field errors of the password
+func validatePassword(password string, username string, email string, user *models.User) ([]map[string]string, error) {
+	fieldErrors := []map[string]string{}
+	violations, err := core.CheckPasswordPolicy(password, username, email)
+	if err != nil {
+		return fieldErrors, err
+	}
+	if user != nil {
+		isReused, err := repository.IsPasswordReused(models.DBConn, *user, password)
+		if err != nil {
+			return fieldErrors, err
+		}
+		if isReused {
+			violations = append(violations, fmt.Sprintf("password should not same as last %d password", settings.PASSWORD_HISTORY_COUNT))
+		}
+	}
+	for _, violation := range violations {
+		fieldErrors = append(fieldErrors, map[string]string{"password": violation})
+	}
+	return fieldErrors, nil
+}
+
+// validateScope check every space separated scope is a permission,
+// return normalized scope or errInvalidScope
+func validateScope(scope string) (string, error) {
+	scopes := core.SplitSpaceSeparated(scope)
+	if len(scopes) == 0 {
+		return "", nil
+	}
+
+	permissions, err := repository.GetAllPermission(models.DBConn)
+	if err != nil {
+		return "", err
+	}
+	permissionNames := []string{}
+	for _, permission := range permissions {
+		permissionNames = append(permissionNames, permission.Name)
+	}
+	for _, item := range scopes {
+		if !core.IsSubset([]string{item}, permissionNames) {
+			return "", fmt.Errorf("%w %s", errInvalidScope, item)
+		}
+	}
+	return strings.Join(scopes, " "), nil
+}
+
+// generateLoginResponse record login session of user and generate access token and new refresh token family
+// of the session, empty scope is not limited. clientId is OAuth2 client the tokens issued to, nil for /auth login
+func generateLoginResponse(user models.User, scope string, clientId *string, info core.SessionInfo) (schemas.LoginResponse, models.RefreshToken, error) {
+	session, refreshToken, rawRefreshToken, err := repository.CreateUserSession(models.DBConn, user.ID, info, clientId, scope, time.Now())
+	if err != nil {
+		return schemas.LoginResponse{}, refreshToken, err
+	}
+
+	token, err := core.GenerateSessionJWTTokenFromUser(models.DBConn, user, scope, session.ID)
+	if err != nil {
+		return schemas.LoginResponse{}, refreshToken, err
+	}
+
+	return schemas.LoginResponse{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    settings.ACCESS_TOKEN_EXPIRE_MINUTES * 60,
+		Scope:        scope,
+	}, refreshToken, nil
+}
+
+// refreshLoginResponse rotate refresh token and generate new access token,
+// reusing revoked refresh token revoke all token on the same family.
+// return errInvalidRefreshToken if refresh token invalid, expired, reused, issued to other client
+// (clientId nil for /auth/refresh), user deactivated and if user email not verified on refuse_login policy
+func refreshLoginResponse(rawRefreshToken string, clientId *string) (schemas.LoginResponse, error) {
+	// Get Refresh Token
+	oldRefreshToken, err := repository.GetRefreshTokenByToken(models.DBConn, rawRefreshToken)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return schemas.LoginResponse{}, errInvalidRefreshToken
+		}
+		return schemas.LoginResponse{}, err
+	}
+
+	// Reused refresh token, revoke all token on the family
+	now := time.Now()
+	if oldRefreshToken.RevokedAt != nil {
+		if err := repository.RevokeRefreshTokenFamily(models.DBConn, oldRefreshToken.FamilyID, now); err != nil {
+			return schemas.LoginResponse{}, err
+		}
+		return schemas.LoginResponse{}, errInvalidRefreshToken
+	}
+
+	if now.After(oldRefreshToken.ExpiredAt) {
+		return schemas.LoginResponse{}, errInvalidRefreshToken
+	}
+
+	// Only redeemed by the client the token issued to
+	if (clientId == nil) != (oldRefreshToken.ClientID == nil) ||
+		(clientId != nil && *clientId != *oldRefreshToken.ClientID) {
+		return schemas.LoginResponse{}, errInvalidRefreshToken
+	}
+
+	// Get User
+	user, err := repository.GetUserById(models.DBConn, oldRefreshToken.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return schemas.LoginResponse{}, errInvalidRefreshToken
+		}
+		return schemas.LoginResponse{}, err
+	}
+	if err := core.CheckUserStatus(user); err != nil {
+		return schemas.LoginResponse{}, errInvalidRefreshToken
+	}
+
+	// Unverified email (changed after login) refused or limited scope
+	scope, err := core.ApplyEmailVerificationPolicy(user, oldRefreshToken.Scope)
+	if err != nil {
+		return schemas.LoginResponse{}, fmt.Errorf("%w, %s", errInvalidRefreshToken, err.Error())
+	}
+
+	// Rotate refresh token
+	newRefreshToken, rawRefreshToken, err := repository.RotateRefreshToken(models.DBConn, oldRefreshToken, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+			if err := repository.RevokeRefreshTokenFamily(models.DBConn, oldRefreshToken.FamilyID, now); err != nil {
+				return schemas.LoginResponse{}, err
+			}
+			return schemas.LoginResponse{}, errInvalidRefreshToken
+		}
+		return schemas.LoginResponse{}, err
+	}
+
+	// Keep session alive, refresh token family created before session recorded has no session
+	sessionId := ""
+	session, err := repository.GetUserSessionById(models.DBConn, oldRefreshToken.FamilyID)
+	if err == nil {
+		if err := repository.RefreshUserSession(models.DBConn, session.ID, newRefreshToken.ExpiredAt, now); err != nil {
+			return schemas.LoginResponse{}, err
+		}
+		sessionId = session.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return schemas.LoginResponse{}, err
+	}
+
+	// Generate JWT token with the same scope
+	token, err := core.GenerateSessionJWTTokenFromUser(models.DBConn, user, scope, sessionId)
+	if err != nil {
+		return schemas.LoginResponse{}, err
+	}
+
+	return schemas.LoginResponse{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    settings.ACCESS_TOKEN_EXPIRE_MINUTES * 60,
+		Scope:        scope,
+	}, nil
+}
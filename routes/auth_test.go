@@ -6,12 +6,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
 	"github.com/BimaAdi/fiberGormBoilerplate/routes"
 	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
 	"github.com/BimaAdi/fiberGormBoilerplate/settings"
@@ -30,6 +32,7 @@ func (suite *MigrateAuthTestSuite) SetupSuite() {
 	settings.InitiateSettings("../.env")
 	models.Initiate()
 	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
 	app := fiber.New()
 	suite.app = routes.InitiateRoutes(app)
 	suite.timeout = 5 // second
@@ -78,6 +81,8 @@ func (suite *MigrateAuthTestSuite) TestLoginSuccess() {
 	}
 	err = json.Unmarshal(body, &jsonResponse)
 	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEmpty(suite.T(), jsonResponse.RefreshToken)
+	assert.Equal(suite.T(), settings.ACCESS_TOKEN_EXPIRE_MINUTES*60, jsonResponse.ExpiresIn)
 }
 
 func (suite *MigrateAuthTestSuite) TestLoginFailed() {
@@ -114,6 +119,200 @@ func (suite *MigrateAuthTestSuite) TestLoginFailed() {
 	assert.Equal(suite.T(), 400, resp.StatusCode)
 }
 
+func (suite *MigrateAuthTestSuite) TestLoginIdentifier() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user_login := models.User{
+		Email:       "Test@Test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user_login)
+	defer func() {
+		settings.LOGIN_IDENTIFIER = core.LoginIdentifierUsernameOrEmail
+	}()
+	login := func(username string) int {
+		var param = url.Values{}
+		param.Set("username", username)
+		param.Set("password", "Fakepassword")
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := suite.app.Test(req, suite.timeout)
+		assert.Nil(suite.T(), err)
+		return resp.StatusCode
+	}
+
+	// When username or email allowed
+	settings.LOGIN_IDENTIFIER = core.LoginIdentifierUsernameOrEmail
+
+	// Expect email matched case insensitive
+	assert.Equal(suite.T(), 200, login("test"))
+	assert.Equal(suite.T(), 200, login("test@test.com"))
+
+	// When only username allowed
+	settings.LOGIN_IDENTIFIER = core.LoginIdentifierUsername
+
+	// Expect
+	assert.Equal(suite.T(), 200, login("test"))
+	assert.Equal(suite.T(), 400, login("test@test.com"))
+
+	// When only email allowed
+	settings.LOGIN_IDENTIFIER = core.LoginIdentifierEmail
+
+	// Expect
+	assert.Equal(suite.T(), 400, login("test"))
+	assert.Equal(suite.T(), 200, login("TEST@test.com"))
+
+	// When create user with same email in different case
+	other_user := models.User{
+		Email:       "test@test.com",
+		Username:    "other",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	err = models.DBConn.Create(&other_user).Error
+
+	// Expect refused by unique email index
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *MigrateAuthTestSuite) TestLoginRehashPassword() {
+	// Given user password hashed with outdated bcrypt cost
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.BcryptHasher{Cost: 4}.Hash("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user_login := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user_login)
+
+	// When
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect password rehashed with configured hasher
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	user, err := repository.GetUserById(models.DBConn, user_login.ID)
+	assert.Nil(suite.T(), err)
+	assert.NotEqual(suite.T(), hashPasword, user.Password)
+	assert.False(suite.T(), core.PasswordNeedsRehash(user.Password))
+	assert.True(suite.T(), core.CheckPasswordHash("Fakepassword", user.Password))
+}
+
+func (suite *MigrateAuthTestSuite) TestRefreshTokenSuccess() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	request_user := models.User{
+		Email:       "a@test.com",
+		Username:    "a",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&request_user)
+	oldRefreshToken, rawRefreshToken, err := repository.CreateRefreshToken(models.DBConn, request_user.ID, nil, nil, "", time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("refresh_token", rawRefreshToken)
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/auth/refresh", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEmpty(suite.T(), jsonResponse.AccessToken)
+	assert.NotEqual(suite.T(), rawRefreshToken, jsonResponse.RefreshToken)
+
+	rotatedRefreshToken := models.RefreshToken{}
+	models.DBConn.Where("id = ?", oldRefreshToken.ID).First(&rotatedRefreshToken)
+	assert.NotNil(suite.T(), rotatedRefreshToken.RevokedAt)
+	assert.NotNil(suite.T(), rotatedRefreshToken.ReplacedByID)
+}
+
+func (suite *MigrateAuthTestSuite) TestRefreshTokenReused() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	request_user := models.User{
+		Email:       "a@test.com",
+		Username:    "a",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&request_user)
+	oldRefreshToken, rawOldRefreshToken, err := repository.CreateRefreshToken(models.DBConn, request_user.ID, nil, nil, "", time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	newRefreshToken, _, err := repository.RotateRefreshToken(models.DBConn, oldRefreshToken, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("refresh_token", rawOldRefreshToken)
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/auth/refresh", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+	revokedRefreshToken := models.RefreshToken{}
+	models.DBConn.Where("id = ?", newRefreshToken.ID).First(&revokedRefreshToken)
+	assert.NotNil(suite.T(), revokedRefreshToken.RevokedAt)
+}
+
 func (suite *MigrateAuthTestSuite) TestLogoutSuccess() {
 	// Given
 	// create request user
@@ -150,6 +349,125 @@ func (suite *MigrateAuthTestSuite) TestLogoutSuccess() {
 	jsonResponse := schemas.LogoutResponse{}
 	err = json.Unmarshal(body, &jsonResponse)
 	assert.Nil(suite.T(), err, "Invalid response json")
+
+	// When 2
+	// Test revoked token
+	req2, _ := http.NewRequest("POST", "/auth/logout", nil)
+	req2.Header.Set("authorization", "Bearer "+token)
+	resp2, err := suite.app.Test(req2, suite.timeout)
+
+	// Expect 2
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp2.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestLogoutAllSuccess() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	request_user := models.User{
+		Email:       "a@test.com",
+		Username:    "a",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&request_user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	otherToken, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	// iat has second precision, token issued on the same second of logout all not revoked
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	refreshToken, _, err := repository.CreateRefreshToken(models.DBConn, request_user.ID, nil, nil, "", time.Now().Add(-time.Minute))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("before", time.Now().Add(time.Second).Format(time.RFC3339))
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/auth/logout-all", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 422, resp.StatusCode)
+
+	// When 2
+	req2, _ := http.NewRequest("POST", "/auth/logout-all", nil)
+	req2.Header.Set("authorization", "Bearer "+token)
+	resp2, err := suite.app.Test(req2, suite.timeout)
+
+	// Expect 2
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp2.StatusCode)
+	_, err = core.GetUserFromJWTToken(models.DBConn, otherToken)
+	assert.NotNil(suite.T(), err)
+	revokedRefreshToken := models.RefreshToken{}
+	models.DBConn.Where("id = ?", refreshToken.ID).First(&revokedRefreshToken)
+	assert.NotNil(suite.T(), revokedRefreshToken.RevokedAt)
+}
+
+func (suite *MigrateAuthTestSuite) TestLoginAfterLogoutAll() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user_login := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user_login)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user_login)
+	if err != nil {
+		panic(err.Error())
+	}
+	req, _ := http.NewRequest("POST", "/auth/logout-all", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// When login right after logout all (on the same second)
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	req, _ = http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	req, _ = http.NewRequest("GET", "/user/me", nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect new token accepted
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
 }
 
 func (suite *MigrateAuthTestSuite) TestLogoutInvalidToken() {
@@ -173,6 +491,799 @@ func (suite *MigrateAuthTestSuite) TestLogoutInvalidToken() {
 	assert.Nil(suite.T(), err, "Invalid response json")
 }
 
+func (suite *MigrateAuthTestSuite) TestLoginScope() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user_login := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user_login)
+
+	// When
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	param.Set("scope", "user:read")
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/auth/login", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "user:read", jsonResponse.Scope)
+
+	// Expect read only token could read user but not delete user
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	req, _ = http.NewRequest("DELETE", "/user/"+user_login.ID, nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// Expect refreshed token keep the scope
+	param = url.Values{}
+	param.Set("refresh_token", jsonResponse.RefreshToken)
+	payload = bytes.NewBufferString(param.Encode())
+	req, _ = http.NewRequest("POST", "/auth/refresh", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	refreshResponse := schemas.LoginResponse{}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &refreshResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "user:read", refreshResponse.Scope)
+
+	// When unknown scope
+	param = url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	param.Set("scope", "user:read admin")
+	payload = bytes.NewBufferString(param.Encode())
+	req, _ = http.NewRequest("POST", "/auth/login", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 422, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestLoginLockout() {
+	// Given
+	settings.LOGIN_MAX_FAILED_ATTEMPTS = 3
+	core.IPLoginThrottle = core.NewLoginThrottle()
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user_login := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user_login)
+	login := func(password string) *http.Response {
+		var param = url.Values{}
+		param.Set("username", "test")
+		param.Set("password", password)
+		var payload = bytes.NewBufferString(param.Encode())
+		req, _ := http.NewRequest("POST", "/auth/login", payload)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := suite.app.Test(req, suite.timeout)
+		assert.Nil(suite.T(), err)
+		return resp
+	}
+
+	// When
+	resp1 := login("wrongpassword")
+	resp2 := login("wrongpassword")
+	resp3 := login("wrongpassword")
+	resp4 := login("Fakepassword")
+
+	// Expect locked on 3rd failure, correct password still locked
+	assert.Equal(suite.T(), 400, resp1.StatusCode)
+	assert.Equal(suite.T(), 400, resp2.StatusCode)
+	assert.Equal(suite.T(), 429, resp3.StatusCode)
+	assert.Equal(suite.T(), 429, resp4.StatusCode)
+	assert.Equal(suite.T(), "60", resp4.Header.Get("Retry-After"))
+	user, err := repository.GetUserById(models.DBConn, user_login.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 3, user.FailedLoginCount)
+	assert.NotNil(suite.T(), user.LockedUntil)
+
+	// When unlocked
+	_, err = repository.ClearLoginLockout(models.DBConn, user)
+	assert.Nil(suite.T(), err)
+	resp5 := login("Fakepassword")
+
+	// Expect
+	assert.Equal(suite.T(), 200, resp5.StatusCode)
+
+	// When failure after quiet window
+	lastFailedLoginAt := time.Now().Add(-core.LoginFailureWindow() - time.Minute)
+	models.DBConn.Model(&models.User{}).Where("id = ?", user_login.ID).Updates(map[string]interface{}{
+		"failed_login_count":   2,
+		"last_failed_login_at": lastFailedLoginAt,
+	})
+	resp6 := login("wrongpassword")
+
+	// Expect count restarted, not locked
+	assert.Equal(suite.T(), 400, resp6.StatusCode)
+	user, err = repository.GetUserById(models.DBConn, user_login.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, user.FailedLoginCount)
+	assert.Nil(suite.T(), user.LockedUntil)
+	settings.LOGIN_MAX_FAILED_ATTEMPTS = 5
+}
+
+func (suite *MigrateAuthTestSuite) TestPasswordReset() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+	_, rawRefreshToken, err := repository.CreateRefreshToken(models.DBConn, user.ID, nil, nil, "", time.Now().Add(-time.Minute))
+	if err != nil {
+		panic(err.Error())
+	}
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+	// iat has second precision, token issued on the same second of password reset not revoked
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	// When forgot password
+	var param = url.Values{}
+	param.Set("email", "test@test.com")
+	req, _ := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect reset link sent and only the hash stored
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	notifications := notifier.Notifications("test@test.com")
+	assert.Len(suite.T(), notifications, 1)
+	rawToken := suite.tokenFromNotification(notifications[0], settings.PASSWORD_RESET_URL)
+	resetToken := models.PasswordResetToken{}
+	models.DBConn.Where("user_id = ?", user.ID).First(&resetToken)
+	assert.Equal(suite.T(), core.HashToken(rawToken), resetToken.TokenHash)
+
+	// When reset password
+	param = url.Values{}
+	param.Set("token", rawToken)
+	param.Set("password", "Newpassword")
+	req, _ = http.NewRequest("POST", "/auth/password/reset", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect password changed and old session revoked
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	updatedUser, _ := repository.GetUserById(models.DBConn, user.ID)
+	assert.True(suite.T(), core.CheckPasswordHash("Newpassword", updatedUser.Password))
+	_, err = core.GetUserFromJWTToken(models.DBConn, token)
+	assert.NotNil(suite.T(), err)
+	param = url.Values{}
+	param.Set("refresh_token", rawRefreshToken)
+	req, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// Expect reset token single use
+	param = url.Values{}
+	param.Set("token", rawToken)
+	param.Set("password", "Otherpassword")
+	req, _ = http.NewRequest("POST", "/auth/password/reset", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestPasswordResetExpiredToken() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	createdAt := time.Now().Add(-time.Minute * time.Duration(settings.PASSWORD_RESET_TOKEN_EXPIRE_MINUTES+1))
+	_, rawToken, err := repository.CreatePasswordResetToken(models.DBConn, user.ID, createdAt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("token", rawToken)
+	param.Set("password", "Newpassword")
+	req, _ := http.NewRequest("POST", "/auth/password/reset", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestMagicLinkLogin() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+	settings.MAGIC_LINK_ENABLED = true
+	defer func() { settings.MAGIC_LINK_ENABLED = false }()
+
+	// When request magic link
+	var param = url.Values{}
+	param.Set("email", "test@test.com")
+	req, _ := http.NewRequest("POST", "/auth/magic-link", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect link sent and nonce cookie set
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	notifications := notifier.Notifications("test@test.com")
+	assert.Len(suite.T(), notifications, 1)
+	rawToken := suite.tokenFromNotification(notifications[0], settings.MAGIC_LINK_URL)
+	var nonceCookie *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "magic_link_nonce" {
+			nonceCookie = cookie
+		}
+	}
+	assert.NotNil(suite.T(), nonceCookie)
+	assert.True(suite.T(), nonceCookie.HttpOnly)
+
+	// When verify from other device (without the nonce cookie)
+	param = url.Values{}
+	param.Set("token", rawToken)
+	req, _ = http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When verify with other nonce
+	req, _ = http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "magic_link_nonce", Value: "other-nonce"})
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When verify from the device requested the link
+	req, _ = http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(nonceCookie)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect logged in and email verified
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	loggedInUser, err := core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.ID, loggedInUser.ID)
+	assert.NotNil(suite.T(), loggedInUser.EmailVerifiedAt)
+
+	// Expect token single use
+	req, _ = http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(nonceCookie)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestMagicLinkExpiredToken() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	settings.MAGIC_LINK_ENABLED = true
+	defer func() { settings.MAGIC_LINK_ENABLED = false }()
+	createdAt := time.Now().Add(-time.Minute * time.Duration(settings.MAGIC_LINK_TOKEN_EXPIRE_MINUTES+1))
+	_, rawToken, err := repository.CreateMagicLinkToken(models.DBConn, user.ID, "nonce", createdAt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("token", rawToken)
+	req, _ := http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "magic_link_nonce", Value: "nonce"})
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestMagicLinkThrottle() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+	settings.MAGIC_LINK_ENABLED = true
+	maxFailedAttempts := settings.LOGIN_IP_MAX_FAILED_ATTEMPTS
+	settings.LOGIN_IP_MAX_FAILED_ATTEMPTS = 3
+	core.IPLoginThrottle = core.NewLoginThrottle()
+	defer func() {
+		settings.MAGIC_LINK_ENABLED = false
+		settings.LOGIN_IP_MAX_FAILED_ATTEMPTS = maxFailedAttempts
+		core.IPLoginThrottle = core.NewLoginThrottle()
+	}()
+	requestLink := func(email string) *http.Response {
+		param := url.Values{}
+		param.Set("email", email)
+		req, _ := http.NewRequest("POST", "/auth/magic-link", bytes.NewBufferString(param.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := suite.app.Test(req, suite.timeout)
+		assert.Nil(suite.T(), err)
+		return resp
+	}
+
+	// When request link twice
+	assert.Equal(suite.T(), 200, requestLink("test@test.com").StatusCode)
+	resp := requestLink("test@test.com")
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// Expect only one link sent within MAGIC_LINK_RESEND_SECONDS, working with the latest cookie
+	notifications := notifier.Notifications("test@test.com")
+	assert.Len(suite.T(), notifications, 1)
+	rawToken := suite.tokenFromNotification(notifications[0], settings.MAGIC_LINK_URL)
+	var nonceCookie *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "magic_link_nonce" {
+			nonceCookie = cookie
+		}
+	}
+	assert.NotNil(suite.T(), nonceCookie)
+	magicLinkToken := models.MagicLinkToken{}
+	models.DBConn.Where("token_hash = ?", core.HashToken(rawToken)).First(&magicLinkToken)
+	assert.Equal(suite.T(), core.HashToken(nonceCookie.Value), magicLinkToken.NonceHash)
+
+	// When request link of unknown email
+	assert.Equal(suite.T(), 200, requestLink("unknown@test.com").StatusCode)
+	assert.Equal(suite.T(), 200, requestLink("other@test.com").StatusCode)
+
+	// Expect client ip locked
+	resp = requestLink("test@test.com")
+	assert.Equal(suite.T(), 429, resp.StatusCode)
+	assert.NotEmpty(suite.T(), resp.Header.Get("Retry-After"))
+}
+
+func (suite *MigrateAuthTestSuite) TestMagicLinkLockedUser() {
+	// Given user locked by failed login
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	lockedUntil := time.Now().Add(time.Minute)
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		LockedUntil: &lockedUntil,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	settings.MAGIC_LINK_ENABLED = true
+	defer func() { settings.MAGIC_LINK_ENABLED = false }()
+	_, rawToken, err := repository.CreateMagicLinkToken(models.DBConn, user.ID, "nonce", time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("token", rawToken)
+	req, _ := http.NewRequest("POST", "/auth/magic-link/verify", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "magic_link_nonce", Value: "nonce"})
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 429, resp.StatusCode)
+	assert.NotEmpty(suite.T(), resp.Header.Get("Retry-After"))
+}
+
+func (suite *MigrateAuthTestSuite) TestForgotPasswordUnknownEmail() {
+	// Given
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+
+	// When
+	var param = url.Values{}
+	param.Set("email", "unknown@test.com")
+	req, _ := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect same response without sending notification
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	assert.Len(suite.T(), notifier.Notifications("unknown@test.com"), 0)
+}
+
+func (suite *MigrateAuthTestSuite) TestVerifyEmail() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	request_user := models.User{
+		Email:       "a@test.com",
+		Username:    "a",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&request_user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+
+	// When create user
+	requestJsonByte, _ := json.Marshal(schemas.UserCreateRequest{
+		Username:    "test",
+		Email:       "test@test.com",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+	})
+	req, _ := http.NewRequest("POST", "/user/", bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect verification link sent
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	createdUser, err := repository.GetUserByEmail(models.DBConn, "test@test.com")
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), createdUser.EmailVerifiedAt)
+	notifications := notifier.Notifications("test@test.com")
+	assert.Len(suite.T(), notifications, 1)
+	rawToken := suite.tokenFromNotification(notifications[0], settings.EMAIL_VERIFICATION_URL)
+
+	// When verify email
+	var param = url.Values{}
+	param.Set("token", rawToken)
+	req, _ = http.NewRequest("POST", "/auth/verify-email", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	createdUser, _ = repository.GetUserById(models.DBConn, createdUser.ID)
+	assert.NotNil(suite.T(), createdUser.EmailVerifiedAt)
+
+	// When email changed
+	requestJsonByte, _ = json.Marshal(schemas.UserUpdateRequest{
+		Username:    "test",
+		Email:       "new@test.com",
+		IsActive:    true,
+		IsSuperuser: true,
+	})
+	req, _ = http.NewRequest("PUT", "/user/"+createdUser.ID, bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect email need to be verified again
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	createdUser, _ = repository.GetUserById(models.DBConn, createdUser.ID)
+	assert.Nil(suite.T(), createdUser.EmailVerifiedAt)
+	assert.Len(suite.T(), notifier.Notifications("new@test.com"), 1)
+
+	// Expect used token could not be used again
+	req, _ = http.NewRequest("POST", "/auth/verify-email", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateAuthTestSuite) TestLoginEmailNotVerified() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	defer func() { settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyNone }()
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+
+	// When refuse_login policy
+	settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyRefuseLogin
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When limit_scope policy
+	settings.EMAIL_VERIFICATION_POLICY = core.EmailVerificationPolicyLimitScope
+	settings.EMAIL_UNVERIFIED_SCOPE = "user:read"
+	req, _ = http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "user:read", jsonResponse.Scope)
+
+	// When verified
+	repository.MarkUserEmailVerified(models.DBConn, user, time.Now())
+	req, _ = http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect not limited
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse = schemas.LoginResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	json.Unmarshal(body, &jsonResponse)
+	assert.Equal(suite.T(), "", jsonResponse.Scope)
+}
+
+func (suite *MigrateAuthTestSuite) TestRegister() {
+	// Given
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+	settings.REGISTRATION_POLICY = core.RegistrationPolicyOpen
+	settings.REGISTRATION_EMAIL_DOMAINS = "test.com"
+	defer func() {
+		settings.REGISTRATION_POLICY = core.RegistrationPolicyDisabled
+		settings.REGISTRATION_EMAIL_DOMAINS = ""
+	}()
+
+	// When
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("email", "test@test.com")
+	param.Set("password", "Fakepassword")
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect active non superuser created and verification link sent
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	createdUser, err := repository.GetUserByUsername(models.DBConn, "test")
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), createdUser.IsActive)
+	assert.False(suite.T(), createdUser.IsSuperuser)
+	assert.Nil(suite.T(), createdUser.EmailVerifiedAt)
+	assert.True(suite.T(), core.CheckPasswordHash("Fakepassword", createdUser.Password))
+	assert.Len(suite.T(), notifier.Notifications("test@test.com"), 1)
+
+	// When register with used username and not allowed email domain
+	param.Set("email", "test@other.com")
+	req, _ = http.NewRequest("POST", "/auth/register", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 422, resp.StatusCode)
+	jsonResponse := schemas.UnprocessableEntityResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), []map[string]string{
+		{"email": "email domain not allowed"},
+		{"username": "username already used"},
+	}, jsonResponse.Message)
+}
+
+func (suite *MigrateAuthTestSuite) TestRegisterPolicy() {
+	// Given
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("email", "test@test.com")
+	param.Set("password", "Fakepassword")
+	defer func() { settings.REGISTRATION_POLICY = core.RegistrationPolicyDisabled }()
+
+	for _, policy := range []string{core.RegistrationPolicyDisabled, core.RegistrationPolicyInviteOnly} {
+		// When
+		settings.REGISTRATION_POLICY = policy
+		req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBufferString(param.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := suite.app.Test(req, suite.timeout)
+
+		// Expect
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), 403, resp.StatusCode)
+	}
+	_, err := repository.GetUserByUsername(models.DBConn, "test")
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *MigrateAuthTestSuite) TestRegisterCaptcha() {
+	// Given
+	settings.REGISTRATION_POLICY = core.RegistrationPolicyOpen
+	core.RegistrationCaptchaVerifier = rejectCaptchaVerifier{}
+	defer func() {
+		settings.REGISTRATION_POLICY = core.RegistrationPolicyDisabled
+		core.RegistrationCaptchaVerifier = core.NoopCaptchaVerifier{}
+	}()
+
+	// When
+	var param = url.Values{}
+	param.Set("username", "test")
+	param.Set("email", "test@test.com")
+	param.Set("password", "Fakepassword")
+	param.Set("captcha_token", "bot")
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+// rejectCaptchaVerifier captcha verifier rejecting every token
+type rejectCaptchaVerifier struct{}
+
+func (rejectCaptchaVerifier) Verify(token string, ip string) (bool, error) {
+	return false, nil
+}
+
+// tokenFromNotification get raw token from link on notification body
+func (suite *MigrateAuthTestSuite) tokenFromNotification(notification core.Notification, linkPrefix string) string {
+	linkStart := strings.Index(notification.Body, linkPrefix)
+	assert.NotEqual(suite.T(), -1, linkStart)
+	rawToken, err := url.QueryUnescape(strings.Fields(notification.Body[linkStart+len(linkPrefix):])[0])
+	assert.Nil(suite.T(), err)
+	return rawToken
+}
+
 func (suite *MigrateAuthTestSuite) TearDownTest() {
 	models.ClearAllData()
 }
@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+var errLDAPUserConflict = errors.New("username or email of ldap user already used by other user")
+
+// authenticator check credentials submitted on login and return the authenticated user,
+// errInvalidCredentials returned if the credentials not accepted so the next authenticator tried
+type authenticator interface {
+	Authenticate(identifier string, password string) (models.User, error)
+}
+
+// authenticatorsByName authenticator selectable on AUTHENTICATORS
+var authenticatorsByName = map[string]authenticator{
+	core.AuthenticatorPassword: passwordAuthenticator{},
+	core.AuthenticatorLDAP:     ldapAuthenticator{},
+}
+
+// checkCredentials try authenticators of AUTHENTICATORS in order until one accept the credentials
+func checkCredentials(identifier string, password string) (models.User, error) {
+	for _, name := range core.SplitSpaceSeparated(settings.AUTHENTICATORS) {
+		user, err := authenticatorsByName[name].Authenticate(identifier, password)
+		if errors.Is(err, errInvalidCredentials) {
+			continue
+		}
+		return user, err
+	}
+	return models.User{}, errInvalidCredentials
+}
+
+// ==========================================
+
+// passwordAuthenticator check password against password hash of user found by LOGIN_IDENTIFIER
+type passwordAuthenticator struct{}
+
+func (passwordAuthenticator) Authenticate(identifier string, password string) (models.User, error) {
+	user, err := getUserByLoginIdentifier(identifier)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, errInvalidCredentials
+		}
+		return user, err
+	}
+
+	// LDAP user only authenticated by LDAP, so disabling the entry on directory disable the login
+	if user.LdapDn != nil {
+		return user, errInvalidCredentials
+	}
+	if !core.CheckPasswordHash(password, user.Password) {
+		return user, errInvalidCredentials
+	}
+
+	// Password stored with outdated algorithm or parameters, rehash with plain password
+	if core.PasswordNeedsRehash(user.Password) {
+		return repository.RehashUserPassword(models.DBConn, user, password)
+	}
+	return user, nil
+}
+
+// ==========================================
+
+// ldapAuthenticator bind to LDAP_URL as the user entry, user synced on first login
+// and roles of LDAP_GROUP_ROLES synced from group membership on every login
+type ldapAuthenticator struct{}
+
+func (ldapAuthenticator) Authenticate(identifier string, password string) (models.User, error) {
+	identity, err := core.AuthenticateLDAP(identifier, password)
+	if err != nil {
+		if errors.Is(err, core.ErrLDAPInvalidCredentials) {
+			return models.User{}, errInvalidCredentials
+		}
+		return models.User{}, err
+	}
+
+	now := time.Now()
+	user, err := getOrCreateLDAPUser(identity, now)
+	if err != nil {
+		return user, err
+	}
+	if err := syncLDAPUserRoles(user, identity.Groups, now); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// getOrCreateLDAPUser get user synced from the LDAP entry or create it,
+// local user with the same username or email never linked to the entry
+func getOrCreateLDAPUser(identity core.LDAPIdentity, now time.Time) (models.User, error) {
+	user, err := repository.GetUserByLdapDn(models.DBConn, identity.DN)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	if identity.Email == "" {
+		return user, fmt.Errorf("ldap entry %s has no %s attribute", identity.DN, settings.LDAP_EMAIL_ATTRIBUTE)
+	}
+
+	isUsed, err := repository.IsUsernameUsed(models.DBConn, identity.Username)
+	if err != nil {
+		return user, err
+	}
+	if isUsed {
+		return user, errLDAPUserConflict
+	}
+	_, err = repository.GetUserByEmail(models.DBConn, identity.Email)
+	if err == nil {
+		return user, errLDAPUserConflict
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	return repository.CreateLDAPUser(models.DBConn, identity.Username, identity.Email, identity.DN, now)
+}
+
+// syncLDAPUserRoles assign role mapped from group the user member of and unassign other mapped role,
+// role not mapped on LDAP_GROUP_ROLES (assigned manually) left as is
+func syncLDAPUserRoles(user models.User, groups []string, now time.Time) error {
+	groupRoles, err := core.ParseLDAPGroupRoles(settings.LDAP_GROUP_ROLES)
+	if err != nil {
+		return err
+	}
+	for roleName, isMember := range core.MapLDAPGroupRoles(groupRoles, groups) {
+		role, err := repository.GetRoleByName(models.DBConn, roleName)
+		if err != nil {
+			// Mapped role not created yet, nothing to assign
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if isMember {
+			err = repository.AssignRoleToUser(models.DBConn, user, role, now)
+		} else {
+			err = repository.UnassignRoleFromUser(models.DBConn, user, role)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
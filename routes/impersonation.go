@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Impersonate User
+//
+//	@Summary		Impersonate User
+//	@Description	Superuser get access token acting as the user, token has act claim of the superuser,
+//	@Description	expire after IMPERSONATION_EXPIRE_MINUTES and has no refresh token. Superuser could not be impersonated.
+//	@Description	every request with the token logged on audit log
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"User ID"
+//	@Param			user	body		schemas.UserImpersonateRequest	false	"Impersonate User"
+//	@Success		200		{object}	schemas.UserImpersonateResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		404		{object}	schemas.NotFoundResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/{id}/impersonate [post]
+func ImpersonateUserRoute(c *fiber.Ctx) error {
+	// Get data from body (optional)
+	var jsonRequest schemas.UserImpersonateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&jsonRequest); err != nil {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	principal := getPrincipal(c)
+	if principal.User.ID == user.ID {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "could not impersonate yourself",
+		})
+	}
+	if user.IsSuperuser {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "could not impersonate superuser",
+		})
+	}
+	if err := core.CheckUserStatus(user); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "could not impersonate inactive user",
+		})
+	}
+
+	token, expiredAt, err := core.GenerateImpersonationJWTToken(user, principal.User)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	reason := ""
+	if jsonRequest.Reason != nil {
+		reason = *jsonRequest.Reason
+	}
+	core.AuditLogger.Printf(
+		"impersonation started actor=%s (%s) user=%s (%s) expired_at=%s reason=%q",
+		principal.User.ID, principal.User.Username, user.ID, user.Username, expiredAt.Format(time.RFC3339), reason,
+	)
+
+	return c.Status(200).JSON(schemas.UserImpersonateResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   settings.IMPERSONATION_EXPIRE_MINUTES * 60,
+		ExpiredAt:   expiredAt,
+		UserId:      user.ID,
+		ActorId:     principal.User.ID,
+	})
+}
+
+// End Impersonation
+//
+//	@Summary		End Impersonation
+//	@Description	Revoke impersonation token used on the request
+//	@Tags			User
+//	@Produce		json
+//	@Success		200	{object}	schemas.UserEndImpersonationResponse
+//	@Failure		400	{object}	schemas.BadRequestResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/impersonate/end [post]
+func EndImpersonationRoute(c *fiber.Ctx) error {
+	// Authorize User
+	token, err := core.GetTokenFromAuthorizationHeader(c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	user, impersonator, err := core.GetUserAndImpersonatorFromJWTToken(models.DBConn, token)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if impersonator == nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "token is not impersonation token",
+		})
+	}
+
+	if err := core.RevokeJWTToken(token); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	core.AuditLogger.Printf(
+		"impersonation ended actor=%s (%s) user=%s (%s)",
+		impersonator.ID, impersonator.Username, user.ID, user.Username,
+	)
+
+	return c.Status(200).JSON(schemas.UserEndImpersonationResponse{
+		Message: "impersonation ended",
+	})
+}
@@ -0,0 +1,298 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Get All Invitation
+//
+//	@Summary		Get All Invitation
+//	@Description	Get all invitation
+//	@Tags			Invitation
+//	@Produce		json
+//	@Success		200	{object}	schemas.InvitationListResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password[user:create]
+//	@Security		ApiKeyAuth
+//	@Router			/invitation/ [get]
+func GetAllInvitationRoute(c *fiber.Ctx) error {
+	invitations, err := repository.GetAllInvitation(models.DBConn)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	now := time.Now()
+	results := []schemas.InvitationDetailResponse{}
+	for _, item := range invitations {
+		results = append(results, invitationDetailResponse(item, now))
+	}
+	return c.Status(200).JSON(schemas.InvitationListResponse{
+		Results: results,
+	})
+}
+
+// Create Invitation
+//
+//	@Summary		Create Invitation
+//	@Description	invite email with optional pre-assigned role, invitation link sent to the email.
+//	@Description	non superuser could only pre-assign role which permissions already granted to them
+//	@Tags			Invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			invitation	body		schemas.InvitationCreateRequest	true	"Create Invitation"
+//	@Success		201			{object}	schemas.InvitationDetailResponse
+//	@Failure		400			{object}	schemas.BadRequestResponse
+//	@Failure		401			{object}	schemas.UnauthorizedResponse
+//	@Failure		403			{object}	schemas.ForbiddenResponse
+//	@Failure		422			{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500			{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password[user:create]
+//	@Security		ApiKeyAuth
+//	@Router			/invitation/ [post]
+func CreateInvitationRoute(c *fiber.Ctx) error {
+	// Inviter should user
+	principal := getPrincipal(c)
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could invite",
+		})
+	}
+
+	// validation
+	request := schemas.InvitationCreateRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+	if _, err := repository.GetUserByEmail(models.DBConn, request.Email); err == nil {
+		return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+			Message: []map[string]string{
+				{"email": "email already used"},
+			},
+		})
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Pre-assigned role should exist and not exceed inviter permission
+	var role *models.Role
+	if request.Role != "" {
+		existingRole, err := repository.GetRoleByName(models.DBConn, request.Role)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+					Message: []map[string]string{
+						{"role": "role not found"},
+					},
+				})
+			}
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		rolePermissions, err := repository.GetRolePermissions(models.DBConn, existingRole)
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		for _, permission := range rolePermissions {
+			if !principal.HasPermission(permission) {
+				return c.Status(403).JSON(schemas.ForbiddenResponse{
+					Message: "permission denied, could not assign role with " + permission,
+				})
+			}
+		}
+		role = &existingRole
+	}
+
+	// Create invitation and send it to invitee
+	now := time.Now()
+	invitation, rawToken, err := repository.CreateInvitation(models.DBConn, request.Email, principal.User, role, now)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.UserNotifier.Send(core.NewInvitationNotification(invitation.Email, principal.User, rawToken)); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(invitationDetailResponse(invitation, now))
+}
+
+// Revoke Invitation
+//
+//	@Summary		Revoke Invitation
+//	@Description	revoke pending invitation
+//	@Tags			Invitation
+//	@Param			id	path	string	true	"Invitation ID"
+//	@Success		204
+//	@Failure		400	{object}	schemas.BadRequestResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password[user:create]
+//	@Security		ApiKeyAuth
+//	@Router			/invitation/{id} [delete]
+func RevokeInvitationRoute(c *fiber.Ctx) error {
+	// Get Params
+	invitationId := c.Params("invitationId")
+	if !core.IsValidUUID(invitationId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "invitation not found",
+		})
+	}
+
+	invitation, err := repository.GetInvitationById(models.DBConn, invitationId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "invitation not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if _, err := repository.RevokeInvitation(models.DBConn, invitation, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrInvitationInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "invitation already accepted or revoked",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.SendStatus(204)
+}
+
+// Accept Invitation
+//
+//	@Summary		Accept Invitation
+//	@Description	accept invitation using token from invitation link, invitee become active user
+//	@Description	with the invited email (verified) and pre-assigned role
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.AcceptInvitationFormRequest	true	"form data"
+//	@Success		201		{object}	schemas.RegisterResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/auth/invitation/accept [post]
+func authAcceptInvitationRoute(c *fiber.Ctx) error {
+	// Get data from form
+	formRequest := schemas.AcceptInvitationFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+	invitation, err := repository.GetInvitationByToken(models.DBConn, formRequest.Token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired invitation",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	// email domain allow-list only for self registration, invitee chosen by admin
+	validation_errors, err = validateNewUser(formRequest.Username, invitation.Email)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	passwordErrors, err := validatePassword(formRequest.Password, formRequest.Username, invitation.Email, nil)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	validation_errors.Message = append(validation_errors.Message, passwordErrors...)
+	if len(validation_errors.Message) > 0 {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Accept invitation and create user
+	user, err := repository.AcceptInvitation(models.DBConn, formRequest.Token, formRequest.Username, formRequest.Password, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrInvitationInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired invitation",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(schemas.RegisterResponse{
+		Id:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	})
+}
+
+// invitationDetailResponse invitation status is pending, accepted, revoked or expired
+func invitationDetailResponse(invitation models.Invitation, now time.Time) schemas.InvitationDetailResponse {
+	status := "pending"
+	if invitation.AcceptedAt != nil {
+		status = "accepted"
+	} else if invitation.RevokedAt != nil {
+		status = "revoked"
+	} else if !now.Before(invitation.ExpiredAt) {
+		status = "expired"
+	}
+
+	response := schemas.InvitationDetailResponse{
+		Id:         invitation.ID,
+		Email:      invitation.Email,
+		Status:     status,
+		ExpiredAt:  invitation.ExpiredAt,
+		AcceptedAt: invitation.AcceptedAt,
+		RevokedAt:  invitation.RevokedAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+	if invitation.Role != nil {
+		response.Role = &invitation.Role.Name
+	}
+	if invitation.Inviter != nil {
+		response.InvitedBy = &invitation.Inviter.Username
+	}
+	return response
+}
@@ -0,0 +1,251 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateInvitationTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateInvitationTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+}
+
+func (suite *MigrateInvitationTestSuite) SetupTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateInvitationTestSuite) createUser(username string, isSuperuser bool) models.User {
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       username + "@test.com",
+		Username:    username,
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: isSuperuser,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	return user
+}
+
+func (suite *MigrateInvitationTestSuite) createRole(name string, permissionNames ...string) models.Role {
+	now := time.Now()
+	role, err := repository.CreateRole(models.DBConn, name, "", now)
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, permissionName := range permissionNames {
+		permission, err := repository.GetPermissionByName(models.DBConn, permissionName)
+		if err != nil {
+			panic(err.Error())
+		}
+		if err := repository.GrantPermissionToRole(models.DBConn, role, permission, now); err != nil {
+			panic(err.Error())
+		}
+	}
+	return role
+}
+
+func (suite *MigrateInvitationTestSuite) invite(token string, email string, role string) *http.Response {
+	requestJsonByte, _ := json.Marshal(schemas.InvitationCreateRequest{
+		Email: email,
+		Role:  role,
+	})
+	req, _ := http.NewRequest("POST", "/invitation/", bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func (suite *MigrateInvitationTestSuite) accept(rawToken string, username string) *http.Response {
+	var param = url.Values{}
+	param.Set("token", rawToken)
+	param.Set("username", username)
+	param.Set("password", "Newpassword")
+	req, _ := http.NewRequest("POST", "/auth/invitation/accept", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+// tokenFromNotification get raw token from invitation link on notification body
+func (suite *MigrateInvitationTestSuite) tokenFromNotification(notification core.Notification) string {
+	linkStart := strings.Index(notification.Body, settings.INVITATION_URL)
+	assert.NotEqual(suite.T(), -1, linkStart)
+	rawToken, err := url.QueryUnescape(strings.Fields(notification.Body[linkStart+len(settings.INVITATION_URL):])[0])
+	assert.Nil(suite.T(), err)
+	return rawToken
+}
+
+func (suite *MigrateInvitationTestSuite) TestCreateAndAcceptInvitation() {
+	// Given
+	request_user := suite.createUser("admin", true)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	suite.createRole("viewer", core.PermissionUserRead)
+	notifier := core.NewMemoryNotifier()
+	core.UserNotifier = notifier
+
+	// When
+	resp := suite.invite(token, "new@test.com", "viewer")
+
+	// Expect invitation link sent
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	jsonResponse := schemas.InvitationDetailResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "pending", jsonResponse.Status)
+	assert.Equal(suite.T(), "viewer", *jsonResponse.Role)
+	assert.Equal(suite.T(), "admin", *jsonResponse.InvitedBy)
+	notifications := notifier.Notifications("new@test.com")
+	assert.Len(suite.T(), notifications, 1)
+	rawToken := suite.tokenFromNotification(notifications[0])
+
+	// When accept
+	resp = suite.accept(rawToken, "new")
+
+	// Expect active user with pre-assigned role
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	user, err := repository.GetUserByUsername(models.DBConn, "new")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "new@test.com", user.Email)
+	assert.True(suite.T(), user.IsActive)
+	assert.False(suite.T(), user.IsSuperuser)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+	assert.True(suite.T(), core.CheckPasswordHash("Newpassword", user.Password))
+	permissions, err := repository.GetUserPermissions(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []string{core.PermissionUserRead}, permissions)
+
+	// Expect invitation single use
+	resp = suite.accept(rawToken, "other")
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When list invitation
+	req, _ := http.NewRequest("GET", "/invitation/", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	listResponse := schemas.InvitationListResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &listResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Len(suite.T(), listResponse.Results, 1)
+	assert.Equal(suite.T(), "accepted", listResponse.Results[0].Status)
+}
+
+func (suite *MigrateInvitationTestSuite) TestCreateInvitationRoleNotGranted() {
+	// Given inviter only has user:create
+	request_user := suite.createUser("inviter", false)
+	inviterRole := suite.createRole("inviter", core.PermissionUserCreate)
+	if err := repository.AssignRoleToUser(models.DBConn, request_user, inviterRole, time.Now()); err != nil {
+		panic(err.Error())
+	}
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	suite.createRole("deleter", core.PermissionUserDelete)
+
+	// When
+	resp := suite.invite(token, "new@test.com", "deleter")
+
+	// Expect
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When role within inviter permission
+	resp = suite.invite(token, "new@test.com", "inviter")
+
+	// Expect
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+}
+
+func (suite *MigrateInvitationTestSuite) TestRevokeInvitation() {
+	// Given
+	request_user := suite.createUser("admin", true)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	invitation, rawToken, err := repository.CreateInvitation(models.DBConn, "new@test.com", request_user, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ := http.NewRequest("DELETE", "/invitation/"+invitation.ID, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect revoked invitation could not be accepted
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+	resp = suite.accept(rawToken, "new")
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	_, err = repository.GetUserByUsername(models.DBConn, "new")
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *MigrateInvitationTestSuite) TestAcceptExpiredInvitation() {
+	// Given
+	request_user := suite.createUser("admin", true)
+	createdAt := time.Now().Add(-time.Minute * time.Duration(settings.INVITATION_EXPIRE_MINUTES+1))
+	_, rawToken, err := repository.CreateInvitation(models.DBConn, "new@test.com", request_user, nil, createdAt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	resp := suite.accept(rawToken, "new")
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateInvitationTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateInvitationTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateInvitationTestSuite))
+}
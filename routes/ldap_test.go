@@ -0,0 +1,182 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/ldaptest"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateLDAPTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+	server  *ldaptest.Server
+}
+
+func (suite *MigrateLDAPTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+
+	server, err := ldaptest.NewServer()
+	if err != nil {
+		panic(err.Error())
+	}
+	suite.server = server
+	settings.AUTHENTICATORS = "password ldap"
+	settings.LDAP_URL = server.URL()
+	settings.LDAP_BASE_DN = "ou=people,dc=example,dc=com"
+	settings.LDAP_GROUP_ROLES = "cn=admins,ou=groups,dc=example,dc=com:admin"
+}
+
+func (suite *MigrateLDAPTestSuite) SetupTest() {
+	models.ClearAllData()
+	suite.server.SetEntries(ldapEntry("alice", "alice@example.com", "cn=admins,ou=groups,dc=example,dc=com"))
+}
+
+func ldapEntry(uid string, mail string, groups ...string) ldaptest.Entry {
+	return ldaptest.Entry{
+		DN:       "uid=" + uid + ",ou=people,dc=example,dc=com",
+		Password: uid + "password",
+		Attributes: map[string][]string{
+			"uid":      {uid},
+			"mail":     {mail},
+			"memberOf": groups,
+		},
+	}
+}
+
+func (suite *MigrateLDAPTestSuite) login(username string, password string) *http.Response {
+	var param = url.Values{}
+	param.Set("username", username)
+	param.Set("password", password)
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func (suite *MigrateLDAPTestSuite) hasRole(user models.User, role models.Role) bool {
+	var count int64
+	models.DBConn.Model(&models.UserRole{}).Where("user_id = ? AND role_id = ?", user.ID, role.ID).Count(&count)
+	return count > 0
+}
+
+func (suite *MigrateLDAPTestSuite) TestLDAPLoginSyncUser() {
+	// Given
+	now := time.Now()
+	adminRole, err := repository.CreateRole(models.DBConn, "admin", "administrator", now)
+	assert.Nil(suite.T(), err)
+
+	// When
+	resp := suite.login("alice", "alicepassword")
+
+	// Expect user synced with role of the group and logged in
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	user, err := core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "alice", user.Username)
+	assert.Equal(suite.T(), "alice@example.com", user.Email)
+	assert.Equal(suite.T(), "uid=alice,ou=people,dc=example,dc=com", *user.LdapDn)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+	assert.False(suite.T(), user.IsSuperuser)
+	assert.True(suite.T(), suite.hasRole(user, adminRole))
+
+	// When removed from the group and login again
+	suite.server.SetEntries(ldapEntry("alice", "alice@example.com"))
+	resp = suite.login("alice", "alicepassword")
+
+	// Expect the same user logged in and mapped role unassigned
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	var count int64
+	models.DBConn.Model(&models.User{}).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+	assert.False(suite.T(), suite.hasRole(user, adminRole))
+
+	// When wrong password
+	resp = suite.login("alice", "wrongpassword")
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	user, err = repository.GetUserByUsername(models.DBConn, "alice")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, user.FailedLoginCount)
+}
+
+func (suite *MigrateLDAPTestSuite) TestLDAPLoginLocalUser() {
+	// Given local user with the same username as ldap entry
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, _ := core.HashPassword("localpassword")
+	localUser := models.User{
+		Email:       "alice@local.com",
+		Username:    "alice",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&localUser)
+
+	// When login with local password
+	resp := suite.login("alice", "localpassword")
+
+	// Expect local user still logged in by password authenticator
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// When login with ldap password
+	resp = suite.login("alice", "alicepassword")
+
+	// Expect local user not taken over by ldap entry
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	_, err = repository.GetUserByLdapDn(models.DBConn, "uid=alice,ou=people,dc=example,dc=com")
+	assert.NotNil(suite.T(), err)
+
+	// When only ldap authenticator
+	settings.AUTHENTICATORS = "ldap"
+	defer func() { settings.AUTHENTICATORS = "password ldap" }()
+	resp = suite.login("alice", "localpassword")
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateLDAPTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateLDAPTestSuite) TearDownSuite() {
+	suite.server.Close()
+	settings.AUTHENTICATORS = "password"
+}
+
+func TestMigrateLDAPTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateLDAPTestSuite))
+}
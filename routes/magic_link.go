@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// cookie keeping nonce binding magic link token to the device requested it
+const magicLinkNonceCookie = "magic_link_nonce"
+
+// Magic Link
+//
+//	@Summary		Magic Link
+//	@Description	send one time login link to user email, always respond 200 whether the email registered or not.
+//	@Description	the link only works on the device requested it (nonce kept on magic_link_nonce cookie),
+//	@Description	new link not sent to the same email within MAGIC_LINK_RESEND_SECONDS
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.MagicLinkFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.MagicLinkResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Failure		501		{object}	schemas.NotImplementedResponse
+//	@Router			/auth/magic-link [post]
+func authMagicLinkRoute(c *fiber.Ctx) error {
+	if !settings.MAGIC_LINK_ENABLED {
+		return c.Status(501).JSON(schemas.NotImplementedResponse{
+			Error: "magic link login not enabled",
+		})
+	}
+
+	// Get data from form
+	formRequest := schemas.MagicLinkFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Client ip locked
+	now := time.Now()
+	if retryAfter := core.IPLoginThrottle.RetryAfter(c.IP(), now); retryAfter > 0 {
+		return loginLockedResponse(c, loginLockedError{RetryAfter: retryAfter})
+	}
+
+	// Nonce cookie set for unknown email too, prevent email enumeration
+	nonce, err := core.GenerateSecureToken(32)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     magicLinkNonceCookie,
+		Value:    nonce,
+		Path:     "/auth/magic-link",
+		Expires:  now.Add(time.Minute * time.Duration(settings.MAGIC_LINK_TOKEN_EXPIRE_MINUTES)),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	response := schemas.MagicLinkResponse{
+		Message: "if the email registered, login link has been sent",
+	}
+	user, err := repository.GetUserByEmail(models.DBConn, formRequest.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// counted as failed login of client ip, the same as unknown username
+			core.IPLoginThrottle.RecordFailure(c.IP(), now)
+			return c.Status(200).JSON(response)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	// LDAP user only authenticated by LDAP
+	if !user.IsActive || user.LdapDn != nil {
+		return c.Status(200).JSON(response)
+	}
+
+	// Link recently sent to the email, not sent again so the endpoint could not flood the inbox,
+	// the sent link bound to the new nonce cookie instead
+	isRecentlySent, err := repository.RebindRecentMagicLinkToken(
+		models.DBConn, user.ID, nonce, now.Add(-time.Second*time.Duration(settings.MAGIC_LINK_RESEND_SECONDS)),
+	)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if isRecentlySent {
+		core.IPLoginThrottle.RecordFailure(c.IP(), now)
+		return c.Status(200).JSON(response)
+	}
+
+	// Create magic link token and send it to user
+	_, rawToken, err := repository.CreateMagicLinkToken(models.DBConn, user.ID, nonce, now)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.UserNotifier.Send(core.NewMagicLinkNotification(user, rawToken)); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(response)
+}
+
+// Magic Link Verify
+//
+//	@Summary		Magic Link Verify
+//	@Description	login using token from magic link, should be requested with magic_link_nonce cookie of the device requested the link.
+//	@Description	email of the user marked verified, user with two factor enabled get challenge token (202) to be exchanged on /auth/login/2fa
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			payload	formData	schemas.MagicLinkVerifyFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Success		202		{object}	schemas.TwoFactorChallengeResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Failure		501		{object}	schemas.NotImplementedResponse
+//	@Router			/auth/magic-link/verify [post]
+func authMagicLinkVerifyRoute(c *fiber.Ctx) error {
+	if !settings.MAGIC_LINK_ENABLED {
+		return c.Status(501).JSON(schemas.NotImplementedResponse{
+			Error: "magic link login not enabled",
+		})
+	}
+
+	// Get data from form
+	formRequest := schemas.MagicLinkVerifyFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	is_valid, validation_errors := core.ValidateSchemas(formRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	// Use token, only valid with nonce of the device requested it
+	nonce := c.Cookies(magicLinkNonceCookie)
+	if nonce == "" {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: repository.ErrMagicLinkTokenInvalid.Error(),
+		})
+	}
+	now := time.Now()
+	user, err := repository.UseMagicLinkToken(models.DBConn, formRequest.Token, nonce, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrMagicLinkTokenInvalid) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	c.ClearCookie(magicLinkNonceCookie)
+	if err := core.CheckUserStatus(user); err != nil {
+		return userInactiveResponse(c)
+	}
+
+	// User locked by failed login, magic link not bypass the lockout
+	if lockedUntil := user.LockedUntil; lockedUntil != nil && now.Before(*lockedUntil) {
+		return loginLockedResponse(c, loginLockedError{RetryAfter: lockedUntil.Sub(now)})
+	}
+
+	// Link opened from the email, so the email verified
+	if user.EmailVerifiedAt == nil {
+		user, err = repository.MarkUserEmailVerified(models.DBConn, user, now)
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
+	// Two factor enabled, exchange challenge token on /auth/login/2fa
+	if user.TotpEnabledAt != nil {
+		challengeToken, err := core.GenerateTwoFactorChallengeToken(user, "")
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(202).JSON(schemas.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         settings.TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES * 60,
+		})
+	}
+
+	// Generate JWT token and refresh token
+	loginResponse, _, err := generateLoginResponse(user, "", nil, core.GetSessionInfo(c))
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+)
+
+const principalLocalsKey = "principal"
+
+// requirePermission middleware authorize user or machine client from bearer token
+// and check the permission, authorized principal could be get using getPrincipal
+func requirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Authorize User or machine client
+		principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+		if err != nil {
+			return c.Status(401).JSON(schemas.UnauthorizedResponse{
+				Message: "Invalid/Expired token",
+			})
+		}
+
+		// Get user permission from roles, superuser has every permission
+		if principal.IsUser() && !principal.User.IsSuperuser {
+			principal.Permissions, err = repository.GetUserPermissions(models.DBConn, principal.User.ID)
+			if err != nil {
+				return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+					Error: err.Error(),
+				})
+			}
+		}
+
+		if !principal.HasPermission(permission) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: "permission denied, " + permission + " required",
+			})
+		}
+
+		c.Locals(principalLocalsKey, principal)
+		return c.Next()
+	}
+}
+
+// requireSuperuser middleware authorize superuser, scoped token of superuser is not allowed
+// authorized principal could be get using getPrincipal
+func requireSuperuser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Authorize User
+		principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+		if err != nil {
+			return c.Status(401).JSON(schemas.UnauthorizedResponse{
+				Message: "Invalid/Expired token",
+			})
+		}
+
+		if !principal.IsSuperuser() || principal.Scopes != nil {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: "permission denied, superuser required",
+			})
+		}
+
+		c.Locals(principalLocalsKey, principal)
+		return c.Next()
+	}
+}
+
+// getPrincipal get principal authorized by requirePermission or requireSuperuser
+func getPrincipal(c *fiber.Ctx) core.Principal {
+	principal, _ := c.Locals(principalLocalsKey).(core.Principal)
+	return principal
+}
+
+// impersonationAuditLog middleware log every request authorized with impersonation token
+// on core.AuditLogger, attributed to the superuser impersonating the user
+func impersonationAuditLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if impersonation, ok := core.GetImpersonation(c); ok {
+			core.AuditLogger.Printf(
+				"impersonated request actor=%s (%s) user=%s (%s) method=%s path=%s status=%d",
+				impersonation.ActorID, impersonation.ActorUsername, impersonation.UserID, impersonation.Username,
+				c.Method(), c.Path(), c.Response().StatusCode(),
+			)
+		}
+		return err
+	}
+}
+
+// refuseImpersonation middleware refuse impersonation token on route creating or changing long lived credential
+// (api key, two factor, passkey) so access of impersonating superuser stay time boxed,
+// and on route ending other session of the user (logout everywhere, terminate session)
+func refuseImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, err := core.GetTokenFromAuthorizationHeader(c)
+		if err == nil && core.IsImpersonationJWTToken(token) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: "not allowed while impersonating",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,458 @@
+package routes
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var errInvalidOAuthRequest = errors.New("invalid request")
+var errInvalidOAuthClient = errors.New("invalid client")
+
+// OAuth2 Authorize Page
+//
+//	@Summary		OAuth2 Authorize Page
+//	@Description	login page for oauth2 authorization code flow with PKCE (S256)
+//	@Tags			OAuth2
+//	@Produce		html
+//	@Param			response_type			query		string	true	"code"
+//	@Param			client_id				query		string	true	"client id"
+//	@Param			redirect_uri			query		string	false	"redirect uri, required if client has more than one redirect uri"
+//	@Param			scope					query		string	false	"space separated scope"
+//	@Param			state					query		string	false	"state"
+//	@Param			code_challenge			query		string	true	"PKCE code challenge"
+//	@Param			code_challenge_method	query		string	true	"S256"
+//	@Success		200
+//	@Failure		400	{object}	schemas.BadRequestResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Router			/oauth/authorize/ [get]
+func oauthAuthorizePageRoute(c *fiber.Ctx) error {
+	// Get Query Parameter
+	query := schemas.OAuthAuthorizeQuery{}
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	_, _, _, err := validateOAuthAuthorizeRequest(
+		query.ResponseType, query.ClientId, query.RedirectUri, query.Scope,
+		query.CodeChallenge, query.CodeChallengeMethod,
+	)
+	if err != nil {
+		if errors.Is(err, errInvalidOAuthRequest) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.SendFile(filepath.Join(settings.TEMPLATE_DIRECTORY, "login-ui.html"))
+}
+
+// OAuth2 Authorize
+//
+//	@Summary		OAuth2 Authorize
+//	@Description	login user and issue authorization code, redirect user to redirect_to
+//	@Tags			OAuth2
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.OAuthAuthorizeRequest	true	"authorize request"
+//	@Success		200		{object}	schemas.OAuthAuthorizeResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/oauth/authorize/ [post]
+func oauthAuthorizeRoute(c *fiber.Ctx) error {
+	// Get data from body
+	request := schemas.OAuthAuthorizeRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// validation
+	client, redirectUri, scope, err := validateOAuthAuthorizeRequest(
+		request.ResponseType, request.ClientId, request.RedirectUri, request.Scope,
+		request.CodeChallenge, request.CodeChallengeMethod,
+	)
+	if err != nil {
+		if errors.Is(err, errInvalidOAuthRequest) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: err.Error(),
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Get User and Check Password
+	user, err := authenticateUser(request.Username, request.Password, c.IP())
+	if err != nil {
+		if errors.Is(err, errInvalidCredentials) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "invalid credentials",
+			})
+		}
+		if errors.Is(err, core.ErrUserInactive) {
+			return userInactiveResponse(c)
+		}
+		if errors.Is(err, errLDAPUserConflict) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: err.Error(),
+			})
+		}
+		var lockedErr loginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(c, lockedErr)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Check two factor code
+	if user.TotpEnabledAt != nil {
+		if request.Otp == "" {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "two factor code required",
+			})
+		}
+		user, err = checkTwoFactorCode(user, request.Otp, c.IP())
+		if err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				return c.Status(400).JSON(schemas.BadRequestResponse{
+					Message: err.Error(),
+				})
+			}
+			var lockedErr loginLockedError
+			if errors.As(err, &lockedErr) {
+				return loginLockedResponse(c, lockedErr)
+			}
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
+	// Unverified email refused or limited scope
+	scope, err = core.ApplyEmailVerificationPolicy(user, scope)
+	if err != nil {
+		return emailNotVerifiedResponse(c)
+	}
+
+	// Generate authorization code
+	_, code, err := repository.CreateOAuthAuthorizationCode(
+		models.DBConn, client.ClientID, user.ID, redirectUri, scope,
+		request.CodeChallenge, request.CodeChallengeMethod, time.Now(),
+	)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	redirectTo, _ := url.Parse(redirectUri)
+	redirectQuery := redirectTo.Query()
+	redirectQuery.Set("code", code)
+	if request.State != "" {
+		redirectQuery.Set("state", request.State)
+	}
+	redirectTo.RawQuery = redirectQuery.Encode()
+
+	return c.Status(200).JSON(schemas.OAuthAuthorizeResponse{
+		RedirectTo: redirectTo.String(),
+	})
+}
+
+// OAuth2 Token
+//
+//	@Summary		OAuth2 Token
+//	@Description	exchange authorization code (authorization_code grant) or refresh token (refresh_token grant) with access token,
+//	@Description	machine client get access token for itself using client_credentials grant (no refresh token),
+//	@Description	confidential client authenticate using http basic auth or client_secret
+//	@Tags			OAuth2
+//	@Produce		json
+//	@Param			payload	formData	schemas.OAuthTokenFormRequest	true	"form data"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Failure		400		{object}	schemas.OAuthErrorResponse
+//	@Failure		401		{object}	schemas.OAuthErrorResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Router			/oauth/token [post]
+func oauthTokenRoute(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "no-store")
+
+	// Get data from form
+	formRequest := schemas.OAuthTokenFormRequest{}
+	if err := c.BodyParser(&formRequest); err != nil {
+		return c.Status(400).JSON(schemas.OAuthErrorResponse{
+			Error:            "invalid_request",
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	// Authenticate client
+	client, err := authenticateOAuthClient(c, formRequest)
+	if err != nil {
+		if errors.Is(err, errInvalidOAuthClient) {
+			return c.Status(401).JSON(schemas.OAuthErrorResponse{
+				Error:            "invalid_client",
+				ErrorDescription: "client authentication failed",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Client should allowed to use the grant type
+	grantType := []string{formRequest.GrantType}
+	if core.IsSubset(grantType, []string{"authorization_code", "refresh_token", "client_credentials"}) &&
+		!core.IsSubset(grantType, core.SplitSpaceSeparated(client.GrantTypes)) {
+		return c.Status(400).JSON(schemas.OAuthErrorResponse{
+			Error:            "unauthorized_client",
+			ErrorDescription: "client not allowed to use grant_type " + formRequest.GrantType,
+		})
+	}
+
+	switch formRequest.GrantType {
+	case "client_credentials":
+		return oauthClientCredentialsGrant(c, client, formRequest)
+	case "authorization_code":
+		return oauthAuthorizationCodeGrant(c, client, formRequest)
+	case "refresh_token":
+		loginResponse, err := refreshLoginResponse(formRequest.RefreshToken, &client.ClientID)
+		if err != nil {
+			if errors.Is(err, errInvalidRefreshToken) {
+				return c.Status(400).JSON(schemas.OAuthErrorResponse{
+					Error:            "invalid_grant",
+					ErrorDescription: "invalid/expired refresh token",
+				})
+			}
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(200).JSON(loginResponse)
+	default:
+		return c.Status(400).JSON(schemas.OAuthErrorResponse{
+			Error:            "unsupported_grant_type",
+			ErrorDescription: "grant_type should authorization_code, refresh_token or client_credentials",
+		})
+	}
+}
+
+func oauthClientCredentialsGrant(c *fiber.Ctx, client models.OAuthClient, formRequest schemas.OAuthTokenFormRequest) error {
+	// Only confidential client could authenticate itself
+	if client.ClientSecret == nil {
+		return c.Status(401).JSON(schemas.OAuthErrorResponse{
+			Error:            "invalid_client",
+			ErrorDescription: "client_credentials grant only for confidential client",
+		})
+	}
+
+	// Scope should allowed for client, default every client scope
+	clientScopes := core.SplitSpaceSeparated(client.Scopes)
+	requestedScopes := core.SplitSpaceSeparated(formRequest.Scope)
+	if len(requestedScopes) == 0 {
+		requestedScopes = clientScopes
+	}
+	if !core.IsSubset(requestedScopes, clientScopes) {
+		return c.Status(400).JSON(schemas.OAuthErrorResponse{
+			Error:            "invalid_scope",
+			ErrorDescription: "scope not allowed",
+		})
+	}
+	scope := strings.Join(requestedScopes, " ")
+
+	// Generate JWT token, subject is the client
+	token, err := core.GenerateJWTTokenFromClient(client, scope)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   settings.ACCESS_TOKEN_EXPIRE_MINUTES * 60,
+		Scope:       scope,
+	})
+}
+
+func oauthAuthorizationCodeGrant(c *fiber.Ctx, client models.OAuthClient, formRequest schemas.OAuthTokenFormRequest) error {
+	invalidGrantResponse := schemas.OAuthErrorResponse{
+		Error:            "invalid_grant",
+		ErrorDescription: "invalid/expired authorization code",
+	}
+
+	// Get authorization code
+	code, err := repository.GetOAuthAuthorizationCodeByCode(models.DBConn, formRequest.Code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(400).JSON(invalidGrantResponse)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Validate authorization code
+	now := time.Now()
+	if code.ClientID != client.ClientID ||
+		code.RedirectURI != formRequest.RedirectUri ||
+		now.After(code.ExpiredAt) ||
+		!core.VerifyCodeChallenge(formRequest.CodeVerifier, code.CodeChallenge, code.CodeChallengeMethod) {
+		return c.Status(400).JSON(invalidGrantResponse)
+	}
+
+	// Authorization code is single use, reused code revoke token issued by the code
+	if err := repository.UseOAuthAuthorizationCode(models.DBConn, code, now); err != nil {
+		if errors.Is(err, repository.ErrAuthorizationCodeUsed) {
+			if code.RefreshTokenFamilyID != nil {
+				if err := repository.RevokeRefreshTokenFamily(models.DBConn, *code.RefreshTokenFamilyID, now); err != nil {
+					return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+						Error: err.Error(),
+					})
+				}
+			}
+			return c.Status(400).JSON(invalidGrantResponse)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Get User
+	user, err := repository.GetUserById(models.DBConn, code.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(400).JSON(invalidGrantResponse)
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.CheckUserStatus(user); err != nil {
+		return c.Status(400).JSON(invalidGrantResponse)
+	}
+
+	// Generate JWT token and refresh token
+	loginResponse, refreshToken, err := generateLoginResponse(user, code.Scope, &client.ClientID, core.GetSessionInfo(c))
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := repository.SetOAuthAuthorizationCodeRefreshTokenFamily(models.DBConn, code, refreshToken.FamilyID); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
+}
+
+// validateOAuthAuthorizeRequest validate client, redirect uri, scope and PKCE of authorization request,
+// if redirectUri empty use the only registered redirect uri and if scope empty use every client scope
+// Return value (client, redirect_uri, scope, error)
+func validateOAuthAuthorizeRequest(responseType string, clientId string, redirectUri string, scope string, codeChallenge string, codeChallengeMethod string) (models.OAuthClient, string, string, error) {
+	if responseType != "code" {
+		return models.OAuthClient{}, "", "", fmt.Errorf("%w, response_type should code", errInvalidOAuthRequest)
+	}
+
+	// Get Client
+	client, err := repository.GetOAuthClientByClientId(models.DBConn, clientId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return client, "", "", fmt.Errorf("%w, client not found", errInvalidOAuthRequest)
+		}
+		return client, "", "", err
+	}
+
+	if !core.IsSubset([]string{"authorization_code"}, core.SplitSpaceSeparated(client.GrantTypes)) {
+		return client, "", "", fmt.Errorf("%w, client not allowed to use authorization_code grant", errInvalidOAuthRequest)
+	}
+
+	// Redirect uri should registered
+	registeredRedirectUris := core.SplitSpaceSeparated(client.RedirectURIs)
+	if redirectUri == "" && len(registeredRedirectUris) == 1 {
+		redirectUri = registeredRedirectUris[0]
+	}
+	if !core.IsSubset([]string{redirectUri}, registeredRedirectUris) {
+		return client, "", "", fmt.Errorf("%w, redirect_uri not registered", errInvalidOAuthRequest)
+	}
+
+	// Scope should allowed for client
+	clientScopes := core.SplitSpaceSeparated(client.Scopes)
+	requestedScopes := core.SplitSpaceSeparated(scope)
+	if len(requestedScopes) == 0 {
+		requestedScopes = clientScopes
+	}
+	// token without scope has full access of the user, never issued to third party client
+	if len(requestedScopes) == 0 {
+		return client, "", "", fmt.Errorf("%w, client has no scope", errInvalidOAuthRequest)
+	}
+	if !core.IsSubset(requestedScopes, clientScopes) {
+		return client, "", "", fmt.Errorf("%w, scope not allowed", errInvalidOAuthRequest)
+	}
+
+	// PKCE required
+	if codeChallenge == "" || codeChallengeMethod != "S256" {
+		return client, "", "", fmt.Errorf("%w, code_challenge with code_challenge_method S256 required", errInvalidOAuthRequest)
+	}
+
+	return client, redirectUri, strings.Join(requestedScopes, " "), nil
+}
+
+// authenticateOAuthClient get client from http basic auth or form,
+// confidential client should send valid client secret
+func authenticateOAuthClient(c *fiber.Ctx, formRequest schemas.OAuthTokenFormRequest) (models.OAuthClient, error) {
+	clientId := formRequest.ClientId
+	clientSecret := formRequest.ClientSecret
+	if authHeader := c.Get("Authorization"); strings.HasPrefix(authHeader, "Basic ") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authHeader, "Basic "))
+		if err != nil {
+			return models.OAuthClient{}, errInvalidOAuthClient
+		}
+		credentials := strings.SplitN(string(decoded), ":", 2)
+		if len(credentials) != 2 {
+			return models.OAuthClient{}, errInvalidOAuthClient
+		}
+		clientId, _ = url.QueryUnescape(credentials[0])
+		clientSecret, _ = url.QueryUnescape(credentials[1])
+	}
+
+	client, err := repository.GetOAuthClientByClientId(models.DBConn, clientId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return client, errInvalidOAuthClient
+		}
+		return client, err
+	}
+
+	if client.ClientSecret != nil && !core.CheckTokenHash(clientSecret, *client.ClientSecret) {
+		return client, errInvalidOAuthClient
+	}
+	return client, nil
+}
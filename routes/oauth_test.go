@@ -0,0 +1,402 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateOAuthTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateOAuthTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	settings.TEMPLATE_DIRECTORY = "../templates"
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5 // second
+}
+
+func (suite *MigrateOAuthTestSuite) SetupTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateOAuthTestSuite) createUserAndClient() (models.User, models.OAuthClient, string) {
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	client, clientSecret, err := repository.CreateOAuthClient(
+		models.DBConn, "test client", []string{"http://localhost:3000/callback"},
+		[]string{"user:read"}, []string{"authorization_code", "refresh_token"}, true, time.Now(),
+	)
+	if err != nil {
+		panic(err.Error())
+	}
+	return user, client, clientSecret
+}
+
+func (suite *MigrateOAuthTestSuite) authorize(clientId string, codeChallenge string) string {
+	payload, _ := json.Marshal(schemas.OAuthAuthorizeRequest{
+		Username:            "test",
+		Password:            "Fakepassword",
+		ResponseType:        "code",
+		ClientId:            clientId,
+		State:               "xyz",
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: "S256",
+	})
+	req, _ := http.NewRequest("POST", "/oauth/authorize/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	jsonResponse := schemas.OAuthAuthorizeResponse{}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	redirectTo, err := url.Parse(jsonResponse.RedirectTo)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "localhost:3000", redirectTo.Host)
+	assert.Equal(suite.T(), "xyz", redirectTo.Query().Get("state"))
+	return redirectTo.Query().Get("code")
+}
+
+func (suite *MigrateOAuthTestSuite) exchangeCode(clientId string, clientSecret string, code string, codeVerifier string) *http.Response {
+	var param = url.Values{}
+	param.Set("grant_type", "authorization_code")
+	param.Set("code", code)
+	param.Set("redirect_uri", "http://localhost:3000/callback")
+	param.Set("code_verifier", codeVerifier)
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/oauth/token", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientId, clientSecret)
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func (suite *MigrateOAuthTestSuite) TestAuthorizePage() {
+	// Given
+	_, client, _ := suite.createUserAndClient()
+
+	// When
+	var param = url.Values{}
+	param.Set("response_type", "code")
+	param.Set("client_id", client.ClientID)
+	param.Set("code_challenge", core.GenerateCodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	param.Set("code_challenge_method", "S256")
+	req, _ := http.NewRequest("GET", "/oauth/authorize/?"+param.Encode(), nil)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// When without PKCE
+	param.Del("code_challenge")
+	req, _ = http.NewRequest("GET", "/oauth/authorize/?"+param.Encode(), nil)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When client registered without scope
+	noScopeClient, _, err := repository.CreateOAuthClient(
+		models.DBConn, "no scope", []string{"http://localhost:3000/callback"},
+		[]string{}, []string{"authorization_code", "refresh_token"}, true, time.Now(),
+	)
+	if err != nil {
+		panic(err.Error())
+	}
+	param.Set("client_id", noScopeClient.ClientID)
+	param.Set("code_challenge", core.GenerateCodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	req, _ = http.NewRequest("GET", "/oauth/authorize/?"+param.Encode(), nil)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect no unscoped token issued
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateOAuthTestSuite) TestAuthorizationCodeFlow() {
+	// Given
+	user, client, clientSecret := suite.createUserAndClient()
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	code := suite.authorize(client.ClientID, core.GenerateCodeChallenge(codeVerifier))
+
+	// When
+	resp := suite.exchangeCode(client.ClientID, clientSecret, code, codeVerifier)
+
+	// Expect
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	assert.Equal(suite.T(), "no-store", resp.Header.Get("Cache-Control"))
+	jsonResponse := schemas.LoginResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEqual(suite.T(), "", jsonResponse.RefreshToken)
+	tokenUser, err := core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.ID, tokenUser.ID)
+}
+
+func (suite *MigrateOAuthTestSuite) TestAuthorizationCodeWrongVerifier() {
+	// Given
+	_, client, clientSecret := suite.createUserAndClient()
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	code := suite.authorize(client.ClientID, core.GenerateCodeChallenge(codeVerifier))
+
+	// When
+	resp := suite.exchangeCode(client.ClientID, clientSecret, code, "wrong-verifier-wrong-verifier-wrong-verifier")
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	jsonResponse := schemas.OAuthErrorResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "invalid_grant", jsonResponse.Error)
+}
+
+func (suite *MigrateOAuthTestSuite) TestAuthorizationCodeReused() {
+	// Given
+	_, client, clientSecret := suite.createUserAndClient()
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	code := suite.authorize(client.ClientID, core.GenerateCodeChallenge(codeVerifier))
+	resp := suite.exchangeCode(client.ClientID, clientSecret, code, codeVerifier)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(body, &jsonResponse)
+
+	// When
+	resp = suite.exchangeCode(client.ClientID, clientSecret, code, codeVerifier)
+
+	// Expect code rejected and refresh token issued by the code revoked
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	refreshToken, err := repository.GetRefreshTokenByToken(models.DBConn, jsonResponse.RefreshToken)
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), refreshToken.RevokedAt)
+}
+
+func (suite *MigrateOAuthTestSuite) TestTokenInvalidClient() {
+	// Given
+	_, client, _ := suite.createUserAndClient()
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	code := suite.authorize(client.ClientID, core.GenerateCodeChallenge(codeVerifier))
+
+	// When
+	resp := suite.exchangeCode(client.ClientID, "wrong secret", code, codeVerifier)
+
+	// Expect
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+}
+
+func (suite *MigrateOAuthTestSuite) TestClientCredentials() {
+	// Given
+	client, clientSecret, err := repository.CreateOAuthClient(
+		models.DBConn, "batch job", []string{}, []string{"user:read"},
+		[]string{"client_credentials"}, true, time.Now(),
+	)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	var param = url.Values{}
+	param.Set("grant_type", "client_credentials")
+	param.Set("client_id", client.ClientID)
+	param.Set("client_secret", clientSecret)
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/oauth/token", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "", jsonResponse.RefreshToken)
+	assert.Equal(suite.T(), "user:read", jsonResponse.Scope)
+	principal, err := core.GetPrincipalFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), principal.IsClient())
+	assert.Equal(suite.T(), client.ClientID, principal.Client.ClientID)
+
+	// Expect client allowed to read user
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("Authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// Expect client without scope user:delete forbidden
+	req, _ = http.NewRequest("DELETE", "/user/"+client.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// Expect client is not a user
+	req, _ = http.NewRequest("POST", "/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+}
+
+func (suite *MigrateOAuthTestSuite) TestClientCredentialsNotAllowed() {
+	// Given client without client_credentials grant
+	_, client, clientSecret := suite.createUserAndClient()
+
+	// When
+	var param = url.Values{}
+	param.Set("grant_type", "client_credentials")
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/oauth/token", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ClientID, clientSecret)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	jsonResponse := schemas.OAuthErrorResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), "unauthorized_client", jsonResponse.Error)
+}
+
+func (suite *MigrateOAuthTestSuite) refreshToken(clientId string, refreshToken string) *http.Response {
+	var param = url.Values{}
+	param.Set("grant_type", "refresh_token")
+	param.Set("client_id", clientId)
+	param.Set("refresh_token", refreshToken)
+	var payload = bytes.NewBufferString(param.Encode())
+	req, _ := http.NewRequest("POST", "/oauth/token", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func (suite *MigrateOAuthTestSuite) TestRefreshTokenBoundToClient() {
+	// Given refresh token issued to public client and other public client
+	user, _, _ := suite.createUserAndClient()
+	client, _, err := repository.CreateOAuthClient(
+		models.DBConn, "spa", []string{"http://localhost:3000/callback"},
+		[]string{"user:read"}, []string{"authorization_code", "refresh_token"}, false, time.Now(),
+	)
+	if err != nil {
+		panic(err.Error())
+	}
+	otherClient, _, err := repository.CreateOAuthClient(
+		models.DBConn, "other spa", []string{"http://localhost:4000/callback"},
+		[]string{"user:read"}, []string{"refresh_token"}, false, time.Now(),
+	)
+	if err != nil {
+		panic(err.Error())
+	}
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	code := suite.authorize(client.ClientID, core.GenerateCodeChallenge(codeVerifier))
+	resp := suite.exchangeCode(client.ClientID, "", code, codeVerifier)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	loginResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(body, &loginResponse)
+
+	// When redeemed by other client
+	resp = suite.refreshToken(otherClient.ClientID, loginResponse.RefreshToken)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+	jsonResponse := schemas.OAuthErrorResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	json.Unmarshal(body, &jsonResponse)
+	assert.Equal(suite.T(), "invalid_grant", jsonResponse.Error)
+
+	// Expect refresh token not redeemed on /auth/refresh
+	var param = url.Values{}
+	param.Set("refresh_token", loginResponse.RefreshToken)
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// Expect refresh token redeemed by the client
+	resp = suite.refreshToken(client.ClientID, loginResponse.RefreshToken)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// Expect refresh token of /auth login not redeemed by client
+	_, rawRefreshToken, err := repository.CreateRefreshToken(models.DBConn, user.ID, nil, nil, "", time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	resp = suite.refreshToken(client.ClientID, rawRefreshToken)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateOAuthTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateOAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateOAuthTestSuite))
+}
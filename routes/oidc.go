@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// cookie keeping oidc login session token between login and callback
+const oidcSessionCookie = "oidc_session"
+
+var errOIDCUserNotRegistered = errors.New("user not registered")
+var errOIDCLDAPUser = errors.New("ldap user could not login with oidc")
+
+// OIDC Login
+//
+//	@Summary		OIDC Login
+//	@Description	start OpenID Connect login, redirect user to identity provider (OIDC_ISSUER)
+//	@Description	then the provider redirect user back to /auth/oidc/callback
+//	@Tags			Auth
+//	@Success		302
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Failure		501	{object}	schemas.NotImplementedResponse
+//	@Router			/auth/oidc/login [get]
+func authOIDCLoginRoute(c *fiber.Ctx) error {
+	if !core.IsOIDCEnabled() {
+		return c.Status(501).JSON(schemas.NotImplementedResponse{
+			Error: "oidc login not configured",
+		})
+	}
+
+	authorizationUrl, sessionToken, err := core.BeginOIDCLogin()
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// session bound to the browser, Lax so the cookie sent on redirect back from provider
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcSessionCookie,
+		Value:    sessionToken,
+		Path:     "/auth/oidc",
+		Expires:  time.Now().Add(time.Minute * time.Duration(settings.OIDC_SESSION_EXPIRE_MINUTES)),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(authorizationUrl, 302)
+}
+
+// OIDC Callback
+//
+//	@Summary		OIDC Callback
+//	@Description	finish OpenID Connect login, verify ID token from identity provider and login user with the same email.
+//	@Description	user created on first login if OIDC_CREATE_USER enabled
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			code	query		string	true	"authorization code"
+//	@Param			state	query		string	true	"state"
+//	@Success		200		{object}	schemas.LoginResponse
+//	@Success		202		{object}	schemas.TwoFactorChallengeResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		429		{object}	schemas.TooManyRequestsResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Failure		501		{object}	schemas.NotImplementedResponse
+//	@Router			/auth/oidc/callback [get]
+func authOIDCCallbackRoute(c *fiber.Ctx) error {
+	if !core.IsOIDCEnabled() {
+		return c.Status(501).JSON(schemas.NotImplementedResponse{
+			Error: "oidc login not configured",
+		})
+	}
+
+	// Get Query Parameter
+	query := schemas.OIDCCallbackQuery{}
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	sessionToken := c.Cookies(oidcSessionCookie)
+	c.ClearCookie(oidcSessionCookie)
+	if query.Error != "" {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: strings.TrimSpace("oidc login failed: " + query.Error + " " + query.ErrorDescription),
+		})
+	}
+
+	// Verify ID token
+	claims, err := core.FinishOIDCLogin(sessionToken, query.State, query.Code)
+	if err != nil {
+		if errors.Is(err, core.ErrOIDCInvalidSession) {
+			return c.Status(400).JSON(schemas.BadRequestResponse{
+				Message: "Invalid/Expired oidc login session",
+			})
+		}
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "oidc login failed: " + err.Error(),
+		})
+	}
+
+	// Link by email, email should be verified by provider
+	if claims.Email == "" || !claims.EmailVerified {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "email not verified by identity provider",
+		})
+	}
+	now := time.Now()
+	user, err := getOrCreateOIDCUser(claims, now)
+	if err != nil {
+		if errors.Is(err, errOIDCUserNotRegistered) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: "user not registered",
+			})
+		}
+		if errors.Is(err, errOIDCLDAPUser) {
+			return c.Status(403).JSON(schemas.ForbiddenResponse{
+				Message: err.Error(),
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.CheckUserStatus(user); err != nil {
+		return userInactiveResponse(c)
+	}
+
+	// User locked by failed login, identity provider not bypass the lockout
+	if lockedUntil := user.LockedUntil; lockedUntil != nil && now.Before(*lockedUntil) {
+		return loginLockedResponse(c, loginLockedError{RetryAfter: lockedUntil.Sub(now)})
+	}
+
+	// Two factor enabled, exchange challenge token on /auth/login/2fa
+	if user.TotpEnabledAt != nil {
+		challengeToken, err := core.GenerateTwoFactorChallengeToken(user, "")
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(202).JSON(schemas.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         settings.TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES * 60,
+		})
+	}
+
+	// Generate JWT token and refresh token
+	loginResponse, _, err := generateLoginResponse(user, "", nil, core.GetSessionInfo(c))
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(loginResponse)
+}
+
+// getOrCreateOIDCUser get user with email of verified ID token or create active non superuser
+// (when OIDC_CREATE_USER enabled) with unusable random password.
+// Email marked verified since verified by identity provider.
+// return errOIDCUserNotRegistered if user not found and OIDC_CREATE_USER disabled,
+// errOIDCLDAPUser if user synced from LDAP (only authenticated by LDAP)
+func getOrCreateOIDCUser(claims core.OIDCClaims, now time.Time) (models.User, error) {
+	user, err := repository.GetUserByEmail(models.DBConn, claims.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	if err == nil && user.LdapDn != nil {
+		return user, errOIDCLDAPUser
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !settings.OIDC_CREATE_USER {
+			return user, errOIDCUserNotRegistered
+		}
+		username, err := availableOIDCUsername(claims)
+		if err != nil {
+			return user, err
+		}
+		password, err := core.GenerateSecureToken(32)
+		if err != nil {
+			return user, err
+		}
+		user, err = repository.CreateUser(models.DBConn, username, claims.Email, password, true, false, now, &now)
+		if err != nil {
+			return user, err
+		}
+	}
+	if user.EmailVerifiedAt == nil {
+		return repository.MarkUserEmailVerified(models.DBConn, user, now)
+	}
+	return user, nil
+}
+
+// availableOIDCUsername username for new oidc user from preferred_username or email name,
+// numbered suffix added if the username already used
+func availableOIDCUsername(claims core.OIDCClaims) (string, error) {
+	username := claims.PreferredUsername
+	if username == "" {
+		username = strings.Split(claims.Email, "@")[0]
+	}
+	candidate := username
+	for i := 2; ; i++ {
+		isUsed, err := repository.IsUsernameUsed(models.DBConn, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !isUsed {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", username, i)
+	}
+}
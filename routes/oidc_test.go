@@ -0,0 +1,327 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/oidctest"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateOIDCTestSuite struct {
+	suite.Suite
+	app      *fiber.App
+	timeout  int
+	provider *oidctest.Provider
+}
+
+func (suite *MigrateOIDCTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+
+	provider, err := oidctest.NewProvider("fiber-gorm-boilerplate", "oidc-secret")
+	if err != nil {
+		panic(err.Error())
+	}
+	suite.provider = provider
+	settings.OIDC_ISSUER = provider.Issuer()
+	settings.OIDC_CLIENT_ID = provider.ClientID
+	settings.OIDC_CLIENT_SECRET = provider.ClientSecret
+	settings.OIDC_REDIRECT_URL = "http://localhost/auth/oidc/callback"
+}
+
+func (suite *MigrateOIDCTestSuite) SetupTest() {
+	models.ClearAllData()
+}
+
+// beginLogin start oidc login, return authorization url and session cookie
+func (suite *MigrateOIDCTestSuite) beginLogin() (string, *http.Cookie) {
+	req, _ := http.NewRequest("GET", "/auth/oidc/login", nil)
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 302, resp.StatusCode)
+	var sessionCookie *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "oidc_session" {
+			sessionCookie = cookie
+		}
+	}
+	assert.NotNil(suite.T(), sessionCookie)
+	return resp.Header.Get("Location"), sessionCookie
+}
+
+// callback call oidc callback as browser redirected back from provider
+func (suite *MigrateOIDCTestSuite) callback(code string, state string, sessionCookie *http.Cookie) *http.Response {
+	query := url.Values{}
+	query.Set("code", code)
+	query.Set("state", state)
+	req, _ := http.NewRequest("GET", "/auth/oidc/callback?"+query.Encode(), nil)
+	if sessionCookie != nil {
+		req.AddCookie(sessionCookie)
+	}
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func stateOf(authorizationUrl string) string {
+	parsedUrl, err := url.Parse(authorizationUrl)
+	if err != nil {
+		panic(err.Error())
+	}
+	return parsedUrl.Query().Get("state")
+}
+
+func (suite *MigrateOIDCTestSuite) TestOIDCLoginCreateUser() {
+	// Given
+	identity := oidctest.Identity{
+		Subject:           "oidc-subject-1",
+		Email:             "oidc@test.com",
+		EmailVerified:     true,
+		PreferredUsername: "oidc",
+	}
+	authorizationUrl, sessionCookie := suite.beginLogin()
+	assert.Contains(suite.T(), authorizationUrl, suite.provider.Issuer()+"/authorize?")
+	code, err := suite.provider.Authorize(authorizationUrl, identity)
+	assert.Nil(suite.T(), err)
+
+	// When
+	resp := suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect user created with verified email and logged in
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	user, err := core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "oidc", user.Username)
+	assert.Equal(suite.T(), "oidc@test.com", user.Email)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+	assert.False(suite.T(), user.IsSuperuser)
+
+	// When session reused
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateOIDCTestSuite) TestOIDCLoginLinkUser() {
+	// Given user registered with the same email (case insensitive) and username of preferred_username
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	existingUser := models.User{
+		Email:       "Oidc@Test.com",
+		Username:    "existing",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&existingUser)
+	authorizationUrl, sessionCookie := suite.beginLogin()
+	code, err := suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+
+	// When
+	resp := suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect logged in as existing user
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	user, err := core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), existingUser.ID, user.ID)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+
+	// When new user preferred_username already used
+	authorizationUrl, sessionCookie = suite.beginLogin()
+	code, err = suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:           "oidc-subject-2",
+		Email:             "other@test.com",
+		EmailVerified:     true,
+		PreferredUsername: "existing",
+	})
+	assert.Nil(suite.T(), err)
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect numbered username
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	createdUser, err := repository.GetUserByEmail(models.DBConn, "other@test.com")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "existing2", createdUser.Username)
+}
+
+func (suite *MigrateOIDCTestSuite) TestOIDCLoginTwoFactorAndLocked() {
+	// Given user registered with two factor enabled
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	now := time.Now()
+	existingUser := models.User{
+		Email:         "oidc@test.com",
+		Username:      "existing",
+		Password:      "Fakepassword",
+		IsActive:      true,
+		IsSuperuser:   false,
+		TotpEnabledAt: &now,
+		CreatedAt:     time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&existingUser)
+	authorizationUrl, sessionCookie := suite.beginLogin()
+	code, err := suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+
+	// When
+	resp := suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect challenge token instead of login
+	assert.Equal(suite.T(), 202, resp.StatusCode)
+	jsonResponse := schemas.TwoFactorChallengeResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.True(suite.T(), jsonResponse.TwoFactorRequired)
+	assert.NotEmpty(suite.T(), jsonResponse.ChallengeToken)
+
+	// When user locked by failed login
+	models.DBConn.Model(&models.User{}).Where("id = ?", existingUser.ID).Update("locked_until", time.Now().Add(time.Minute))
+	authorizationUrl, sessionCookie = suite.beginLogin()
+	code, err = suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 429, resp.StatusCode)
+	assert.NotEmpty(suite.T(), resp.Header.Get("Retry-After"))
+}
+
+func (suite *MigrateOIDCTestSuite) TestOIDCLoginRefused() {
+	// When state not match session
+	authorizationUrl, sessionCookie := suite.beginLogin()
+	code, err := suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+	resp := suite.callback(code, "other-state", sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When without session cookie
+	resp = suite.callback(code, stateOf(authorizationUrl), nil)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When email not verified by provider
+	authorizationUrl, sessionCookie = suite.beginLogin()
+	code, err = suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: false,
+	})
+	assert.Nil(suite.T(), err)
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When user not registered and user creation disabled
+	settings.OIDC_CREATE_USER = false
+	defer func() { settings.OIDC_CREATE_USER = true }()
+	authorizationUrl, sessionCookie = suite.beginLogin()
+	code, err = suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-1",
+		Email:         "oidc@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	_, err = repository.GetUserByEmail(models.DBConn, "oidc@test.com")
+	assert.NotNil(suite.T(), err)
+
+	// When user with the same email synced from LDAP
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	ldapDn := "uid=ldap,ou=people,dc=example,dc=com"
+	ldapUser := models.User{
+		Email:       "ldap@test.com",
+		Username:    "ldap",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		LdapDn:      &ldapDn,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&ldapUser)
+	authorizationUrl, sessionCookie = suite.beginLogin()
+	code, err = suite.provider.Authorize(authorizationUrl, oidctest.Identity{
+		Subject:       "oidc-subject-2",
+		Email:         "ldap@test.com",
+		EmailVerified: true,
+	})
+	assert.Nil(suite.T(), err)
+	resp = suite.callback(code, stateOf(authorizationUrl), sessionCookie)
+
+	// Expect
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+}
+
+func (suite *MigrateOIDCTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateOIDCTestSuite) TearDownSuite() {
+	suite.provider.Close()
+	settings.OIDC_ISSUER = ""
+}
+
+func TestMigrateOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateOIDCTestSuite))
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -8,16 +9,69 @@ import (
 // this way every group of routes can be defined in their own file
 // so this one won't be so messy
 func InitiateRoutes(app *fiber.App) *fiber.App {
+	app.Use(impersonationAuditLog())
+	app.Get("/.well-known/jwks.json", wellKnownJWKSRoute)
+
 	authRoutes := app.Group("/auth")
 	authRoutes.Post("/login", authLoginRoute)
+	authRoutes.Post("/login/2fa", authLoginTwoFactorRoute)
+	authRoutes.Post("/webauthn/register/begin", refuseImpersonation(), authWebAuthnRegisterBeginRoute)
+	authRoutes.Post("/webauthn/register/finish", refuseImpersonation(), authWebAuthnRegisterFinishRoute)
+	authRoutes.Post("/webauthn/login/begin", authWebAuthnLoginBeginRoute)
+	authRoutes.Post("/webauthn/login/finish", authWebAuthnLoginFinishRoute)
+	authRoutes.Post("/refresh", authRefreshRoute)
 	authRoutes.Post("/logout", authLogoutRoute)
+	authRoutes.Post("/logout-all", refuseImpersonation(), authLogoutAllRoute)
+	authRoutes.Post("/register", authRegisterRoute)
+	authRoutes.Post("/invitation/accept", authAcceptInvitationRoute)
+	authRoutes.Post("/password/forgot", authForgotPasswordRoute)
+	authRoutes.Post("/password/reset", authResetPasswordRoute)
+	authRoutes.Post("/magic-link", authMagicLinkRoute)
+	authRoutes.Post("/magic-link/verify", authMagicLinkVerifyRoute)
+	authRoutes.Post("/verify-email", authVerifyEmailRoute)
+	authRoutes.Post("/verify-email/resend", authResendEmailVerificationRoute)
+	authRoutes.Get("/oidc/login", authOIDCLoginRoute)
+	authRoutes.Get("/oidc/callback", authOIDCCallbackRoute)
+
+	oauthRoutes := app.Group("/oauth")
+	oauthRoutes.Get("/authorize/", oauthAuthorizePageRoute)
+	oauthRoutes.Post("/authorize/", oauthAuthorizeRoute)
+	oauthRoutes.Post("/token", oauthTokenRoute)
 
 	userRoutes := app.Group("/user")
-	userRoutes.Get("/", GetAllUserRoute)
-	userRoutes.Get("/:userId", GetDetailUserRoute)
-	userRoutes.Post("/", CreateUserRoute)
-	userRoutes.Put("/:userId", UpdateUserRoute)
-	userRoutes.Delete("/:userId", DeleteUserRoute)
+	// /user/me routes should registered before /user/:userId
+	userRoutes.Get("/me/api-keys", GetAllApiKeyRoute)
+	userRoutes.Get("/me/api-keys/:apiKeyId", GetDetailApiKeyRoute)
+	userRoutes.Post("/me/api-keys", refuseImpersonation(), CreateApiKeyRoute)
+	userRoutes.Put("/me/api-keys/:apiKeyId", refuseImpersonation(), UpdateApiKeyRoute)
+	userRoutes.Delete("/me/api-keys/:apiKeyId", refuseImpersonation(), DeleteApiKeyRoute)
+	userRoutes.Get("/me/2fa", GetTwoFactorRoute)
+	userRoutes.Post("/me/2fa/enroll", refuseImpersonation(), EnrollTwoFactorRoute)
+	userRoutes.Post("/me/2fa/confirm", refuseImpersonation(), ConfirmTwoFactorRoute)
+	userRoutes.Get("/me/webauthn-credentials", GetAllWebAuthnCredentialRoute)
+	userRoutes.Put("/me/webauthn-credentials/:credentialId", refuseImpersonation(), UpdateWebAuthnCredentialRoute)
+	userRoutes.Delete("/me/webauthn-credentials/:credentialId", refuseImpersonation(), DeleteWebAuthnCredentialRoute)
+	userRoutes.Post("/me/impersonate/end", EndImpersonationRoute)
+	userRoutes.Get("/me/sessions", GetAllSessionRoute)
+	userRoutes.Delete("/me/sessions/:sessionId", refuseImpersonation(), DeleteSessionRoute)
+	userRoutes.Get("/", requirePermission(core.PermissionUserRead), GetAllUserRoute)
+	userRoutes.Get("/:userId", requirePermission(core.PermissionUserRead), GetDetailUserRoute)
+	userRoutes.Post("/", requirePermission(core.PermissionUserCreate), CreateUserRoute)
+	userRoutes.Put("/:userId", requirePermission(core.PermissionUserUpdate), UpdateUserRoute)
+	userRoutes.Delete("/:userId", requirePermission(core.PermissionUserDelete), DeleteUserRoute)
+	userRoutes.Post("/:userId/deactivate", requirePermission(core.PermissionUserUpdate), DeactivateUserRoute)
+	userRoutes.Post("/:userId/activate", requirePermission(core.PermissionUserUpdate), ActivateUserRoute)
+	userRoutes.Get("/:userId/lockout", requireSuperuser(), GetUserLockoutRoute)
+	userRoutes.Delete("/:userId/lockout", requireSuperuser(), ClearUserLockoutRoute)
+	userRoutes.Delete("/:userId/2fa", requireSuperuser(), ResetUserTwoFactorRoute)
+	userRoutes.Post("/:userId/impersonate", requireSuperuser(), ImpersonateUserRoute)
+	userRoutes.Get("/:userId/sessions", requireSuperuser(), GetAllUserSessionRoute)
+	userRoutes.Delete("/:userId/sessions/:sessionId", requireSuperuser(), DeleteUserSessionRoute)
+
+	invitationRoutes := app.Group("/invitation")
+	invitationRoutes.Get("/", requirePermission(core.PermissionUserCreate), GetAllInvitationRoute)
+	invitationRoutes.Post("/", requirePermission(core.PermissionUserCreate), CreateInvitationRoute)
+	invitationRoutes.Delete("/:invitationId", requirePermission(core.PermissionUserCreate), RevokeInvitationRoute)
 
 	return app
 }
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Get All Session
+//
+//	@Summary		Get All Session
+//	@Description	Get active login session of current user, session of the access token marked current
+//	@Tags			Session
+//	@Produce		json
+//	@Success		200	{object}	schemas.SessionListResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/sessions [get]
+func GetAllSessionRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage session",
+		})
+	}
+	user := principal.User
+
+	// Session of the request, api key has no session
+	currentSessionId := ""
+	if token, err := core.GetTokenFromAuthorizationHeader(c); err == nil {
+		currentSessionId = core.GetSessionIdFromJWTToken(token)
+	}
+
+	return sessionListResponse(c, user.ID, currentSessionId)
+}
+
+// Delete Session
+//
+//	@Summary		Delete Session
+//	@Description	Terminate login session of current user, refresh token and access token of the session no longer accepted
+//	@Tags			Session
+//	@Param			sessionId	path	string	true	"Session ID"
+//	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/sessions/{sessionId} [delete]
+func DeleteSessionRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token not allowed since scope never cover session
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped token could terminate session",
+		})
+	}
+	user := principal.User
+
+	return terminateSession(c, user.ID)
+}
+
+// Get All User Session
+//
+//	@Summary		Get All User Session
+//	@Description	Get active login session of user, superuser only
+//	@Tags			Session
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	schemas.SessionListResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/sessions [get]
+func GetAllUserSessionRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return sessionListResponse(c, user.ID, "")
+}
+
+// Delete User Session
+//
+//	@Summary		Delete User Session
+//	@Description	Terminate login session of user, superuser only
+//	@Tags			Session
+//	@Param			id			path	string	true	"User ID"
+//	@Param			sessionId	path	string	true	"Session ID"
+//	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/sessions/{sessionId} [delete]
+func DeleteUserSessionRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return terminateSession(c, user.ID)
+}
+
+// sessionListResponse respond active session of the user
+func sessionListResponse(c *fiber.Ctx, userId string, currentSessionId string) error {
+	sessions, err := repository.GetActiveUserSessions(models.DBConn, userId, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	results := []schemas.SessionDetailResponse{}
+	for _, item := range sessions {
+		results = append(results, schemas.SessionDetailResponse{
+			Id:         item.ID,
+			UserAgent:  item.UserAgent,
+			IpAddress:  item.IPAddress,
+			Current:    item.ID == currentSessionId,
+			LastSeenAt: item.LastSeenAt,
+			ExpiredAt:  item.ExpiredAt,
+			CreatedAt:  item.CreatedAt,
+		})
+	}
+	return c.Status(200).JSON(schemas.SessionListResponse{
+		Results: results,
+	})
+}
+
+// terminateSession terminate active session on sessionId param belong to the user
+func terminateSession(c *fiber.Ctx, userId string) error {
+	sessionId := c.Params("sessionId")
+	if !core.IsValidUUID(sessionId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "session not found",
+		})
+	}
+
+	now := time.Now()
+	session, err := repository.GetActiveUserSessionById(models.DBConn, userId, sessionId, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "session not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := repository.TerminateUserSession(models.DBConn, session, now); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	return c.Status(204).JSON(nil)
+}
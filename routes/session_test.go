@@ -0,0 +1,314 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateSessionTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateSessionTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+}
+
+func (suite *MigrateSessionTestSuite) SetupTest() {
+	models.ClearAllData()
+}
+
+func (suite *MigrateSessionTestSuite) createUser(username string, isSuperuser bool) models.User {
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       username + "@test.com",
+		Username:    username,
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: isSuperuser,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	return user
+}
+
+func (suite *MigrateSessionTestSuite) login(username string, userAgent string) schemas.LoginResponse {
+	var param = url.Values{}
+	param.Set("username", username)
+	param.Set("password", "Fakepassword")
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := suite.app.Test(req, suite.timeout)
+	if err != nil {
+		panic(err.Error())
+	}
+	if resp.StatusCode != 200 {
+		panic("login failed")
+	}
+	loginResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &loginResponse); err != nil {
+		panic(err.Error())
+	}
+	return loginResponse
+}
+
+func (suite *MigrateSessionTestSuite) getSessions(path string, token string) (int, schemas.SessionListResponse) {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	jsonResponse := schemas.SessionListResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(body, &jsonResponse)
+	return resp.StatusCode, jsonResponse
+}
+
+func (suite *MigrateSessionTestSuite) TestGetAllSession() {
+	// Given
+	user := suite.createUser("test", false)
+	laptop := suite.login("test", "laptop")
+	suite.login("test", "phone")
+
+	// When
+	statusCode, jsonResponse := suite.getSessions("/user/me/sessions", laptop.AccessToken)
+
+	// Expect
+	assert.Equal(suite.T(), 200, statusCode)
+	assert.Len(suite.T(), jsonResponse.Results, 2)
+	userAgents := []string{}
+	for _, item := range jsonResponse.Results {
+		userAgents = append(userAgents, item.UserAgent)
+		assert.NotEmpty(suite.T(), item.IpAddress)
+		assert.Equal(suite.T(), item.UserAgent == "laptop", item.Current)
+	}
+	assert.ElementsMatch(suite.T(), []string{"laptop", "phone"}, userAgents)
+	session := models.UserSession{}
+	models.DBConn.Where("user_id = ? AND user_agent = ?", user.ID, "laptop").First(&session)
+	refreshToken := models.RefreshToken{}
+	models.DBConn.Where("family_id = ?", session.ID).First(&refreshToken)
+	assert.Equal(suite.T(), user.ID, refreshToken.UserID)
+}
+
+func (suite *MigrateSessionTestSuite) TestDeleteSession() {
+	// Given
+	suite.createUser("test", false)
+	laptop := suite.login("test", "laptop")
+	phone := suite.login("test", "phone")
+	_, jsonResponse := suite.getSessions("/user/me/sessions", laptop.AccessToken)
+	phoneSessionId := ""
+	for _, item := range jsonResponse.Results {
+		if item.UserAgent == "phone" {
+			phoneSessionId = item.Id
+		}
+	}
+
+	// Refreshed access token keep the session
+	var param = url.Values{}
+	param.Set("refresh_token", phone.RefreshToken)
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	refreshed := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(body, &refreshed)
+	assert.Equal(suite.T(), phoneSessionId, core.GetSessionIdFromJWTToken(refreshed.AccessToken))
+
+	// When
+	req, _ = http.NewRequest("DELETE", "/user/me/sessions/"+phoneSessionId, nil)
+	req.Header.Set("authorization", "Bearer "+laptop.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+	statusCode, jsonResponse := suite.getSessions("/user/me/sessions", laptop.AccessToken)
+	assert.Equal(suite.T(), 200, statusCode)
+	assert.Len(suite.T(), jsonResponse.Results, 1)
+
+	// Expect access token and refresh token of terminated session refused
+	for _, token := range []string{phone.AccessToken, refreshed.AccessToken} {
+		statusCode, _ = suite.getSessions("/user/me/sessions", token)
+		assert.Equal(suite.T(), 401, statusCode)
+	}
+	param.Set("refresh_token", refreshed.RefreshToken)
+	req, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// Expect terminated session not found anymore
+	req, _ = http.NewRequest("DELETE", "/user/me/sessions/"+phoneSessionId, nil)
+	req.Header.Set("authorization", "Bearer "+laptop.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 404, resp.StatusCode)
+}
+
+func (suite *MigrateSessionTestSuite) TestDeleteSessionOfOtherUser() {
+	// Given
+	suite.createUser("test", false)
+	suite.createUser("other", false)
+	test := suite.login("test", "laptop")
+	other := suite.login("other", "laptop")
+	otherSessionId := core.GetSessionIdFromJWTToken(other.AccessToken)
+
+	// When
+	req, _ := http.NewRequest("DELETE", "/user/me/sessions/"+otherSessionId, nil)
+	req.Header.Set("authorization", "Bearer "+test.AccessToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 404, resp.StatusCode)
+	statusCode, _ := suite.getSessions("/user/me/sessions", other.AccessToken)
+	assert.Equal(suite.T(), 200, statusCode)
+}
+
+func (suite *MigrateSessionTestSuite) TestDeleteSessionScopedToken() {
+	// Given
+	user := suite.createUser("test", false)
+	laptop := suite.login("test", "laptop")
+	sessionId := core.GetSessionIdFromJWTToken(laptop.AccessToken)
+	token, err := core.GenerateScopedJWTTokenFromUser(models.DBConn, user, "user:read")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ := http.NewRequest("DELETE", "/user/me/sessions/"+sessionId, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When logout everywhere
+	req, _ = http.NewRequest("POST", "/auth/logout-all", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect session kept
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	statusCode, jsonResponse := suite.getSessions("/user/me/sessions", laptop.AccessToken)
+	assert.Equal(suite.T(), 200, statusCode)
+	assert.Len(suite.T(), jsonResponse.Results, 1)
+}
+
+func (suite *MigrateSessionTestSuite) TestLogoutTerminateSession() {
+	// Given
+	user := suite.createUser("test", false)
+	laptop := suite.login("test", "laptop")
+	phone := suite.login("test", "phone")
+
+	// When logout without refresh token
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	req.Header.Set("authorization", "Bearer "+phone.AccessToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect session of the access token terminated
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	statusCode, jsonResponse := suite.getSessions("/user/me/sessions", laptop.AccessToken)
+	assert.Equal(suite.T(), 200, statusCode)
+	assert.Len(suite.T(), jsonResponse.Results, 1)
+	assert.Equal(suite.T(), "laptop", jsonResponse.Results[0].UserAgent)
+
+	// Expect refresh token of the session refused
+	var param = url.Values{}
+	param.Set("refresh_token", phone.RefreshToken)
+	req, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// When logout with api key
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, user.ID, "ci", []string{}, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	req, _ = http.NewRequest("POST", "/auth/logout", nil)
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateSessionTestSuite) TestUserSessionBySuperuser() {
+	// Given
+	suite.createUser("admin", true)
+	user := suite.createUser("test", false)
+	admin := suite.login("admin", "laptop")
+	test := suite.login("test", "phone")
+
+	// When
+	statusCode, jsonResponse := suite.getSessions("/user/"+user.ID+"/sessions", admin.AccessToken)
+
+	// Expect
+	assert.Equal(suite.T(), 200, statusCode)
+	assert.Len(suite.T(), jsonResponse.Results, 1)
+	assert.Equal(suite.T(), "phone", jsonResponse.Results[0].UserAgent)
+	assert.False(suite.T(), jsonResponse.Results[0].Current)
+
+	// Expect only superuser manage session of other user
+	statusCode, _ = suite.getSessions("/user/"+user.ID+"/sessions", test.AccessToken)
+	assert.Equal(suite.T(), 403, statusCode)
+
+	// When
+	req, _ := http.NewRequest("DELETE", "/user/"+user.ID+"/sessions/"+jsonResponse.Results[0].Id, nil)
+	req.Header.Set("authorization", "Bearer "+admin.AccessToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+	statusCode, _ = suite.getSessions("/user/me/sessions", test.AccessToken)
+	assert.Equal(suite.T(), 401, statusCode)
+}
+
+func (suite *MigrateSessionTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateSessionTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateSessionTestSuite))
+}
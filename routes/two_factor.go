@@ -0,0 +1,228 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Get Two Factor
+//
+//	@Summary		Get Two Factor
+//	@Description	Get two factor authentication status of current user
+//	@Tags			Two Factor
+//	@Produce		json
+//	@Success		200	{object}	schemas.TwoFactorStatusResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/2fa [get]
+func GetTwoFactorRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage two factor",
+		})
+	}
+	user := principal.User
+
+	recoveryCodesRemaining := int64(0)
+	if user.TotpEnabledAt != nil {
+		recoveryCodesRemaining, err = repository.CountUnusedUserRecoveryCode(models.DBConn, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
+	return c.Status(200).JSON(schemas.TwoFactorStatusResponse{
+		Enabled:                user.TotpEnabledAt != nil,
+		RecoveryCodesRemaining: recoveryCodesRemaining,
+	})
+}
+
+// Enroll Two Factor
+//
+//	@Summary		Enroll Two Factor
+//	@Description	Generate TOTP secret for current user, add it to authenticator app using the provisioning uri
+//	@Description	then confirm it on /user/me/2fa/confirm
+//	@Tags			Two Factor
+//	@Produce		json
+//	@Success		200	{object}	schemas.TwoFactorEnrollResponse
+//	@Failure		400	{object}	schemas.BadRequestResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/2fa/enroll [post]
+func EnrollTwoFactorRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key not allowed since leaked api key could lock the user out
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could enroll two factor",
+		})
+	}
+	user := principal.User
+
+	if user.TotpEnabledAt != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "two factor already enabled",
+		})
+	}
+
+	secret, err := core.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	user, err = repository.SetUserTotpSecret(models.DBConn, user, secret)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningUri: core.TOTPProvisioningURI(secret, user.Username),
+	})
+}
+
+// Confirm Two Factor
+//
+//	@Summary		Confirm Two Factor
+//	@Description	Enable two factor using code from authenticator app, recovery codes only shown once
+//	@Tags			Two Factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		schemas.TwoFactorConfirmRequest	true	"TOTP code"
+//	@Success		200		{object}	schemas.TwoFactorConfirmResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/2fa/confirm [post]
+func ConfirmTwoFactorRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key not allowed since leaked api key could lock the user out
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could confirm two factor",
+		})
+	}
+	user := principal.User
+
+	// validation
+	jsonRequest := schemas.TwoFactorConfirmRequest{}
+	if err := c.BodyParser(&jsonRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(jsonRequest)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	if user.TotpEnabledAt != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "two factor already enabled",
+		})
+	}
+	if user.TotpSecret == nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "two factor not enrolled",
+		})
+	}
+
+	now := time.Now()
+	step, isMatch := core.VerifyTOTPCode(*user.TotpSecret, jsonRequest.Code, now, user.TotpLastUsedStep)
+	if !isMatch {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: errInvalidTwoFactorCode.Error(),
+		})
+	}
+
+	_, recoveryCodes, err := repository.EnableUserTotp(models.DBConn, user, step, now)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(schemas.TwoFactorConfirmResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// Reset User Two Factor
+//
+//	@Summary		Reset User Two Factor
+//	@Description	Disable two factor of user who lost the authenticator app and recovery codes, superuser only
+//	@Tags			User
+//	@Param			id	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/2fa [delete]
+func ResetUserTwoFactorRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	_, err = repository.ResetUserTotp(models.DBConn, user)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	return c.Status(204).JSON(nil)
+}
@@ -0,0 +1,318 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateTwoFactorTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateTwoFactorTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+}
+
+func (suite *MigrateTwoFactorTestSuite) SetupTest() {
+	models.ClearAllData()
+	core.IPLoginThrottle = core.NewLoginThrottle()
+}
+
+func (suite *MigrateTwoFactorTestSuite) createUser(username string, isSuperuser bool) models.User {
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, err := core.HashPassword("Fakepassword")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       username + "@test.com",
+		Username:    username,
+		Password:    hashPasword,
+		IsActive:    true,
+		IsSuperuser: isSuperuser,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	return user
+}
+
+func (suite *MigrateTwoFactorTestSuite) postForm(path string, param url.Values) *http.Response {
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+func (suite *MigrateTwoFactorTestSuite) TestEnrollAndLogin() {
+	// Given
+	user := suite.createUser("test", false)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When enroll
+	req, _ := http.NewRequest("POST", "/user/me/2fa/enroll", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	enrollResponse := schemas.TwoFactorEnrollResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &enrollResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEmpty(suite.T(), enrollResponse.Secret)
+	assert.Contains(suite.T(), enrollResponse.ProvisioningUri, "otpauth://totp/")
+
+	// When confirm with wrong code
+	req, _ = http.NewRequest("POST", "/user/me/2fa/confirm", bytes.NewBufferString(`{"code": "000000x"}`))
+	req.Header.Set("authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When confirm
+	now := time.Now()
+	code, _ := core.GenerateTOTPCode(enrollResponse.Secret, core.TOTPStep(now))
+	req, _ = http.NewRequest("POST", "/user/me/2fa/confirm", bytes.NewBufferString(`{"code": "`+code+`"}`))
+	req.Header.Set("authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	confirmResponse := schemas.TwoFactorConfirmResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &confirmResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Len(suite.T(), confirmResponse.RecoveryCodes, repository.RecoveryCodeCount)
+
+	// When login
+	param := url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	resp = suite.postForm("/auth/login", param)
+
+	// Expect challenge instead of access token
+	assert.Equal(suite.T(), 202, resp.StatusCode)
+	challengeResponse := schemas.TwoFactorChallengeResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &challengeResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.True(suite.T(), challengeResponse.TwoFactorRequired)
+
+	// Expect challenge token could not used as access token
+	req, _ = http.NewRequest("GET", "/user/me/2fa", nil)
+	req.Header.Set("authorization", "Bearer "+challengeResponse.ChallengeToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// When reuse confirm code (replay)
+	param = url.Values{}
+	param.Set("challenge_token", challengeResponse.ChallengeToken)
+	param.Set("code", code)
+	resp = suite.postForm("/auth/login/2fa", param)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When next code
+	nextCode, _ := core.GenerateTOTPCode(enrollResponse.Secret, core.TOTPStep(now)+1)
+	param.Set("code", nextCode)
+	resp = suite.postForm("/auth/login/2fa", param)
+
+	// Expect
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	loginResponse := schemas.LoginResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &loginResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEmpty(suite.T(), loginResponse.AccessToken)
+	updatedUser, err := repository.GetUserById(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, updatedUser.FailedLoginCount)
+
+	// When reuse challenge token
+	resp = suite.postForm("/auth/login/2fa", param)
+
+	// Expect
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+}
+
+func (suite *MigrateTwoFactorTestSuite) TestEnrollScopedTokenAndApiKey() {
+	// Given
+	user := suite.createUser("test", false)
+	token, err := core.GenerateScopedJWTTokenFromUser(models.DBConn, user, "user:read")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When enroll and confirm with scoped token
+	for _, path := range []string{"/user/me/2fa/enroll", "/user/me/2fa/confirm"} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(`{"code": "000000"}`))
+		req.Header.Set("authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := suite.app.Test(req, suite.timeout)
+
+		// Expect
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), 403, resp.StatusCode)
+	}
+
+	// When enroll and confirm with unscoped api key
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, user.ID, "ci", []string{}, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, path := range []string{"/user/me/2fa/enroll", "/user/me/2fa/confirm"} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(`{"code": "000000"}`))
+		req.Header.Set("X-API-Key", rawKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := suite.app.Test(req, suite.timeout)
+
+		// Expect
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), 403, resp.StatusCode)
+	}
+	updatedUser, _ := repository.GetUserById(models.DBConn, user.ID)
+	assert.Nil(suite.T(), updatedUser.TotpSecret)
+
+	// When get status with scoped token
+	req, _ := http.NewRequest("GET", "/user/me/2fa", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+}
+
+func (suite *MigrateTwoFactorTestSuite) TestLoginRecoveryCode() {
+	// Given
+	user := suite.createUser("test", false)
+	secret, _ := core.GenerateTOTPSecret()
+	user, _ = repository.SetUserTotpSecret(models.DBConn, user, secret)
+	_, recoveryCodes, err := repository.EnableUserTotp(models.DBConn, user, 0, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	login := func() string {
+		param := url.Values{}
+		param.Set("username", "test")
+		param.Set("password", "Fakepassword")
+		resp := suite.postForm("/auth/login", param)
+		assert.Equal(suite.T(), 202, resp.StatusCode)
+		challengeResponse := schemas.TwoFactorChallengeResponse{}
+		body, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(body, &challengeResponse)
+		return challengeResponse.ChallengeToken
+	}
+
+	// When
+	param := url.Values{}
+	param.Set("challenge_token", login())
+	param.Set("code", recoveryCodes[0])
+	resp := suite.postForm("/auth/login/2fa", param)
+
+	// Expect
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	count, err := repository.CountUnusedUserRecoveryCode(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(repository.RecoveryCodeCount-1), count)
+
+	// When reuse recovery code
+	param.Set("challenge_token", login())
+	resp = suite.postForm("/auth/login/2fa", param)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateTwoFactorTestSuite) TestResetTwoFactor() {
+	// Given
+	superuser := suite.createUser("admin", true)
+	user := suite.createUser("test", false)
+	secret, _ := core.GenerateTOTPSecret()
+	user, _ = repository.SetUserTotpSecret(models.DBConn, user, secret)
+	_, _, err := repository.EnableUserTotp(models.DBConn, user, 0, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, superuser)
+	if err != nil {
+		panic(err.Error())
+	}
+	userToken, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When non superuser
+	req, _ := http.NewRequest("DELETE", "/user/"+user.ID+"/2fa", nil)
+	req.Header.Set("authorization", "Bearer "+userToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When
+	req, _ = http.NewRequest("DELETE", "/user/"+user.ID+"/2fa", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+	param := url.Values{}
+	param.Set("username", "test")
+	param.Set("password", "Fakepassword")
+	resp = suite.postForm("/auth/login", param)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	count, err := repository.CountUnusedUserRecoveryCode(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+func (suite *MigrateTwoFactorTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateTwoFactorTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateTwoFactorTestSuite))
+}
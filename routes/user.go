@@ -2,6 +2,7 @@ package routes
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
@@ -23,18 +24,13 @@ import (
 //	@Success		200			{object}	schemas.UserPaginateResponse
 //	@Failure		400			{object}	schemas.BadRequestResponse
 //	@Failure		401			{object}	schemas.UnauthorizedResponse
+//	@Failure		403			{object}	schemas.ForbiddenResponse
 //	@Failure		500			{object}	schemas.InternalServerErrorResponse
-//	@Security		OAuth2Password
+//	@Security		OAuth2Password[user:read]
+//	@Security		OAuth2Application[user:read]
+//	@Security		ApiKeyAuth
 //	@Router			/user/ [get]
 func GetAllUserRoute(c *fiber.Ctx) error {
-	// Authorize User
-	_, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
-	if err != nil {
-		return c.Status(401).JSON(schemas.UnauthorizedResponse{
-			Message: "Invalid/Expired token",
-		})
-	}
-
 	// Get Query Parameter
 	page := c.QueryInt("page", 1)
 	pageSize := c.QueryInt("page_size", 10)
@@ -101,19 +97,15 @@ func GetAllUserRoute(c *fiber.Ctx) error {
 //	@Param			id	path		string	true	"User ID"
 //	@Success		200	{object}	schemas.UserDetailResponse
 //	@Failure		400	{object}	schemas.BadRequestResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
 //	@Failure		404	{object}	schemas.NotFoundResponse
 //	@Failure		500	{object}	schemas.InternalServerErrorResponse
-//	@Security		OAuth2Password
+//	@Security		OAuth2Password[user:read]
+//	@Security		OAuth2Application[user:read]
+//	@Security		ApiKeyAuth
 //	@Router			/user/{id} [get]
 func GetDetailUserRoute(c *fiber.Ctx) error {
-	// Authorize User
-	_, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
-	if err != nil {
-		return c.Status(401).JSON(schemas.UnauthorizedResponse{
-			Message: "Invalid/Expired token",
-		})
-	}
-
 	// Get Params
 	userId := c.Params("userId")
 	if !core.IsValidUUID(userId) {
@@ -153,19 +145,15 @@ func GetDetailUserRoute(c *fiber.Ctx) error {
 //	@Param			user	body		schemas.UserCreateRequest	true	"Create User"
 //	@Success		200		{object}	schemas.UserCreateResponse
 //	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
 //	@Failure		422		{object}	schemas.UnprocessableEntityResponse
 //	@Failure		500		{object}	schemas.InternalServerErrorResponse
-//	@Security		OAuth2Password
+//	@Security		OAuth2Password[user:create]
+//	@Security		OAuth2Application[user:create]
+//	@Security		ApiKeyAuth
 //	@Router			/user/ [post]
 func CreateUserRoute(c *fiber.Ctx) error {
-	// Authorize User
-	_, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
-	if err != nil {
-		return c.Status(401).JSON(schemas.UnauthorizedResponse{
-			Message: "Invalid/Expired token",
-		})
-	}
-
 	// validation
 	var newUser schemas.UserCreateRequest
 	if err := c.BodyParser(&newUser); err != nil {
@@ -178,6 +166,31 @@ func CreateUserRoute(c *fiber.Ctx) error {
 		return c.Status(422).JSON(validation_errors)
 	}
 
+	// only superuser could create superuser
+	if newUser.IsSuperuser && !getPrincipal(c).IsSuperuser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only superuser could create superuser",
+		})
+	}
+
+	// username and email should not used by other user, password follow password policy
+	validation_errors, err := validateNewUser(newUser.Username, newUser.Email)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	passwordErrors, err := validatePassword(newUser.Password, newUser.Username, newUser.Email, nil)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	validation_errors.Message = append(validation_errors.Message, passwordErrors...)
+	if len(validation_errors.Message) > 0 {
+		return c.Status(422).JSON(validation_errors)
+	}
+
 	now := time.Now()
 	createdUser, err := repository.CreateUser(
 		models.DBConn,
@@ -195,6 +208,13 @@ func CreateUserRoute(c *fiber.Ctx) error {
 		})
 	}
 
+	// Send email verification link
+	if err := sendEmailVerification(createdUser); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
 	return c.Status(201).JSON(schemas.UserCreateResponse{
 		Id:          createdUser.ID,
 		Username:    createdUser.Username,
@@ -215,20 +235,16 @@ func CreateUserRoute(c *fiber.Ctx) error {
 //	@Param			user	body		schemas.UserUpdateRequest	true	"Update User"
 //	@Success		200		{object}	schemas.UserUpdateResponse
 //	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
 //	@Failure		404		{object}	schemas.NotFoundResponse
 //	@Failure		422		{object}	schemas.UnprocessableEntityResponse
 //	@Failure		500		{object}	schemas.InternalServerErrorResponse
-//	@Security		OAuth2Password
+//	@Security		OAuth2Password[user:update]
+//	@Security		OAuth2Application[user:update]
+//	@Security		ApiKeyAuth
 //	@Router			/user/{id} [put]
 func UpdateUserRoute(c *fiber.Ctx) error {
-	// Authorize User
-	_, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
-	if err != nil {
-		return c.Status(401).JSON(schemas.UnauthorizedResponse{
-			Message: "Invalid/Expired token",
-		})
-	}
-
 	// get input user
 	userId := c.Params("userId")
 	if !core.IsValidUUID(userId) {
@@ -239,7 +255,7 @@ func UpdateUserRoute(c *fiber.Ctx) error {
 
 	// validation
 	jsonRequest := schemas.UserUpdateRequest{}
-	if err = c.BodyParser(&jsonRequest); err != nil {
+	if err := c.BodyParser(&jsonRequest); err != nil {
 		return c.Status(400).JSON(schemas.BadRequestResponse{
 			Message: err.Error(),
 		})
@@ -263,6 +279,53 @@ func UpdateUserRoute(c *fiber.Ctx) error {
 		})
 	}
 
+	// only superuser could update superuser or grant superuser
+	if (user.IsSuperuser || jsonRequest.IsSuperuser) && !getPrincipal(c).IsSuperuser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only superuser could update superuser",
+		})
+	}
+
+	// account status changed only by activate and deactivate action
+	if jsonRequest.IsActive != user.IsActive {
+		return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+			Message: []map[string]string{
+				{"is_active": "use /user/{id}/activate or /user/{id}/deactivate to change account status"},
+			},
+		})
+	}
+
+	// email should not used by other user
+	if !strings.EqualFold(jsonRequest.Email, user.Email) {
+		_, err := repository.GetUserByEmail(models.DBConn, jsonRequest.Email)
+		if err == nil {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: []map[string]string{
+					{"email": "email already used"},
+				},
+			})
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
+	// password policy and password history
+	if jsonRequest.Password != nil {
+		passwordErrors, err := validatePassword(*jsonRequest.Password, jsonRequest.Username, jsonRequest.Email, &user)
+		if err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		if len(passwordErrors) > 0 {
+			return c.Status(422).JSON(schemas.UnprocessableEntityResponse{
+				Message: passwordErrors,
+			})
+		}
+	}
+
 	// update user
 	updatedUser, err := repository.UpdateUser(
 		models.DBConn,
@@ -280,6 +343,15 @@ func UpdateUserRoute(c *fiber.Ctx) error {
 		})
 	}
 
+	// Changed email need to be verified again
+	if updatedUser.Email != user.Email {
+		if err := sendEmailVerification(updatedUser); err != nil {
+			return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
 	return c.Status(200).JSON(schemas.UserUpdateResponse{
 		Id:          updatedUser.ID,
 		Username:    updatedUser.Username,
@@ -296,19 +368,15 @@ func UpdateUserRoute(c *fiber.Ctx) error {
 //	@Tags			User
 //	@Param			id	path	string	true	"User ID"
 //	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
 //	@Failure		404	{object}	schemas.NotFoundResponse
 //	@Failure		500	{object}	schemas.InternalServerErrorResponse
-//	@Security		OAuth2Password
+//	@Security		OAuth2Password[user:delete]
+//	@Security		OAuth2Application[user:delete]
+//	@Security		ApiKeyAuth
 //	@Router			/user/{id} [delete]
 func DeleteUserRoute(c *fiber.Ctx) error {
-	// Authorize User
-	_, err := core.GetUserFromAuthorizationHeader(models.DBConn, c)
-	if err != nil {
-		return c.Status(401).JSON(schemas.UnauthorizedResponse{
-			Message: "Invalid/Expired token",
-		})
-	}
-
 	// get input user
 	userId := c.Params("userId")
 	if !core.IsValidUUID(userId) {
@@ -330,6 +398,13 @@ func DeleteUserRoute(c *fiber.Ctx) error {
 		})
 	}
 
+	// only superuser could delete superuser
+	if user.IsSuperuser && !getPrincipal(c).IsSuperuser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only superuser could delete superuser",
+		})
+	}
+
 	_, err = repository.DeleteUser(models.DBConn, user)
 	if err != nil {
 		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
@@ -338,3 +413,245 @@ func DeleteUserRoute(c *fiber.Ctx) error {
 	}
 	return c.Status(204).JSON(nil)
 }
+
+// Deactivate User
+//
+//	@Summary		Deactivate User
+//	@Description	Deactivate user, revoke every token of the user and refuse further login
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"User ID"
+//	@Param			user	body		schemas.UserDeactivateRequest	true	"Deactivate User"
+//	@Success		200		{object}	schemas.UserAccountStatusResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		404		{object}	schemas.NotFoundResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password[user:update]
+//	@Security		OAuth2Application[user:update]
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/deactivate [post]
+func DeactivateUserRoute(c *fiber.Ctx) error {
+	// validation
+	var jsonRequest schemas.UserDeactivateRequest
+	if err := c.BodyParser(&jsonRequest); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	principal := getPrincipal(c)
+	if principal.IsUser() && principal.User.ID == user.ID {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: "could not deactivate yourself",
+		})
+	}
+
+	// only superuser could deactivate superuser
+	if user.IsSuperuser && !principal.IsSuperuser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only superuser could deactivate superuser",
+		})
+	}
+
+	// deactivated by is empty when deactivated by machine client
+	var deactivatedById *string
+	if principal.IsUser() {
+		deactivatedById = &principal.User.ID
+	}
+	now := time.Now()
+	user, err = repository.DeactivateUser(models.DBConn, user, deactivatedById, jsonRequest.Reason, now)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if err := core.TokenRevocationStore.RevokeAllBefore(user.ID, now); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(userAccountStatusResponse(user))
+}
+
+// Activate User
+//
+//	@Summary		Activate User
+//	@Description	Activate deactivated user
+//	@Tags			User
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	schemas.UserAccountStatusResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password[user:update]
+//	@Security		OAuth2Application[user:update]
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/activate [post]
+func ActivateUserRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// only superuser could activate superuser
+	if user.IsSuperuser && !getPrincipal(c).IsSuperuser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only superuser could activate superuser",
+		})
+	}
+
+	user, err = repository.ActivateUser(models.DBConn, user, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(userAccountStatusResponse(user))
+}
+
+func userAccountStatusResponse(user models.User) schemas.UserAccountStatusResponse {
+	return schemas.UserAccountStatusResponse{
+		Id:                user.ID,
+		IsActive:          user.IsActive,
+		DeactivatedAt:     user.DeactivatedAt,
+		DeactivatedReason: user.DeactivatedReason,
+		DeactivatedById:   user.DeactivatedByID,
+	}
+}
+
+// Get User Lockout
+//
+//	@Summary		Get User Lockout
+//	@Description	Get failed login and lockout state of user, superuser only
+//	@Tags			User
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	schemas.UserLockoutResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/lockout [get]
+func GetUserLockoutRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(userLockoutResponse(user, time.Now()))
+}
+
+// Clear User Lockout
+//
+//	@Summary		Clear User Lockout
+//	@Description	Reset failed login and unlock user, superuser only
+//	@Tags			User
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	schemas.UserLockoutResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Security		ApiKeyAuth
+//	@Router			/user/{id}/lockout [delete]
+func ClearUserLockoutRoute(c *fiber.Ctx) error {
+	// Get Params
+	userId := c.Params("userId")
+	if !core.IsValidUUID(userId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "user not found",
+		})
+	}
+
+	user, err := repository.GetUserById(models.DBConn, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "user not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	user, err = repository.ClearLoginLockout(models.DBConn, user)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(userLockoutResponse(user, time.Now()))
+}
+
+func userLockoutResponse(user models.User, now time.Time) schemas.UserLockoutResponse {
+	return schemas.UserLockoutResponse{
+		Id:                user.ID,
+		FailedLoginCount:  user.FailedLoginCount,
+		LastFailedLoginAt: user.LastFailedLoginAt,
+		LockedUntil:       user.LockedUntil,
+		IsLocked:          user.LockedUntil != nil && now.Before(*user.LockedUntil),
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
 	"github.com/BimaAdi/fiberGormBoilerplate/routes"
 	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
 	"github.com/BimaAdi/fiberGormBoilerplate/settings"
@@ -472,6 +475,39 @@ func (suite *MigrateTestSuite) TestUpdateUser() {
 	assert.Nil(suite.T(), err, "Invalid response json")
 }
 
+func (suite *MigrateTestSuite) TestUpdateUserEmailCase() {
+	// Given verified user
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	verifiedAt := time.Now()
+	user := models.User{
+		Email:           "test@example.com",
+		Username:        "test",
+		Password:        "Fakepassword",
+		IsActive:        true,
+		EmailVerifiedAt: &verifiedAt,
+		CreatedAt:       time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+
+	// When only case of email changed
+	user, err = repository.UpdateUser(models.DBConn, user, "Test@Example.com", "test", nil, true, false)
+
+	// Expect still verified
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "Test@Example.com", user.Email)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+
+	// When email changed
+	user, err = repository.UpdateUser(models.DBConn, user, "other@example.com", "test", nil, true, false)
+
+	// Expect verified again
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), user.EmailVerifiedAt)
+}
+
 func (suite *MigrateTestSuite) TestDeleteUser() {
 	// Given
 	// create request user
@@ -559,6 +595,608 @@ func (suite *MigrateTestSuite) TestDeleteUser() {
 
 // ==========================================
 
+func (suite *MigrateTestSuite) TestUserPermission() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	users := []models.User{
+		{
+			Email:       "a@test.com",
+			Username:    "a",
+			Password:    "Fakepassword",
+			IsActive:    true,
+			IsSuperuser: false,
+			CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+		{
+			Email:       "b@test.com",
+			Username:    "b",
+			Password:    "Fakepassword",
+			IsActive:    true,
+			IsSuperuser: true,
+			CreatedAt:   time.Date(2022, 10, 4, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+	}
+	models.DBConn.Create(&users)
+	request_user := users[0]
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When user has no role
+	req, _ := http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	jsonResponse := schemas.ForbiddenResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+
+	// Given user has role with user:read and user:delete
+	now := time.Now()
+	role, err := repository.CreateRole(models.DBConn, "operator", "", now)
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, permissionName := range []string{core.PermissionUserRead, core.PermissionUserDelete} {
+		permission, err := repository.GetPermissionByName(models.DBConn, permissionName)
+		if err != nil {
+			panic(err.Error())
+		}
+		if err := repository.GrantPermissionToRole(models.DBConn, role, permission, now); err != nil {
+			panic(err.Error())
+		}
+	}
+	if err := repository.AssignRoleToUser(models.DBConn, request_user, role, now); err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ = http.NewRequest("GET", "/user/", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// When create user without user:create
+	requestJson := schemas.UserCreateRequest{
+		Username:    "new",
+		Email:       "new@test.com",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+	}
+	requestJsonByte, _ := json.Marshal(requestJson)
+	req, _ = http.NewRequest("POST", "/user/", bytes.NewBuffer(requestJsonByte))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When delete superuser
+	req, _ = http.NewRequest("DELETE", "/user/"+users[1].ID, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+}
+
+func (suite *MigrateTestSuite) TestUserLockout() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	lockedUntil := time.Now().Add(time.Hour)
+	users := []models.User{
+		{
+			Email:       "a@test.com",
+			Username:    "a",
+			Password:    "Fakepassword",
+			IsActive:    true,
+			IsSuperuser: true,
+			CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+		{
+			Email:            "b@test.com",
+			Username:         "b",
+			Password:         "Fakepassword",
+			IsActive:         true,
+			IsSuperuser:      false,
+			FailedLoginCount: 5,
+			LockedUntil:      &lockedUntil,
+			CreatedAt:        time.Date(2022, 10, 4, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+	}
+	models.DBConn.Create(&users)
+	request_user := users[0]
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	nonSuperuserToken, err := core.GenerateJWTTokenFromUser(models.DBConn, users[1])
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	req, _ := http.NewRequest("GET", "/user/"+users[1].ID+"/lockout", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.UserLockoutResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), 5, jsonResponse.FailedLoginCount)
+	assert.True(suite.T(), jsonResponse.IsLocked)
+
+	// When non superuser
+	req, _ = http.NewRequest("DELETE", "/user/"+users[1].ID+"/lockout", nil)
+	req.Header.Set("authorization", "Bearer "+nonSuperuserToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When clear lockout
+	req, _ = http.NewRequest("DELETE", "/user/"+users[1].ID+"/lockout", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	user, err := repository.GetUserById(models.DBConn, users[1].ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, user.FailedLoginCount)
+	assert.Nil(suite.T(), user.LockedUntil)
+}
+
+func (suite *MigrateTestSuite) TestUserPasswordPolicy() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	request_user := models.User{
+		Email:       "a@test.com",
+		Username:    "a",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: true,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&request_user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, request_user)
+	if err != nil {
+		panic(err.Error())
+	}
+	settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = "digit"
+	settings.PASSWORD_HISTORY_COUNT = 2
+	defer func() {
+		settings.PASSWORD_REQUIRED_CHARACTER_CLASSES = ""
+		settings.PASSWORD_HISTORY_COUNT = 0
+	}()
+	createUser := func(password string) *http.Response {
+		requestJson := schemas.UserCreateRequest{
+			Username:    "test",
+			Password:    password,
+			Email:       "test@example.com",
+			IsActive:    true,
+			IsSuperuser: true,
+		}
+		requestJsonByte, _ := json.Marshal(requestJson)
+		req, _ := http.NewRequest("POST", "/user/", bytes.NewBuffer(requestJsonByte))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("authorization", "Bearer "+token)
+		resp, err := suite.app.Test(req, suite.timeout)
+		assert.Nil(suite.T(), err)
+		return resp
+	}
+	updatePassword := func(userId string, password string) int {
+		requestJson := schemas.UserUpdateRequest{
+			Username:    "test",
+			Password:    &password,
+			Email:       "test@example.com",
+			IsActive:    true,
+			IsSuperuser: true,
+		}
+		requestJsonByte, _ := json.Marshal(requestJson)
+		req, _ := http.NewRequest("PUT", "/user/"+userId, bytes.NewBuffer(requestJsonByte))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("authorization", "Bearer "+token)
+		resp, err := suite.app.Test(req, suite.timeout)
+		assert.Nil(suite.T(), err)
+		return resp.StatusCode
+	}
+
+	// When password violate policy
+	resp := createUser("testpassword")
+
+	// Expect
+	assert.Equal(suite.T(), 422, resp.StatusCode)
+	jsonResponse := schemas.UnprocessableEntityResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), []map[string]string{
+		{"password": "password should contain digit character"},
+	}, jsonResponse.Message)
+
+	// When password follow policy
+	resp = createUser("testpassword1")
+
+	// Expect
+	assert.Equal(suite.T(), 201, resp.StatusCode)
+	createdUser := schemas.UserCreateResponse{}
+	body, _ = io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &createdUser)
+	assert.Nil(suite.T(), err, "Invalid response json")
+
+	// Expect last 2 password could not be reused
+	assert.Equal(suite.T(), 422, updatePassword(createdUser.Id, "testpassword1"))
+	assert.Equal(suite.T(), 200, updatePassword(createdUser.Id, "testpassword2"))
+	assert.Equal(suite.T(), 422, updatePassword(createdUser.Id, "testpassword1"))
+	assert.Equal(suite.T(), 200, updatePassword(createdUser.Id, "testpassword3"))
+	assert.Equal(suite.T(), 200, updatePassword(createdUser.Id, "testpassword1"))
+}
+
+func (suite *MigrateTestSuite) TestDeactivateUser() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, _ := core.HashPassword("Fakepassword")
+	users := []models.User{
+		{
+			Email:       "a@test.com",
+			Username:    "a",
+			Password:    hashPasword,
+			IsActive:    true,
+			IsSuperuser: true,
+			CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+		{
+			Email:       "b@test.com",
+			Username:    "b",
+			Password:    hashPasword,
+			IsActive:    true,
+			IsSuperuser: false,
+			CreatedAt:   time.Date(2022, 10, 4, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+	}
+	models.DBConn.Create(&users)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, users[0])
+	if err != nil {
+		panic(err.Error())
+	}
+	userToken, err := core.GenerateJWTTokenFromUser(models.DBConn, users[1])
+	if err != nil {
+		panic(err.Error())
+	}
+	_, rawRefreshToken, err := repository.CreateRefreshToken(models.DBConn, users[1].ID, nil, nil, "", time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When deactivate yourself
+	req, _ := http.NewRequest("POST", "/user/"+users[0].ID+"/deactivate", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When non superuser deactivate superuser
+	req, _ = http.NewRequest("POST", "/user/"+users[0].ID+"/deactivate", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+userToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When deactivate user
+	req, _ = http.NewRequest("POST", "/user/"+users[1].ID+"/deactivate", bytes.NewBufferString(`{"reason": "left the company"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.UserAccountStatusResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.False(suite.T(), jsonResponse.IsActive)
+	assert.NotNil(suite.T(), jsonResponse.DeactivatedAt)
+	assert.Equal(suite.T(), "left the company", *jsonResponse.DeactivatedReason)
+	assert.Equal(suite.T(), users[0].ID, *jsonResponse.DeactivatedById)
+
+	// Expect existing token rejected
+	req, _ = http.NewRequest("GET", "/user/me", nil)
+	req.Header.Set("authorization", "Bearer "+userToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// Expect refresh token rejected
+	param := url.Values{}
+	param.Set("refresh_token", rawRefreshToken)
+	req, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 401, resp.StatusCode)
+
+	// Expect login refused
+	param = url.Values{}
+	param.Set("username", "b")
+	param.Set("password", "Fakepassword")
+	req, _ = http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When activate user
+	req, _ = http.NewRequest("POST", "/user/"+users[1].ID+"/activate", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	user, err := repository.GetUserById(models.DBConn, users[1].ID)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), user.IsActive)
+	assert.Nil(suite.T(), user.DeactivatedAt)
+	assert.Nil(suite.T(), user.DeactivatedReason)
+
+	// Expect login allowed
+	req, _ = http.NewRequest("POST", "/auth/login", bytes.NewBufferString(param.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+}
+
+func (suite *MigrateTestSuite) TestImpersonateUser() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	hashPasword, _ := core.HashPassword("Fakepassword")
+	users := []models.User{
+		{
+			Email:       "a@test.com",
+			Username:    "a",
+			Password:    hashPasword,
+			IsActive:    true,
+			IsSuperuser: true,
+			CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+		{
+			Email:       "b@test.com",
+			Username:    "b",
+			Password:    hashPasword,
+			IsActive:    true,
+			IsSuperuser: false,
+			CreatedAt:   time.Date(2022, 10, 4, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+		{
+			Email:       "c@test.com",
+			Username:    "c",
+			Password:    hashPasword,
+			IsActive:    true,
+			IsSuperuser: true,
+			CreatedAt:   time.Date(2022, 10, 3, 10, 0, 0, 0, timeZoneAsiaJakarta),
+		},
+	}
+	models.DBConn.Create(&users)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, users[0])
+	if err != nil {
+		panic(err.Error())
+	}
+	userToken, err := core.GenerateJWTTokenFromUser(models.DBConn, users[1])
+	if err != nil {
+		panic(err.Error())
+	}
+	auditLog := bytes.Buffer{}
+	core.AuditLogger.SetOutput(&auditLog)
+	defer core.AuditLogger.SetOutput(os.Stdout)
+
+	// When non superuser impersonate
+	req, _ := http.NewRequest("POST", "/user/"+users[1].ID+"/impersonate", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+userToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When impersonate other superuser (without body, reason is optional)
+	req, _ = http.NewRequest("POST", "/user/"+users[2].ID+"/impersonate", nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When impersonate user
+	req, _ = http.NewRequest("POST", "/user/"+users[1].ID+"/impersonate", bytes.NewBufferString(`{"reason": "support ticket 42"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect time boxed token of the user with act claim
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	jsonResponse := schemas.UserImpersonateResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		suite.T().Error(err.Error())
+	}
+	err = json.Unmarshal(body, &jsonResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.Equal(suite.T(), users[1].ID, jsonResponse.UserId)
+	assert.Equal(suite.T(), users[0].ID, jsonResponse.ActorId)
+	assert.Equal(suite.T(), settings.IMPERSONATION_EXPIRE_MINUTES*60, jsonResponse.ExpiresIn)
+	user, impersonator, err := core.GetUserAndImpersonatorFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), users[1].ID, user.ID)
+	assert.Equal(suite.T(), users[0].ID, impersonator.ID)
+	assert.Contains(suite.T(), auditLog.String(), "impersonation started actor="+users[0].ID)
+	assert.Contains(suite.T(), auditLog.String(), `reason="support ticket 42"`)
+
+	// When request as the user
+	req, _ = http.NewRequest("GET", "/user/me/2fa", nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect request attributed to the superuser on audit log
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	assert.Contains(suite.T(), auditLog.String(), "impersonated request actor="+users[0].ID+" (a) user="+users[1].ID+" (b) method=GET path=/user/me/2fa status=200")
+
+	// When create long lived credential as the user
+	req, _ = http.NewRequest("POST", "/user/me/api-keys", bytes.NewBufferString(`{"name": "key"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When delete api key of the user
+	apiKey, _, err := repository.CreateApiKey(models.DBConn, users[1].ID, "key", nil, nil, time.Now())
+	assert.Nil(suite.T(), err)
+	req, _ = http.NewRequest("DELETE", "/user/me/api-keys/"+apiKey.ID, nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect api key kept
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	_, err = repository.GetUserApiKeyById(models.DBConn, users[1].ID, apiKey.ID)
+	assert.Nil(suite.T(), err)
+
+	// When rename passkey of the user
+	credential := models.WebAuthnCredential{
+		UserID:       users[1].ID,
+		Name:         "laptop",
+		CredentialID: "credential-id",
+		PublicKey:    []byte("public-key"),
+		CreatedAt:    time.Now(),
+	}
+	models.DBConn.Create(&credential)
+	req, _ = http.NewRequest("PUT", "/user/me/webauthn-credentials/"+credential.ID, bytes.NewBufferString(`{"name": "renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When delete passkey of the user
+	req, _ = http.NewRequest("DELETE", "/user/me/webauthn-credentials/"+credential.ID, nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect passkey kept unchanged
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	credential, err = repository.GetUserWebAuthnCredentialById(models.DBConn, users[1].ID, credential.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "laptop", credential.Name)
+
+	// When end other session of the user
+	session, _, _, err := repository.CreateUserSession(models.DBConn, users[1].ID, core.SessionInfo{UserAgent: "laptop"}, nil, "", time.Now())
+	assert.Nil(suite.T(), err)
+	req, _ = http.NewRequest("DELETE", "/user/me/sessions/"+session.ID, nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	req, _ = http.NewRequest("POST", "/auth/logout-all", nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect session kept
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	_, err = repository.GetActiveUserSessionById(models.DBConn, users[1].ID, session.ID, time.Now())
+	assert.Nil(suite.T(), err)
+
+	// When end impersonation
+	req, _ = http.NewRequest("POST", "/user/me/impersonate/end", nil)
+	req.Header.Set("authorization", "Bearer "+jsonResponse.AccessToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect token revoked
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	assert.Contains(suite.T(), auditLog.String(), "impersonation ended actor="+users[0].ID)
+	_, err = core.GetUserFromJWTToken(models.DBConn, jsonResponse.AccessToken)
+	assert.NotNil(suite.T(), err)
+
+	// When end impersonation with regular token
+	req, _ = http.NewRequest("POST", "/user/me/impersonate/end", nil)
+	req.Header.Set("authorization", "Bearer "+userToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+
+	// When superuser no longer superuser
+	impersonationToken, _, err := core.GenerateImpersonationJWTToken(users[1], users[0])
+	assert.Nil(suite.T(), err)
+	models.DBConn.Model(&models.User{}).Where("id = ?", users[0].ID).Update("is_superuser", false)
+
+	// Expect impersonation token rejected
+	_, err = core.GetUserFromJWTToken(models.DBConn, impersonationToken)
+	assert.ErrorIs(suite.T(), err, core.ErrImpersonatorInvalid)
+}
+
 func (suite *MigrateTestSuite) TearDownTest() {
 	models.ClearAllData()
 }
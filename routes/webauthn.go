@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Get All WebAuthn Credential
+//
+//	@Summary		Get All WebAuthn Credential
+//	@Description	Get all passkey of current user
+//	@Tags			WebAuthn Credential
+//	@Produce		json
+//	@Success		200	{object}	schemas.WebAuthnCredentialListResponse
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/webauthn-credentials [get]
+func GetAllWebAuthnCredentialRoute(c *fiber.Ctx) error {
+	// Authorize User
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user could manage passkey",
+		})
+	}
+	user := principal.User
+
+	credentials, err := repository.GetAllUserWebAuthnCredential(models.DBConn, user.ID)
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	results := []schemas.WebAuthnCredentialDetailResponse{}
+	for _, item := range credentials {
+		results = append(results, webAuthnCredentialDetailResponse(item))
+	}
+	return c.Status(200).JSON(schemas.WebAuthnCredentialListResponse{
+		Results: results,
+	})
+}
+
+// Update WebAuthn Credential
+//
+//	@Summary		Update WebAuthn Credential
+//	@Description	Rename passkey of current user
+//	@Tags			WebAuthn Credential
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string									true	"WebAuthn Credential ID"
+//	@Param			payload	body		schemas.WebAuthnCredentialUpdateRequest	true	"Update WebAuthn Credential"
+//	@Success		200		{object}	schemas.WebAuthnCredentialDetailResponse
+//	@Failure		400		{object}	schemas.BadRequestResponse
+//	@Failure		401		{object}	schemas.UnauthorizedResponse
+//	@Failure		403		{object}	schemas.ForbiddenResponse
+//	@Failure		404		{object}	schemas.NotFoundResponse
+//	@Failure		422		{object}	schemas.UnprocessableEntityResponse
+//	@Failure		500		{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/webauthn-credentials/{id} [put]
+func UpdateWebAuthnCredentialRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key not allowed the same as passkey registration
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could update passkey",
+		})
+	}
+	user := principal.User
+
+	// Get Params
+	credentialId := c.Params("credentialId")
+	if !core.IsValidUUID(credentialId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "credential not found",
+		})
+	}
+
+	// validation
+	request := schemas.WebAuthnCredentialUpdateRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(schemas.BadRequestResponse{
+			Message: err.Error(),
+		})
+	}
+	is_valid, validation_errors := core.ValidateSchemas(request)
+	if !is_valid {
+		return c.Status(422).JSON(validation_errors)
+	}
+
+	credential, err := repository.GetUserWebAuthnCredentialById(models.DBConn, user.ID, credentialId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "credential not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	credential, err = repository.UpdateWebAuthnCredential(models.DBConn, credential, request.Name, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(webAuthnCredentialDetailResponse(credential))
+}
+
+// Delete WebAuthn Credential
+//
+//	@Summary		Delete WebAuthn Credential
+//	@Description	Remove passkey of current user
+//	@Tags			WebAuthn Credential
+//	@Param			id	path	string	true	"WebAuthn Credential ID"
+//	@Success		204
+//	@Failure		401	{object}	schemas.UnauthorizedResponse
+//	@Failure		403	{object}	schemas.ForbiddenResponse
+//	@Failure		404	{object}	schemas.NotFoundResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Security		OAuth2Password
+//	@Router			/user/me/webauthn-credentials/{id} [delete]
+func DeleteWebAuthnCredentialRoute(c *fiber.Ctx) error {
+	// Authorize User, scoped token and api key not allowed the same as passkey registration
+	principal, err := core.GetPrincipalFromAuthorizationHeader(models.DBConn, c)
+	if err != nil {
+		return c.Status(401).JSON(schemas.UnauthorizedResponse{
+			Message: "Invalid/Expired token",
+		})
+	}
+	if !principal.IsUser() || principal.Scopes != nil || principal.IsApiKey() {
+		return c.Status(403).JSON(schemas.ForbiddenResponse{
+			Message: "only user with unscoped access token could delete passkey",
+		})
+	}
+	user := principal.User
+
+	// Get Params
+	credentialId := c.Params("credentialId")
+	if !core.IsValidUUID(credentialId) {
+		return c.Status(404).JSON(schemas.NotFoundResponse{
+			Message: "credential not found",
+		})
+	}
+
+	credential, err := repository.GetUserWebAuthnCredentialById(models.DBConn, user.ID, credentialId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(schemas.NotFoundResponse{
+				Message: "credential not found",
+			})
+		}
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := repository.DeleteWebAuthnCredential(models.DBConn, credential); err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	return c.Status(204).JSON(nil)
+}
+
+func webAuthnCredentialDetailResponse(credential models.WebAuthnCredential) schemas.WebAuthnCredentialDetailResponse {
+	return schemas.WebAuthnCredentialDetailResponse{
+		Id:         credential.ID,
+		Name:       credential.Name,
+		SignCount:  credential.SignCount,
+		Transport:  credential.Transport,
+		LastUsedAt: credential.LastUsedAt,
+		CreatedAt:  credential.CreatedAt,
+	}
+}
@@ -0,0 +1,378 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/core/webauthntest"
+	"github.com/BimaAdi/fiberGormBoilerplate/migrations"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/routes"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateWebAuthnTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	timeout int
+}
+
+func (suite *MigrateWebAuthnTestSuite) SetupSuite() {
+	settings.InitiateSettings("../.env")
+	settings.WEBAUTHN_RP_ID = "localhost"
+	settings.WEBAUTHN_RP_ORIGINS = "http://localhost:8000"
+	models.Initiate()
+	migrations.MigrateUp("../.env", "file://../migrations/migrations_files/")
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	app := fiber.New()
+	suite.app = routes.InitiateRoutes(app)
+	suite.timeout = 5000 // ms
+}
+
+func (suite *MigrateWebAuthnTestSuite) SetupTest() {
+	models.ClearAllData()
+	core.IPLoginThrottle = core.NewLoginThrottle()
+}
+
+func (suite *MigrateWebAuthnTestSuite) postJSON(path string, token string, payload interface{}) *http.Response {
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("authorization", "Bearer "+token)
+	}
+	resp, err := suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	return resp
+}
+
+// registerPasskey register credential of authenticator for user through the api
+func (suite *MigrateWebAuthnTestSuite) registerPasskey(token string, authenticator *webauthntest.Authenticator, name string) *http.Response {
+	resp := suite.postJSON("/auth/webauthn/register/begin", token, nil)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	beginResponse := schemas.WebAuthnRegisterBeginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err := json.Unmarshal(body, &beginResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+
+	credentialJSON, err := authenticator.CreateCredential(beginResponse.Options)
+	assert.Nil(suite.T(), err)
+	return suite.postJSON("/auth/webauthn/register/finish", token, map[string]interface{}{
+		"session_token": beginResponse.SessionToken,
+		"name":          name,
+		"credential":    json.RawMessage(credentialJSON),
+	})
+}
+
+// loginPasskey login using credential of authenticator through the api
+func (suite *MigrateWebAuthnTestSuite) loginPasskey(username string, authenticator *webauthntest.Authenticator) *http.Response {
+	resp := suite.postJSON("/auth/webauthn/login/begin", "", map[string]string{"username": username})
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	beginResponse := schemas.WebAuthnLoginBeginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err := json.Unmarshal(body, &beginResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+
+	assertionJSON, err := authenticator.GetAssertion(beginResponse.Options)
+	assert.Nil(suite.T(), err)
+	return suite.postJSON("/auth/webauthn/login/finish", "", map[string]interface{}{
+		"session_token": beginResponse.SessionToken,
+		"credential":    json.RawMessage(assertionJSON),
+	})
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestRegisterAndLogin() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+	laptop := webauthntest.NewAuthenticator("http://localhost:8000")
+	phone := webauthntest.NewAuthenticator("http://localhost:8000")
+
+	// When register 2 passkey
+	resp1 := suite.registerPasskey(token, laptop, "laptop")
+	resp2 := suite.registerPasskey(token, phone, "phone")
+
+	// Expect
+	assert.Equal(suite.T(), 201, resp1.StatusCode)
+	assert.Equal(suite.T(), 201, resp2.StatusCode)
+	credentials, err := repository.GetAllUserWebAuthnCredential(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), credentials, 2)
+
+	// When login using username
+	resp := suite.loginPasskey("test", phone)
+
+	// Expect
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	loginResponse := schemas.LoginResponse{}
+	body, _ := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &loginResponse)
+	assert.Nil(suite.T(), err, "Invalid response json")
+	assert.NotEmpty(suite.T(), loginResponse.AccessToken)
+	assert.NotEmpty(suite.T(), loginResponse.RefreshToken)
+	loginUser, err := core.GetUserFromJWTToken(models.DBConn, loginResponse.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.ID, loginUser.ID)
+
+	// When discoverable login (without username)
+	resp = suite.loginPasskey("", laptop)
+
+	// Expect sign count stored
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+	credentials, err = repository.GetAllUserWebAuthnCredential(models.DBConn, user.ID)
+	assert.Nil(suite.T(), err)
+	for _, credential := range credentials {
+		assert.Equal(suite.T(), int64(1), credential.SignCount)
+		assert.NotNil(suite.T(), credential.LastUsedAt)
+	}
+
+	// When removed passkey
+	req, _ := http.NewRequest("DELETE", "/user/me/webauthn-credentials/"+credentials[0].ID, nil)
+	req.Header.Set("authorization", "Bearer "+token)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 204, resp.StatusCode)
+
+	// Expect could not login using removed passkey
+	removed := phone
+	if credentials[0].Name == "laptop" {
+		removed = laptop
+	}
+	resp = suite.loginPasskey("", removed)
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestLoginLockedUser() {
+	// Given user locked by failed login
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+	laptop := webauthntest.NewAuthenticator("http://localhost:8000")
+	assert.Equal(suite.T(), 201, suite.registerPasskey(token, laptop, "laptop").StatusCode)
+	models.DBConn.Model(&models.User{}).Where("id = ?", user.ID).Update("locked_until", time.Now().Add(time.Minute))
+
+	// When
+	resp := suite.loginPasskey("test", laptop)
+
+	// Expect
+	assert.Equal(suite.T(), 429, resp.StatusCode)
+	assert.NotEmpty(suite.T(), resp.Header.Get("Retry-After"))
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestRegisterScopedToken() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateScopedJWTTokenFromUser(models.DBConn, user, "user:read")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When
+	resp := suite.postJSON("/auth/webauthn/register/begin", token, nil)
+
+	// Expect
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// Expect unscoped api key could not register passkey either
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, user.ID, "ci", []string{}, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	req, _ := http.NewRequest("POST", "/auth/webauthn/register/begin", nil)
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestManageCredentialScopedToken() {
+	// Given
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+	laptop := webauthntest.NewAuthenticator("http://localhost:8000")
+	assert.Equal(suite.T(), 201, suite.registerPasskey(token, laptop, "laptop").StatusCode)
+	credential := models.WebAuthnCredential{}
+	models.DBConn.Where("user_id = ?", user.ID).First(&credential)
+	scopedToken, err := core.GenerateScopedJWTTokenFromUser(models.DBConn, user, "user:read")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// When rename
+	req, _ := http.NewRequest("PUT", "/user/me/webauthn-credentials/"+credential.ID, bytes.NewBufferString(`{"name": "renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "Bearer "+scopedToken)
+	resp, err := suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When delete
+	req, _ = http.NewRequest("DELETE", "/user/me/webauthn-credentials/"+credential.ID, nil)
+	req.Header.Set("authorization", "Bearer "+scopedToken)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+
+	// When rename and delete with unscoped api key
+	_, rawKey, err := repository.CreateApiKey(models.DBConn, user.ID, "ci", []string{}, nil, time.Now())
+	if err != nil {
+		panic(err.Error())
+	}
+	req, _ = http.NewRequest("PUT", "/user/me/webauthn-credentials/"+credential.ID, bytes.NewBufferString(`{"name": "renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err = suite.app.Test(req, suite.timeout)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	req, _ = http.NewRequest("DELETE", "/user/me/webauthn-credentials/"+credential.ID, nil)
+	req.Header.Set("X-API-Key", rawKey)
+	resp, err = suite.app.Test(req, suite.timeout)
+
+	// Expect passkey kept unchanged
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	credential, err = repository.GetUserWebAuthnCredentialById(models.DBConn, user.ID, credential.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "laptop", credential.Name)
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestLoginBeginUnknownUsername() {
+	// Given user without passkey
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+
+	// When
+	unknownResp := suite.postJSON("/auth/webauthn/login/begin", "", map[string]string{"username": "unknown"})
+	noPasskeyResp := suite.postJSON("/auth/webauthn/login/begin", "", map[string]string{"username": "test"})
+
+	// Expect the same challenge, registered username not revealed
+	assert.Equal(suite.T(), 200, unknownResp.StatusCode)
+	assert.Equal(suite.T(), 200, noPasskeyResp.StatusCode)
+	unknownBegin := schemas.WebAuthnLoginBeginResponse{}
+	body, _ := io.ReadAll(unknownResp.Body)
+	assert.Nil(suite.T(), json.Unmarshal(body, &unknownBegin))
+	noPasskeyBegin := schemas.WebAuthnLoginBeginResponse{}
+	body, _ = io.ReadAll(noPasskeyResp.Body)
+	assert.Nil(suite.T(), json.Unmarshal(body, &noPasskeyBegin))
+	assert.Empty(suite.T(), unknownBegin.Options.Response.AllowedCredentials)
+	assert.Empty(suite.T(), noPasskeyBegin.Options.Response.AllowedCredentials)
+}
+
+func (suite *MigrateWebAuthnTestSuite) TestLoginLDAPUser() {
+	// Given LDAP user with passkey
+	timeZoneAsiaJakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err.Error())
+	}
+	user := models.User{
+		Email:       "test@test.com",
+		Username:    "test",
+		Password:    "Fakepassword",
+		IsActive:    true,
+		IsSuperuser: false,
+		CreatedAt:   time.Date(2022, 10, 5, 10, 0, 0, 0, timeZoneAsiaJakarta),
+	}
+	models.DBConn.Create(&user)
+	token, err := core.GenerateJWTTokenFromUser(models.DBConn, user)
+	if err != nil {
+		panic(err.Error())
+	}
+	laptop := webauthntest.NewAuthenticator("http://localhost:8000")
+	assert.Equal(suite.T(), 201, suite.registerPasskey(token, laptop, "laptop").StatusCode)
+	models.DBConn.Model(&models.User{}).Where("id = ?", user.ID).Update("ldap_dn", "uid=test,ou=people,dc=example,dc=com")
+
+	// When
+	resp := suite.loginPasskey("", laptop)
+
+	// Expect
+	assert.Equal(suite.T(), 400, resp.StatusCode)
+}
+
+func (suite *MigrateWebAuthnTestSuite) TearDownTest() {
+	models.ClearAllData()
+}
+
+func TestMigrateWebAuthnTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateWebAuthnTestSuite))
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/schemas"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JSON Web Key Set
+//
+//	@Summary		JSON Web Key Set
+//	@Description	public keys to verify access token, empty when token signed using HS256
+//	@Tags			Well Known
+//	@Produce		json
+//	@Success		200	{object}	schemas.JWKSResponse
+//	@Failure		500	{object}	schemas.InternalServerErrorResponse
+//	@Router			/.well-known/jwks.json [get]
+func wellKnownJWKSRoute(c *fiber.Ctx) error {
+	set, err := core.GetJWKS()
+	if err != nil {
+		return c.Status(500).JSON(schemas.InternalServerErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(set)
+}
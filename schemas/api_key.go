@@ -0,0 +1,38 @@
+package schemas
+
+import "time"
+
+type ApiKeyDetailResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scope      string     `json:"scope"`
+	ExpiredAt  *time.Time `json:"expired_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ApiKeyListResponse struct {
+	Results []ApiKeyDetailResponse `json:"results"`
+}
+
+type ApiKeyCreateRequest struct {
+	Name      string     `json:"name" validate:"required"`
+	Scope     string     `json:"scope"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+type ApiKeyCreateResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scope      string     `json:"scope"`
+	ExpiredAt  *time.Time `json:"expired_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ApiKeyUpdateRequest struct {
+	Name string `json:"name" validate:"required"`
+}
@@ -1,16 +1,94 @@
 package schemas
 
+import "time"
+
 type LoginFormRequest struct {
 	Username string `form:"username"`
 	Password string `form:"password"`
+	Scope    string `form:"scope"`
 }
 
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type RefreshTokenFormRequest struct {
+	RefreshToken string `form:"refresh_token"`
+}
+
+type LogoutFormRequest struct {
+	RefreshToken string `form:"refresh_token"`
+}
+
+type LogoutAllFormRequest struct {
+	// RFC3339 timestamp, default to now
+	Before string `form:"before"`
 }
 
 type LogoutResponse struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 }
+
+type ForgotPasswordFormRequest struct {
+	Email string `form:"email" validate:"required"`
+}
+
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+type ResetPasswordFormRequest struct {
+	Token    string `form:"token" validate:"required"`
+	Password string `form:"password" validate:"required"`
+}
+
+type ResetPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+type MagicLinkFormRequest struct {
+	Email string `form:"email" validate:"required"`
+}
+
+type MagicLinkResponse struct {
+	Message string `json:"message"`
+}
+
+type MagicLinkVerifyFormRequest struct {
+	Token string `form:"token" validate:"required"`
+}
+
+type VerifyEmailFormRequest struct {
+	Token string `form:"token" validate:"required"`
+}
+
+type VerifyEmailResponse struct {
+	Email           string    `json:"email"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+}
+
+type ResendEmailVerificationFormRequest struct {
+	Email string `form:"email" validate:"required"`
+}
+
+type ResendEmailVerificationResponse struct {
+	Message string `json:"message"`
+}
+
+type RegisterFormRequest struct {
+	Username     string `form:"username" validate:"required"`
+	Email        string `form:"email" validate:"required,email"`
+	Password     string `form:"password" validate:"required"`
+	CaptchaToken string `form:"captcha_token"`
+}
+
+type RegisterResponse struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
@@ -20,6 +20,10 @@ type UnprocessableEntityResponse struct {
 	Message []map[string]string `json:"message"`
 }
 
+type TooManyRequestsResponse struct {
+	Message string `json:"message"`
+}
+
 type InternalServerErrorResponse struct {
 	Error string `json:"error"`
 }
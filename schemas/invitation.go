@@ -0,0 +1,31 @@
+package schemas
+
+import "time"
+
+type InvitationDetailResponse struct {
+	Id         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       *string    `json:"role"`
+	InvitedBy  *string    `json:"invited_by"`
+	Status     string     `json:"status"`
+	ExpiredAt  time.Time  `json:"expired_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type InvitationListResponse struct {
+	Results []InvitationDetailResponse `json:"results"`
+}
+
+type InvitationCreateRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	// role name pre-assigned to invitee (optional)
+	Role string `json:"role"`
+}
+
+type AcceptInvitationFormRequest struct {
+	Token    string `form:"token" validate:"required"`
+	Username string `form:"username" validate:"required"`
+	Password string `form:"password" validate:"required"`
+}
@@ -0,0 +1,44 @@
+package schemas
+
+type OAuthAuthorizeQuery struct {
+	ResponseType        string `query:"response_type"`
+	ClientId            string `query:"client_id"`
+	RedirectUri         string `query:"redirect_uri"`
+	Scope               string `query:"scope"`
+	State               string `query:"state"`
+	CodeChallenge       string `query:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method"`
+}
+
+type OAuthAuthorizeRequest struct {
+	Username            string `json:"username" form:"username"`
+	Password            string `json:"password" form:"password"`
+	Otp                 string `json:"otp" form:"otp"`
+	ResponseType        string `json:"response_type" form:"response_type"`
+	ClientId            string `json:"client_id" form:"client_id"`
+	RedirectUri         string `json:"redirect_uri" form:"redirect_uri"`
+	Scope               string `json:"scope" form:"scope"`
+	State               string `json:"state" form:"state"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+}
+
+type OAuthAuthorizeResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type OAuthTokenFormRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectUri  string `form:"redirect_uri"`
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
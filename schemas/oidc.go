@@ -0,0 +1,8 @@
+package schemas
+
+type OIDCCallbackQuery struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+}
@@ -0,0 +1,18 @@
+package schemas
+
+import "time"
+
+// SessionDetailResponse Current is true for session of the access token used on the request
+type SessionDetailResponse struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IpAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type SessionListResponse struct {
+	Results []SessionDetailResponse `json:"results"`
+}
@@ -0,0 +1,30 @@
+package schemas
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+type LoginTwoFactorFormRequest struct {
+	ChallengeToken string `form:"challenge_token"`
+	Code           string `form:"code"`
+}
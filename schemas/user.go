@@ -1,5 +1,7 @@
 package schemas
 
+import "time"
+
 type UserDetailResponse struct {
 	Id          string `json:"id"`
 	Username    string `json:"username"`
@@ -18,7 +20,7 @@ type UserPaginateResponse struct {
 
 type UserCreateRequest struct {
 	Username    string `json:"username" validate:"required"`
-	Email       string `json:"email" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
 	Password    string `json:"password" validate:"required"`
 	IsActive    bool   `json:"is_active" validate:"required"`
 	IsSuperuser bool   `json:"is_superuser" validate:"required"`
@@ -34,7 +36,7 @@ type UserCreateResponse struct {
 
 type UserUpdateRequest struct {
 	Username    string  `json:"username" validate:"required"`
-	Email       string  `json:"email" validate:"required"`
+	Email       string  `json:"email" validate:"required,email"`
 	Password    *string `json:"password"`
 	IsActive    bool    `json:"is_active" validate:"required"`
 	IsSuperuser bool    `json:"is_superuser" validate:"required"`
@@ -47,3 +49,40 @@ type UserUpdateResponse struct {
 	IsActive    bool   `json:"is_active"`
 	IsSuperuser bool   `json:"is_superuser"`
 }
+
+type UserLockoutResponse struct {
+	Id                string     `json:"id"`
+	FailedLoginCount  int        `json:"failed_login_count"`
+	LastFailedLoginAt *time.Time `json:"last_failed_login_at"`
+	LockedUntil       *time.Time `json:"locked_until"`
+	IsLocked          bool       `json:"is_locked"`
+}
+
+type UserDeactivateRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type UserAccountStatusResponse struct {
+	Id                string     `json:"id"`
+	IsActive          bool       `json:"is_active"`
+	DeactivatedAt     *time.Time `json:"deactivated_at"`
+	DeactivatedReason *string    `json:"deactivated_reason"`
+	DeactivatedById   *string    `json:"deactivated_by_id"`
+}
+
+type UserImpersonateRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type UserImpersonateResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int       `json:"expires_in"`
+	ExpiredAt   time.Time `json:"expired_at"`
+	UserId      string    `json:"user_id"`
+	ActorId     string    `json:"actor_id"`
+}
+
+type UserEndImpersonationResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,51 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type WebAuthnRegisterBeginResponse struct {
+	Options      protocol.CredentialCreation `json:"options" swaggertype:"object"`
+	SessionToken string                      `json:"session_token"`
+}
+
+type WebAuthnRegisterFinishRequest struct {
+	SessionToken string          `json:"session_token" validate:"required"`
+	Name         string          `json:"name" validate:"required"`
+	Credential   json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+type WebAuthnLoginBeginRequest struct {
+	Username string `json:"username"`
+}
+
+type WebAuthnLoginBeginResponse struct {
+	Options      protocol.CredentialAssertion `json:"options" swaggertype:"object"`
+	SessionToken string                       `json:"session_token"`
+}
+
+type WebAuthnLoginFinishRequest struct {
+	SessionToken string          `json:"session_token" validate:"required"`
+	Credential   json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+	Scope        string          `json:"scope"`
+}
+
+type WebAuthnCredentialDetailResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	SignCount  int64      `json:"sign_count"`
+	Transport  string     `json:"transport"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type WebAuthnCredentialListResponse struct {
+	Results []WebAuthnCredentialDetailResponse `json:"results"`
+}
+
+type WebAuthnCredentialUpdateRequest struct {
+	Name string `json:"name" validate:"required"`
+}
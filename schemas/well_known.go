@@ -0,0 +1,5 @@
+package schemas
+
+type JWKSResponse struct {
+	Keys []map[string]interface{} `json:"keys"`
+}
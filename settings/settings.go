@@ -3,6 +3,7 @@ package settings
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -24,7 +25,117 @@ var POSTGRESQL_TIMEZONE string
 
 // JWT secret
 var JWT_SECRET string
+var JWT_ALGORITHM string
+var JWT_PRIVATE_KEY_PATH string
+var JWT_PUBLIC_KEY_PATH string
+var JWT_KEY_RING_PATH string
 var ACCESS_TOKEN_EXPIRE_MINUTES int
+var REFRESH_TOKEN_EXPIRE_MINUTES int
+
+// Impersonation, token of superuser impersonating user has no refresh token
+var IMPERSONATION_EXPIRE_MINUTES int
+
+// OAuth2
+var OAUTH_AUTHORIZATION_CODE_EXPIRE_MINUTES int
+
+// Password hashing, PASSWORD_HASHER is bcrypt or argon2id (ARGON2_MEMORY_KIB in KiB)
+var PASSWORD_HASHER string
+var BCRYPT_COST int
+var ARGON2_MEMORY_KIB int
+var ARGON2_ITERATIONS int
+var ARGON2_PARALLELISM int
+
+// Password policy, PASSWORD_REQUIRED_CHARACTER_CLASSES is space separated lowercase, uppercase, digit or symbol.
+// PASSWORD_BREACHED_LIST_PATH is file of breached password SHA-1 hash, empty disable the check.
+// PASSWORD_HISTORY_COUNT is number of last password could not be reused, 0 disable the check
+var PASSWORD_MIN_LENGTH int
+var PASSWORD_REQUIRED_CHARACTER_CLASSES string
+var PASSWORD_FORBID_USER_INFO bool
+var PASSWORD_BREACHED_LIST_PATH string
+var PASSWORD_HISTORY_COUNT int
+
+// Login identifier, LOGIN_IDENTIFIER is username, email or username_or_email
+var LOGIN_IDENTIFIER string
+
+// Login lockout
+var LOGIN_MAX_FAILED_ATTEMPTS int
+var LOGIN_IP_MAX_FAILED_ATTEMPTS int
+var LOGIN_LOCKOUT_MINUTES int
+var LOGIN_LOCKOUT_MAX_MINUTES int
+
+// Two factor authentication
+var TOTP_ISSUER string
+var TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES int
+
+// WebAuthn, WEBAUTHN_RP_ORIGINS is space separated
+var WEBAUTHN_RP_ID string
+var WEBAUTHN_RP_DISPLAY_NAME string
+var WEBAUTHN_RP_ORIGINS string
+var WEBAUTHN_SESSION_EXPIRE_MINUTES int
+
+// Password reset, PASSWORD_RESET_URL is link sent to user with the token appended
+var PASSWORD_RESET_TOKEN_EXPIRE_MINUTES int
+var PASSWORD_RESET_URL string
+
+// Magic link login, MAGIC_LINK_URL is link sent to user with the token appended.
+// new link not sent to the same email within MAGIC_LINK_RESEND_SECONDS
+var MAGIC_LINK_ENABLED bool
+var MAGIC_LINK_TOKEN_EXPIRE_MINUTES int
+var MAGIC_LINK_URL string
+var MAGIC_LINK_RESEND_SECONDS int
+
+// Email verification, EMAIL_VERIFICATION_POLICY is none, refuse_login or limit_scope.
+// limit_scope limit token of unverified user to space separated EMAIL_UNVERIFIED_SCOPE
+var EMAIL_VERIFICATION_POLICY string
+var EMAIL_UNVERIFIED_SCOPE string
+var EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES int
+var EMAIL_VERIFICATION_URL string
+
+// Registration, REGISTRATION_POLICY is open, invite_only or disabled.
+// REGISTRATION_EMAIL_DOMAINS is space separated allowed email domain, empty allow every domain
+var REGISTRATION_POLICY string
+var REGISTRATION_EMAIL_DOMAINS string
+
+// Invitation, INVITATION_URL is link sent to invitee with the token appended
+var INVITATION_EXPIRE_MINUTES int
+var INVITATION_URL string
+
+// OpenID Connect login, disabled if OIDC_ISSUER empty. OIDC_SCOPE is space separated,
+// OIDC_CREATE_USER create user on first login of email not registered yet
+var OIDC_ISSUER string
+var OIDC_CLIENT_ID string
+var OIDC_CLIENT_SECRET string
+var OIDC_REDIRECT_URL string
+var OIDC_SCOPE string
+var OIDC_CREATE_USER bool
+var OIDC_SESSION_EXPIRE_MINUTES int
+
+// Login authenticator, AUTHENTICATORS is space separated password and/or ldap tried in order
+var AUTHENTICATORS string
+
+// LDAP authenticator, user searched by LDAP_USER_FILTER (%s replaced with escaped username)
+// under LDAP_BASE_DN using LDAP_BIND_DN (anonymous if empty) then bound with the user password.
+// LDAP_GROUP_ROLES is semicolon separated <group dn>:<role name> pair
+var LDAP_URL string
+var LDAP_START_TLS bool
+var LDAP_BIND_DN string
+var LDAP_BIND_PASSWORD string
+var LDAP_BASE_DN string
+var LDAP_USER_FILTER string
+var LDAP_USERNAME_ATTRIBUTE string
+var LDAP_EMAIL_ATTRIBUTE string
+var LDAP_GROUP_ATTRIBUTE string
+var LDAP_GROUP_ROLES string
+
+// SMTP notifier, notification kept in memory if SMTP_HOST empty
+var SMTP_HOST string
+var SMTP_PORT string
+var SMTP_USERNAME string
+var SMTP_PASSWORD string
+var SMTP_FROM string
+
+// Template
+var TEMPLATE_DIRECTORY string
 
 func EnvToInt(key string) (int, error) {
 	valueString := os.Getenv(key)
@@ -32,6 +143,27 @@ func EnvToInt(key string) (int, error) {
 	return valueInt, err
 }
 
+func EnvOrDefault(key string, defaultValue string) string {
+	if os.Getenv(key) == "" {
+		return defaultValue
+	}
+	return os.Getenv(key)
+}
+
+func EnvToIntOrDefault(key string, defaultValue int) (int, error) {
+	if os.Getenv(key) == "" {
+		return defaultValue, nil
+	}
+	return EnvToInt(key)
+}
+
+func EnvToBoolOrDefault(key string, defaultValue bool) (bool, error) {
+	if os.Getenv(key) == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseBool(os.Getenv(key))
+}
+
 func InitiateSettings(pathToEnvFile string) {
 	var err error
 	if os.Getenv("ENVIRONTMENT") != "PROD" {
@@ -53,8 +185,180 @@ func InitiateSettings(pathToEnvFile string) {
 	POSTGRESQL_SSL_MODE = os.Getenv("POSTGRESQL_SSL_MODE")
 	POSTGRESQL_TIMEZONE = os.Getenv("POSTGRESQL_TIMEZONE")
 	JWT_SECRET = os.Getenv("JWT_SECRET")
+	JWT_ALGORITHM = os.Getenv("JWT_ALGORITHM")
+	JWT_PRIVATE_KEY_PATH = os.Getenv("JWT_PRIVATE_KEY_PATH")
+	JWT_PUBLIC_KEY_PATH = os.Getenv("JWT_PUBLIC_KEY_PATH")
+	JWT_KEY_RING_PATH = os.Getenv("JWT_KEY_RING_PATH")
 	ACCESS_TOKEN_EXPIRE_MINUTES, err = EnvToInt("ACCESS_TOKEN_EXPIRE_MINUTES")
 	if err != nil {
 		panic("ACCESS_TOKEN_EXPIRE_MINUTES not defined on env or not a number")
 	}
+	REFRESH_TOKEN_EXPIRE_MINUTES, err = EnvToIntOrDefault("REFRESH_TOKEN_EXPIRE_MINUTES", 10080)
+	if err != nil {
+		panic("REFRESH_TOKEN_EXPIRE_MINUTES is not a number")
+	}
+	IMPERSONATION_EXPIRE_MINUTES, err = EnvToIntOrDefault("IMPERSONATION_EXPIRE_MINUTES", 15)
+	if err != nil {
+		panic("IMPERSONATION_EXPIRE_MINUTES is not a number")
+	}
+	OAUTH_AUTHORIZATION_CODE_EXPIRE_MINUTES, err = EnvToIntOrDefault("OAUTH_AUTHORIZATION_CODE_EXPIRE_MINUTES", 10)
+	if err != nil {
+		panic("OAUTH_AUTHORIZATION_CODE_EXPIRE_MINUTES is not a number")
+	}
+	PASSWORD_HASHER = EnvOrDefault("PASSWORD_HASHER", "argon2id")
+	if PASSWORD_HASHER != "bcrypt" && PASSWORD_HASHER != "argon2id" {
+		panic("PASSWORD_HASHER should bcrypt or argon2id")
+	}
+	BCRYPT_COST, err = EnvToIntOrDefault("BCRYPT_COST", 12)
+	if err != nil {
+		panic("BCRYPT_COST is not a number")
+	}
+	if BCRYPT_COST < 4 || BCRYPT_COST > 31 {
+		panic("BCRYPT_COST should between 4 and 31")
+	}
+	ARGON2_MEMORY_KIB, err = EnvToIntOrDefault("ARGON2_MEMORY_KIB", 19456)
+	if err != nil {
+		panic("ARGON2_MEMORY_KIB is not a number")
+	}
+	ARGON2_ITERATIONS, err = EnvToIntOrDefault("ARGON2_ITERATIONS", 2)
+	if err != nil {
+		panic("ARGON2_ITERATIONS is not a number")
+	}
+	ARGON2_PARALLELISM, err = EnvToIntOrDefault("ARGON2_PARALLELISM", 1)
+	if err != nil {
+		panic("ARGON2_PARALLELISM is not a number")
+	}
+	if ARGON2_MEMORY_KIB < 1 || ARGON2_ITERATIONS < 1 || ARGON2_PARALLELISM < 1 || ARGON2_PARALLELISM > 255 {
+		panic("ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and ARGON2_PARALLELISM should positive (ARGON2_PARALLELISM up to 255)")
+	}
+	PASSWORD_MIN_LENGTH, err = EnvToIntOrDefault("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		panic("PASSWORD_MIN_LENGTH is not a number")
+	}
+	PASSWORD_REQUIRED_CHARACTER_CLASSES = os.Getenv("PASSWORD_REQUIRED_CHARACTER_CLASSES")
+	for _, characterClass := range strings.Fields(PASSWORD_REQUIRED_CHARACTER_CLASSES) {
+		if characterClass != "lowercase" && characterClass != "uppercase" && characterClass != "digit" && characterClass != "symbol" {
+			panic("PASSWORD_REQUIRED_CHARACTER_CLASSES should lowercase, uppercase, digit or symbol")
+		}
+	}
+	PASSWORD_FORBID_USER_INFO, err = EnvToBoolOrDefault("PASSWORD_FORBID_USER_INFO", false)
+	if err != nil {
+		panic("PASSWORD_FORBID_USER_INFO is not a boolean")
+	}
+	PASSWORD_BREACHED_LIST_PATH = os.Getenv("PASSWORD_BREACHED_LIST_PATH")
+	PASSWORD_HISTORY_COUNT, err = EnvToIntOrDefault("PASSWORD_HISTORY_COUNT", 0)
+	if err != nil {
+		panic("PASSWORD_HISTORY_COUNT is not a number")
+	}
+	LOGIN_IDENTIFIER = EnvOrDefault("LOGIN_IDENTIFIER", "username_or_email")
+	if LOGIN_IDENTIFIER != "username" && LOGIN_IDENTIFIER != "email" && LOGIN_IDENTIFIER != "username_or_email" {
+		panic("LOGIN_IDENTIFIER should username, email or username_or_email")
+	}
+	LOGIN_MAX_FAILED_ATTEMPTS, err = EnvToIntOrDefault("LOGIN_MAX_FAILED_ATTEMPTS", 5)
+	if err != nil {
+		panic("LOGIN_MAX_FAILED_ATTEMPTS is not a number")
+	}
+	LOGIN_IP_MAX_FAILED_ATTEMPTS, err = EnvToIntOrDefault("LOGIN_IP_MAX_FAILED_ATTEMPTS", 20)
+	if err != nil {
+		panic("LOGIN_IP_MAX_FAILED_ATTEMPTS is not a number")
+	}
+	LOGIN_LOCKOUT_MINUTES, err = EnvToIntOrDefault("LOGIN_LOCKOUT_MINUTES", 1)
+	if err != nil {
+		panic("LOGIN_LOCKOUT_MINUTES is not a number")
+	}
+	LOGIN_LOCKOUT_MAX_MINUTES, err = EnvToIntOrDefault("LOGIN_LOCKOUT_MAX_MINUTES", 60)
+	if err != nil {
+		panic("LOGIN_LOCKOUT_MAX_MINUTES is not a number")
+	}
+	TOTP_ISSUER = EnvOrDefault("TOTP_ISSUER", "fiberGormBoilerplate")
+	TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES, err = EnvToIntOrDefault("TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES", 5)
+	if err != nil {
+		panic("TWO_FACTOR_CHALLENGE_EXPIRE_MINUTES is not a number")
+	}
+	WEBAUTHN_RP_ID = EnvOrDefault("WEBAUTHN_RP_ID", "localhost")
+	WEBAUTHN_RP_DISPLAY_NAME = EnvOrDefault("WEBAUTHN_RP_DISPLAY_NAME", "fiberGormBoilerplate")
+	WEBAUTHN_RP_ORIGINS = EnvOrDefault("WEBAUTHN_RP_ORIGINS", "http://localhost:"+SERVER_PORT)
+	WEBAUTHN_SESSION_EXPIRE_MINUTES, err = EnvToIntOrDefault("WEBAUTHN_SESSION_EXPIRE_MINUTES", 5)
+	if err != nil {
+		panic("WEBAUTHN_SESSION_EXPIRE_MINUTES is not a number")
+	}
+	PASSWORD_RESET_TOKEN_EXPIRE_MINUTES, err = EnvToIntOrDefault("PASSWORD_RESET_TOKEN_EXPIRE_MINUTES", 30)
+	if err != nil {
+		panic("PASSWORD_RESET_TOKEN_EXPIRE_MINUTES is not a number")
+	}
+	PASSWORD_RESET_URL = EnvOrDefault("PASSWORD_RESET_URL", "http://localhost:"+SERVER_PORT+"/reset-password?token=")
+	MAGIC_LINK_ENABLED, err = EnvToBoolOrDefault("MAGIC_LINK_ENABLED", false)
+	if err != nil {
+		panic("MAGIC_LINK_ENABLED is not a boolean")
+	}
+	MAGIC_LINK_TOKEN_EXPIRE_MINUTES, err = EnvToIntOrDefault("MAGIC_LINK_TOKEN_EXPIRE_MINUTES", 15)
+	if err != nil {
+		panic("MAGIC_LINK_TOKEN_EXPIRE_MINUTES is not a number")
+	}
+	MAGIC_LINK_URL = EnvOrDefault("MAGIC_LINK_URL", "http://localhost:"+SERVER_PORT+"/magic-link?token=")
+	MAGIC_LINK_RESEND_SECONDS, err = EnvToIntOrDefault("MAGIC_LINK_RESEND_SECONDS", 60)
+	if err != nil {
+		panic("MAGIC_LINK_RESEND_SECONDS is not a number")
+	}
+	EMAIL_VERIFICATION_POLICY = EnvOrDefault("EMAIL_VERIFICATION_POLICY", "none")
+	if EMAIL_VERIFICATION_POLICY != "none" && EMAIL_VERIFICATION_POLICY != "refuse_login" && EMAIL_VERIFICATION_POLICY != "limit_scope" {
+		panic("EMAIL_VERIFICATION_POLICY should none, refuse_login or limit_scope")
+	}
+	EMAIL_UNVERIFIED_SCOPE = EnvOrDefault("EMAIL_UNVERIFIED_SCOPE", "user:read")
+	EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES, err = EnvToIntOrDefault("EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES", 1440)
+	if err != nil {
+		panic("EMAIL_VERIFICATION_TOKEN_EXPIRE_MINUTES is not a number")
+	}
+	EMAIL_VERIFICATION_URL = EnvOrDefault("EMAIL_VERIFICATION_URL", "http://localhost:"+SERVER_PORT+"/verify-email?token=")
+	REGISTRATION_POLICY = EnvOrDefault("REGISTRATION_POLICY", "disabled")
+	if REGISTRATION_POLICY != "open" && REGISTRATION_POLICY != "invite_only" && REGISTRATION_POLICY != "disabled" {
+		panic("REGISTRATION_POLICY should open, invite_only or disabled")
+	}
+	REGISTRATION_EMAIL_DOMAINS = os.Getenv("REGISTRATION_EMAIL_DOMAINS")
+	INVITATION_EXPIRE_MINUTES, err = EnvToIntOrDefault("INVITATION_EXPIRE_MINUTES", 10080)
+	if err != nil {
+		panic("INVITATION_EXPIRE_MINUTES is not a number")
+	}
+	INVITATION_URL = EnvOrDefault("INVITATION_URL", "http://localhost:"+SERVER_PORT+"/accept-invitation?token=")
+	OIDC_ISSUER = os.Getenv("OIDC_ISSUER")
+	OIDC_CLIENT_ID = os.Getenv("OIDC_CLIENT_ID")
+	OIDC_CLIENT_SECRET = os.Getenv("OIDC_CLIENT_SECRET")
+	OIDC_REDIRECT_URL = EnvOrDefault("OIDC_REDIRECT_URL", "http://localhost:"+SERVER_PORT+"/auth/oidc/callback")
+	OIDC_SCOPE = EnvOrDefault("OIDC_SCOPE", "openid email profile")
+	OIDC_CREATE_USER, err = EnvToBoolOrDefault("OIDC_CREATE_USER", true)
+	if err != nil {
+		panic("OIDC_CREATE_USER is not a boolean")
+	}
+	OIDC_SESSION_EXPIRE_MINUTES, err = EnvToIntOrDefault("OIDC_SESSION_EXPIRE_MINUTES", 10)
+	if err != nil {
+		panic("OIDC_SESSION_EXPIRE_MINUTES is not a number")
+	}
+	AUTHENTICATORS = EnvOrDefault("AUTHENTICATORS", "password")
+	if len(strings.Fields(AUTHENTICATORS)) == 0 {
+		panic("AUTHENTICATORS should password and/or ldap")
+	}
+	for _, authenticator := range strings.Fields(AUTHENTICATORS) {
+		if authenticator != "password" && authenticator != "ldap" {
+			panic("AUTHENTICATORS should password and/or ldap")
+		}
+	}
+	LDAP_URL = os.Getenv("LDAP_URL")
+	LDAP_START_TLS, err = EnvToBoolOrDefault("LDAP_START_TLS", false)
+	if err != nil {
+		panic("LDAP_START_TLS is not a boolean")
+	}
+	LDAP_BIND_DN = os.Getenv("LDAP_BIND_DN")
+	LDAP_BIND_PASSWORD = os.Getenv("LDAP_BIND_PASSWORD")
+	LDAP_BASE_DN = os.Getenv("LDAP_BASE_DN")
+	LDAP_USER_FILTER = EnvOrDefault("LDAP_USER_FILTER", "(uid=%s)")
+	LDAP_USERNAME_ATTRIBUTE = EnvOrDefault("LDAP_USERNAME_ATTRIBUTE", "uid")
+	LDAP_EMAIL_ATTRIBUTE = EnvOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail")
+	LDAP_GROUP_ATTRIBUTE = EnvOrDefault("LDAP_GROUP_ATTRIBUTE", "memberOf")
+	LDAP_GROUP_ROLES = os.Getenv("LDAP_GROUP_ROLES")
+	SMTP_HOST = os.Getenv("SMTP_HOST")
+	SMTP_PORT = EnvOrDefault("SMTP_PORT", "587")
+	SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+	SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
+	SMTP_FROM = os.Getenv("SMTP_FROM")
+	TEMPLATE_DIRECTORY = EnvOrDefault("TEMPLATE_DIRECTORY", "templates")
 }
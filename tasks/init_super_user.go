@@ -1,8 +1,10 @@
 package tasks
 
 import (
+	"strings"
 	"time"
 
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
 	"github.com/BimaAdi/fiberGormBoilerplate/repository"
 	"github.com/BimaAdi/fiberGormBoilerplate/settings"
@@ -15,6 +17,22 @@ func CreateSuperUser(envPath string, email string, username string, password str
 	// Initiate Database connection
 	models.Initiate()
 
+	violations, err := core.CheckPasswordPolicy(password, username, email)
+	if err != nil {
+		panic(err.Error())
+	}
+	if len(violations) > 0 {
+		panic(strings.Join(violations, ", "))
+	}
+
 	now := time.Now()
-	repository.CreateUser(models.DBConn, username, email, password, true, true, now, &now)
+	user, err := repository.CreateUser(models.DBConn, username, email, password, true, true, now, &now)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// email given by operator, no need to verify
+	if _, err := repository.MarkUserEmailVerified(models.DBConn, user, now); err != nil {
+		panic(err.Error())
+	}
 }
@@ -27,4 +27,5 @@ func TestCreateSuperUser(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, createdUser)
 	assert.True(t, core.CheckPasswordHash("password", createdUser.Password))
+	assert.NotNil(t, createdUser.EmailVerifiedAt)
 }
@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+)
+
+// readJWTKeyRing read key ring from JWT_KEY_RING_PATH,
+// new key ring start with key from JWT_SECRET or JWT_PRIVATE_KEY_PATH as active key
+func readJWTKeyRing(envPath string) (core.JWTKeyRing, error) {
+	settings.InitiateSettings(envPath)
+	if settings.JWT_KEY_RING_PATH == "" {
+		return core.JWTKeyRing{}, errors.New("JWT_KEY_RING_PATH not defined on env")
+	}
+
+	ring, err := core.ReadJWTKeyRing(settings.JWT_KEY_RING_PATH)
+	if err != nil {
+		return ring, err
+	}
+	if len(ring.Keys) == 0 {
+		entry, err := core.NewJWTKeyRingEntryFromSettings(time.Now())
+		if err != nil {
+			return ring, err
+		}
+		ring.Add(entry)
+	}
+	return ring, nil
+}
+
+func defaultJWTAlgorithm() string {
+	if settings.JWT_ALGORITHM == "" {
+		return "HS256"
+	}
+	return settings.JWT_ALGORITHM
+}
+
+func ListJWTKey(envPath string) ([]core.JWTKeyRingEntry, error) {
+	ring, err := readJWTKeyRing(envPath)
+	return ring.Keys, err
+}
+
+// GenerateJWTKey add new key to key ring, new key is inactive unless promote is true
+func GenerateJWTKey(envPath string, algorithm string, promote bool) (core.JWTKeyRingEntry, error) {
+	ring, err := readJWTKeyRing(envPath)
+	if err != nil {
+		return core.JWTKeyRingEntry{}, err
+	}
+	if algorithm == "" {
+		algorithm = defaultJWTAlgorithm()
+	}
+
+	entry, err := core.NewJWTKeyRingEntry(algorithm, time.Now())
+	if err != nil {
+		return entry, err
+	}
+	ring.Add(entry)
+	if promote {
+		if err := ring.Promote(entry.KeyID); err != nil {
+			return entry, err
+		}
+		entry.Status = core.JWTKeyStatusActive
+	}
+	return entry, core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring)
+}
+
+func PromoteJWTKey(envPath string, kid string) error {
+	ring, err := readJWTKeyRing(envPath)
+	if err != nil {
+		return err
+	}
+	if err := ring.Promote(kid); err != nil {
+		return err
+	}
+	return core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring)
+}
+
+func RetireJWTKey(envPath string, kid string) error {
+	ring, err := readJWTKeyRing(envPath)
+	if err != nil {
+		return err
+	}
+	if err := ring.Retire(kid); err != nil {
+		return err
+	}
+	return core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring)
+}
+
+// RotateJWTKey generate new active key, previous active key still valid for verification
+// and every older inactive key retired
+func RotateJWTKey(envPath string, algorithm string) (core.JWTKeyRingEntry, []string, error) {
+	ring, err := readJWTKeyRing(envPath)
+	if err != nil {
+		return core.JWTKeyRingEntry{}, nil, err
+	}
+	if algorithm == "" {
+		algorithm = defaultJWTAlgorithm()
+	}
+
+	// retire inactive key
+	retiredKeyIds := []string{}
+	for _, entry := range ring.Keys {
+		if entry.Status == core.JWTKeyStatusInactive {
+			if err := ring.Retire(entry.KeyID); err != nil {
+				return core.JWTKeyRingEntry{}, nil, err
+			}
+			retiredKeyIds = append(retiredKeyIds, entry.KeyID)
+		}
+	}
+
+	// generate and promote new key
+	entry, err := core.NewJWTKeyRingEntry(algorithm, time.Now())
+	if err != nil {
+		return entry, nil, err
+	}
+	ring.Add(entry)
+	if err := ring.Promote(entry.KeyID); err != nil {
+		return entry, nil, err
+	}
+	entry.Status = core.JWTKeyStatusActive
+	return entry, retiredKeyIds, core.WriteJWTKeyRing(settings.JWT_KEY_RING_PATH, ring)
+}
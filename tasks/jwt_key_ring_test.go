@@ -0,0 +1,37 @@
+package tasks_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateJWTKey(t *testing.T) {
+	// Given
+	t.Setenv("JWT_KEY_RING_PATH", filepath.Join(t.TempDir(), "jwt_key_ring.json"))
+	initialKeys, err := tasks.ListJWTKey("../.env")
+	assert.Nil(t, err)
+	assert.Len(t, initialKeys, 1)
+
+	// When
+	firstKey, _, err := tasks.RotateJWTKey("../.env", "EdDSA")
+	assert.Nil(t, err)
+	secondKey, retiredKeyIds, err := tasks.RotateJWTKey("../.env", "RS256")
+	assert.Nil(t, err)
+
+	// Expect
+	assert.Equal(t, []string{initialKeys[0].KeyID}, retiredKeyIds)
+	keys, err := tasks.ListJWTKey("../.env")
+	assert.Nil(t, err)
+	assert.Len(t, keys, 3)
+	statuses := map[string]string{}
+	for _, key := range keys {
+		statuses[key.KeyID] = key.Status
+	}
+	assert.Equal(t, core.JWTKeyStatusRetired, statuses[initialKeys[0].KeyID])
+	assert.Equal(t, core.JWTKeyStatusInactive, statuses[firstKey.KeyID])
+	assert.Equal(t, core.JWTKeyStatusActive, statuses[secondKey.KeyID])
+}
@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+// CreateOAuthClient register oauth2 client, public client (isConfidential false) has no client secret,
+// machine client only has client_credentials grant type.
+// Return value (client, raw client secret, error), raw client secret only shown once
+func CreateOAuthClient(envPath string, name string, redirectURIs []string, scopes []string, grantTypes []string, isConfidential bool) (models.OAuthClient, string, error) {
+	// Initialize environtment variable
+	settings.InitiateSettings(envPath)
+
+	// Initiate Database connection
+	models.Initiate()
+
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code", "refresh_token"}
+	}
+	if !core.IsSubset(grantTypes, []string{"authorization_code", "refresh_token", "client_credentials"}) {
+		return models.OAuthClient{}, "", errors.New("grant type should authorization_code, refresh_token or client_credentials")
+	}
+	if core.IsSubset([]string{"authorization_code"}, grantTypes) && len(redirectURIs) == 0 {
+		return models.OAuthClient{}, "", errors.New("at least one redirect uri required for authorization_code grant")
+	}
+	if core.IsSubset([]string{"authorization_code"}, grantTypes) && len(scopes) == 0 {
+		return models.OAuthClient{}, "", errors.New("at least one scope required for authorization_code grant")
+	}
+	if core.IsSubset([]string{"client_credentials"}, grantTypes) && !isConfidential {
+		return models.OAuthClient{}, "", errors.New("client_credentials grant only for confidential client")
+	}
+	return repository.CreateOAuthClient(models.DBConn, name, redirectURIs, scopes, grantTypes, isConfidential, time.Now())
+}
+
+func ListOAuthClient(envPath string) ([]models.OAuthClient, error) {
+	// Initialize environtment variable
+	settings.InitiateSettings(envPath)
+
+	// Initiate Database connection
+	models.Initiate()
+
+	return repository.GetAllOAuthClient(models.DBConn)
+}
+
+func DeleteOAuthClient(envPath string, clientId string) error {
+	// Initialize environtment variable
+	settings.InitiateSettings(envPath)
+
+	// Initiate Database connection
+	models.Initiate()
+
+	client, err := repository.GetOAuthClientByClientId(models.DBConn, clientId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("client " + clientId + " not found")
+		}
+		return err
+	}
+	_, err = repository.DeleteOAuthClient(models.DBConn, client)
+	return err
+}
@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BimaAdi/fiberGormBoilerplate/models"
+	"github.com/BimaAdi/fiberGormBoilerplate/repository"
+	"github.com/BimaAdi/fiberGormBoilerplate/settings"
+	"gorm.io/gorm"
+)
+
+func initiateRoleTask(envPath string) {
+	// Initialize environtment variable
+	settings.InitiateSettings(envPath)
+
+	// Initiate Database connection
+	models.Initiate()
+}
+
+func getRoleByName(name string) (models.Role, error) {
+	role, err := repository.GetRoleByName(models.DBConn, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return role, errors.New("role " + name + " not found")
+	}
+	return role, err
+}
+
+func getPermissionByName(name string) (models.Permission, error) {
+	permission, err := repository.GetPermissionByName(models.DBConn, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return permission, errors.New("permission " + name + " not found")
+	}
+	return permission, err
+}
+
+func getUserByUsername(username string) (models.User, error) {
+	user, err := repository.GetUserByUsername(models.DBConn, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, errors.New("user " + username + " not found")
+	}
+	return user, err
+}
+
+func CreateRole(envPath string, name string, description string) (models.Role, error) {
+	initiateRoleTask(envPath)
+	return repository.CreateRole(models.DBConn, name, description, time.Now())
+}
+
+// ListRole return every role with it's permissions
+func ListRole(envPath string) ([]models.Role, map[string][]string, error) {
+	initiateRoleTask(envPath)
+	roles, err := repository.GetAllRole(models.DBConn)
+	if err != nil {
+		return roles, nil, err
+	}
+	rolePermissions := map[string][]string{}
+	for _, role := range roles {
+		rolePermissions[role.ID], err = repository.GetRolePermissions(models.DBConn, role)
+		if err != nil {
+			return roles, rolePermissions, err
+		}
+	}
+	return roles, rolePermissions, nil
+}
+
+func DeleteRole(envPath string, name string) error {
+	initiateRoleTask(envPath)
+	role, err := getRoleByName(name)
+	if err != nil {
+		return err
+	}
+	return repository.DeleteRole(models.DBConn, role)
+}
+
+func ListPermission(envPath string) ([]models.Permission, error) {
+	initiateRoleTask(envPath)
+	return repository.GetAllPermission(models.DBConn)
+}
+
+func GrantPermissionToRole(envPath string, roleName string, permissionName string) error {
+	initiateRoleTask(envPath)
+	role, err := getRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	permission, err := getPermissionByName(permissionName)
+	if err != nil {
+		return err
+	}
+	return repository.GrantPermissionToRole(models.DBConn, role, permission, time.Now())
+}
+
+func RevokePermissionFromRole(envPath string, roleName string, permissionName string) error {
+	initiateRoleTask(envPath)
+	role, err := getRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	permission, err := getPermissionByName(permissionName)
+	if err != nil {
+		return err
+	}
+	return repository.RevokePermissionFromRole(models.DBConn, role, permission)
+}
+
+func AssignRoleToUser(envPath string, roleName string, username string) error {
+	initiateRoleTask(envPath)
+	role, err := getRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	user, err := getUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	return repository.AssignRoleToUser(models.DBConn, user, role, time.Now())
+}
+
+func UnassignRoleFromUser(envPath string, roleName string, username string) error {
+	initiateRoleTask(envPath)
+	role, err := getRoleByName(roleName)
+	if err != nil {
+		return err
+	}
+	user, err := getUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	return repository.UnassignRoleFromUser(models.DBConn, user, role)
+}
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"github.com/BimaAdi/fiberGormBoilerplate/core"
 	_ "github.com/BimaAdi/fiberGormBoilerplate/docs"
 	"github.com/BimaAdi/fiberGormBoilerplate/models"
 	"github.com/BimaAdi/fiberGormBoilerplate/routes"
@@ -16,6 +17,17 @@ func RunServer(envPath string) {
 
 	// Initiate Database connection
 	models.Initiate()
+	core.TokenRevocationStore = core.NewDatabaseRevocationStore(models.DBConn)
+	if settings.SMTP_HOST != "" {
+		core.UserNotifier = core.NewSMTPNotifier(settings.SMTP_HOST, settings.SMTP_PORT, settings.SMTP_USERNAME, settings.SMTP_PASSWORD, settings.SMTP_FROM)
+	}
+	if settings.PASSWORD_BREACHED_LIST_PATH != "" {
+		breachedChecker, err := core.NewHashPrefixBreachedPasswordChecker(settings.PASSWORD_BREACHED_LIST_PATH)
+		if err != nil {
+			panic(err.Error())
+		}
+		core.PasswordBreachedChecker = breachedChecker
+	}
 
 	// development or release
 	// if settings.GIN_MODE == "release" {
@@ -36,9 +48,8 @@ func RunServer(envPath string) {
 		MaxAge:           0,
 	}))
 
-	// TODO Initiate static and template
-	// router.Static("/assets", "./assets")
-	// router.LoadHTMLGlob("templates/*.html")
+	// Initiate static, template served from settings.TEMPLATE_DIRECTORY
+	app.Static("/assets", "./assets")
 
 	// Initialize fiber route
 	app = routes.InitiateRoutes(app)